@@ -0,0 +1,107 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package corpus gives the tests of the generators access to the corpus of
+// switch descriptors of hwpreader/testdata and to their golden outputs.
+//
+// Each board of the corpus is a raw descriptor, <board>.bin, encoded from the
+// hardware profile source <board>.c. The boards are modelled after known
+// designs, such as the XMG1915-10E, as vendor profiles cannot be
+// redistributed. The outputs of the generators are kept
+// next to it as <board>.<format>. After an intended change of an output,
+// rewrite them from the hwpreader directory with:
+//
+//	go test ./csrc ./dot ./dts ./hexmap ./openwrt ./panel ./rtl -run TestCorpus -update
+package corpus
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Dir is the corpus directory, relative to the packages of hwpreader.
+const Dir = "../testdata"
+
+var update = flag.Bool("update", false, "rewrite the golden files of the corpus")
+
+// Board is a switch descriptor of the corpus.
+type Board struct {
+	Name   string
+	Layout *rtl.Layout
+	Switch *rtl.Switch
+	// Data holds the raw descriptor and Fields the fields decoded from it.
+	Data   []byte
+	Fields []rtl.Field
+}
+
+// Boards decodes the descriptors of the corpus. Decoding failures and
+// warnings fail the test: the corpus holds valid descriptors only.
+func Boards(t testing.TB) []*Board {
+	t.Helper()
+	blobs, err := filepath.Glob(filepath.Join(Dir, "*.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) == 0 {
+		t.Fatalf("no descriptor in %s", Dir)
+	}
+	var boards []*Board
+	for _, blob := range blobs {
+		data, err := os.ReadFile(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := rtl.DetectLayout(data)
+		if err != nil {
+			t.Fatalf("%s: %v", blob, err)
+		}
+		sw := &rtl.Switch{}
+		fields, warnings, err := sw.DecodeFields(data, l, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", blob, err)
+		}
+		for _, w := range warnings {
+			t.Errorf("%s: %s", blob, w)
+		}
+		boards = append(boards, &Board{Name: strings.TrimSuffix(filepath.Base(blob), ".bin"), Layout: l, Switch: sw, Data: data, Fields: fields})
+	}
+	return boards
+}
+
+// Lookup returns the board of the corpus called name. The descriptors are
+// decoded anew at each call: tests may modify the board for their edge cases.
+func Lookup(t testing.TB, name string) *Board {
+	t.Helper()
+	for _, b := range Boards(t) {
+		if b.Name == name {
+			return b
+		}
+	}
+	t.Fatalf("no board %s in %s", name, Dir)
+	return nil
+}
+
+// Golden compares the output of the board in format with its golden file, or
+// rewrites the golden file with -update.
+func Golden(t testing.TB, b *Board, format string, got []byte) {
+	t.Helper()
+	path := filepath.Join(Dir, b.Name+"."+format)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file %s:\n%s", format, path, got)
+	}
+}
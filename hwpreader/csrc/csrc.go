@@ -0,0 +1,521 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package csrc parses the hardware profiles sources (hw_profiles/*.c) of the
+// Realtek SDK into rtl structures.
+//
+// Only the subset of C used by these files is supported: global variables
+// initialized with designated initializers, constant expressions and object
+// or function-like #define macros. Other directives are ignored, so all the
+// branches of conditional blocks are parsed.
+package csrc
+
+import (
+	"fmt"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Descriptor is a switch descriptor (hwp_swDescp_t) defined in the source.
+type Descriptor struct {
+	Name   string
+	Switch *rtl.Switch
+}
+
+// File holds the result of the parsing of a source.
+type File struct {
+	decls []*declaration
+	eval  *evaluator
+}
+
+// Parse parses a hardware profile source.
+func Parse(src []byte) (*File, error) {
+	p, err := newParser(string(src))
+	if err != nil {
+		return nil, err
+	}
+	decls, err := p.declarations()
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		decls: decls,
+		eval:  &evaluator{symbols: symbols(), macros: p.macros},
+	}, nil
+}
+
+// Switches returns the switch descriptors of the file, in definition order.
+func (f *File) Switches() ([]*Descriptor, error) {
+	var descs []*Descriptor
+	for _, d := range f.decls {
+		if d.typ != "hwp_swDescp_t" || d.ptr || d.array {
+			continue
+		}
+		sw, err := f.newSwitch(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", d.name, err)
+		}
+		descs = append(descs, &Descriptor{Name: d.name, Switch: sw})
+	}
+	return descs, nil
+}
+
+// Switch returns the switch descriptor called name.
+func (f *File) Switch(name string) (*rtl.Switch, error) {
+	descs, err := f.Switches()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range descs {
+		if d.Name == name {
+			return d.Switch, nil
+		}
+	}
+	return nil, fmt.Errorf("switch descriptor %s not found", name)
+}
+
+// Profile is a hardware profile (hwp_hwProfile_t) defined in the source.
+type Profile struct {
+	Name    string
+	Profile *rtl.HwProfile
+}
+
+// Profiles returns the hardware profiles of the file, in definition order. The
+// switch descriptors they reference must be defined in the file.
+func (f *File) Profiles() ([]*Profile, error) {
+	descs, err := f.Switches()
+	if err != nil {
+		return nil, err
+	}
+	switches := make(map[string]*rtl.Switch)
+	for _, d := range descs {
+		switches[d.Name] = d.Switch
+	}
+	var profiles []*Profile
+	for _, d := range f.decls {
+		if d.typ != "hwp_hwProfile_t" || d.ptr || d.array {
+			continue
+		}
+		hp, err := f.newProfile(d, switches)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", d.name, err)
+		}
+		profiles = append(profiles, &Profile{Name: d.name, Profile: hp})
+	}
+	return profiles, nil
+}
+
+// assignment is a leaf of an initializer: the field path, e.g.
+// port.descp[3].mac_id, and its value.
+type assignment struct {
+	path []designator
+	val  *value
+}
+
+// key returns the path with indexes elided, e.g. port.descp[].mac_id.
+func (a *assignment) key() string {
+	var b strings.Builder
+	for _, d := range a.path {
+		if d.index != nil {
+			b.WriteString("[]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(d.field)
+	}
+	return b.String()
+}
+
+// flatten walks an initializer list and returns its leaves. Positional entries
+// are given an explicit index designator, as array elements.
+func (f *File) flatten(v *value, prefix []designator) ([]*assignment, error) {
+	if !v.isList() {
+		return []*assignment{{path: prefix, val: v}}, nil
+	}
+	var res []*assignment
+	next := 0
+	for _, e := range v.list {
+		desig := e.desig
+		if len(desig) == 0 || desig[0].index != nil {
+			if len(desig) > 0 {
+				n, err := f.eval.number(desig[0].index)
+				if err != nil {
+					return nil, err
+				}
+				next = int(n)
+				desig = desig[1:]
+			}
+			desig = append([]designator{{index: indexItems(next)}}, desig...)
+			next++
+		}
+		path := append(append([]designator{}, prefix...), desig...)
+		leaves, err := f.flatten(e.val, path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, leaves...)
+	}
+	return res, nil
+}
+
+func indexItems(i int) []item {
+	return []item{{tok: NUMBER, lit: fmt.Sprint(i)}}
+}
+
+// indexes returns the indexes of the path of an assignment.
+func (f *File) indexes(a *assignment) ([]int, error) {
+	var idx []int
+	for _, d := range a.path {
+		if d.index == nil {
+			continue
+		}
+		n, err := f.eval.number(d.index)
+		if err != nil {
+			return nil, err
+		}
+		idx = append(idx, int(n))
+	}
+	return idx, nil
+}
+
+// table collects the entries of a descriptor table indexed by their position
+// in the initializer.
+type table[T any] struct {
+	name    string
+	max     int
+	entries map[int]*T
+}
+
+func newTable[T any](name string, max int) *table[T] {
+	return &table[T]{name: name, max: max, entries: make(map[int]*T)}
+}
+
+func (t *table[T]) at(i int) (*T, error) {
+	if i < 0 || i >= t.max {
+		return nil, fmt.Errorf("%s[%d] out of range", t.name, i)
+	}
+	if e, ok := t.entries[i]; ok {
+		return e, nil
+	}
+	e := new(T)
+	t.entries[i] = e
+	return e, nil
+}
+
+// list returns the entries up to the end marker, as the SDK does.
+func (t *table[T]) list(end func(*T) bool) ([]*T, error) {
+	var res []*T
+	for i := range t.max {
+		e, ok := t.entries[i]
+		if !ok {
+			if len(t.entries) == len(res) {
+				// No end marker: the whole initialized table is used.
+				return res, nil
+			}
+			return nil, fmt.Errorf("%s[%d] is missing", t.name, i)
+		}
+		if end(e) {
+			return res, nil
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func (f *File) newSwitch(d *declaration) (*rtl.Switch, error) {
+	if !d.init.isList() {
+		return nil, fmt.Errorf("line %d: expected an initializer list", d.line)
+	}
+	leaves, err := f.flatten(d.init, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sw := &rtl.Switch{Leds: &rtl.Leds{}}
+	ports := newTable[rtl.Port]("port.descp", rtl.RTK_MAX_PORT_PER_UNIT)
+	serdes := newTable[rtl.Serdes]("serdes.descp", rtl.RTK_MAX_SDS_PER_UNIT)
+	converters := newTable[rtl.SerdesConverter]("sc.descp", rtl.RTK_MAX_SC_PER_UNIT)
+	phys := newTable[rtl.Phy]("phy.descp", rtl.RTK_MAX_PHY_PER_UNIT)
+
+	for _, a := range leaves {
+		key := a.key()
+		if a.val.isList() {
+			return nil, fmt.Errorf("line %d: unexpected initializer list for %s", a.val.line, key)
+		}
+		v, err := f.eval.number(a.val.expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		idx, err := f.indexes(a)
+		if err != nil {
+			return nil, err
+		}
+
+		field := key[strings.LastIndexByte(key, '.')+1:]
+		switch {
+		case !strings.Contains(key, "."):
+			err = setSwitchField(sw, field, v)
+		case strings.HasPrefix(key, "port.descp[]."):
+			var p *rtl.Port
+			if p, err = ports.at(idx[0]); err == nil {
+				err = setPortField(p, field, v)
+			}
+		case strings.HasPrefix(key, "serdes.descp[]."):
+			var s *rtl.Serdes
+			if s, err = serdes.at(idx[0]); err == nil {
+				err = setSerdesField(s, field, v)
+			}
+		case strings.HasPrefix(key, "sc.descp[]."):
+			var c *rtl.SerdesConverter
+			if c, err = converters.at(idx[0]); err == nil {
+				err = setConverterField(c, field, v)
+			}
+		case strings.HasPrefix(key, "phy.descp[]."):
+			var p *rtl.Phy
+			if p, err = phys.at(idx[0]); err == nil {
+				err = setPhyField(p, field, v)
+			}
+		case key == "led.descp.led_active":
+			sw.Leds.Active, sw.Leds.HasActive = rtl.LedActive(v), true
+		case key == "led.descp.led_if_sel":
+			sw.Leds.LedIfSel = rtl.LedIfSel(v)
+		case key == "led.descp.led_definition_set[].led[]":
+			if idx[0] >= rtl.RTK_MAX_LED_MOD || idx[1] >= rtl.RTK_MAX_LED_PER_PORT {
+				err = fmt.Errorf("led_definition_set[%d].led[%d] out of range", idx[0], idx[1])
+			} else {
+				sw.Leds.LedSet[idx[0]].Led[idx[1]] = rtl.LedWord(v)
+			}
+		default:
+			err = fmt.Errorf("unknown field")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", a.val.line, key, err)
+		}
+	}
+
+	if sw.Ports, err = ports.list(func(p *rtl.Port) bool { return p.MacId == rtl.HWP_END }); err != nil {
+		return nil, err
+	}
+	if sw.Serdes, err = serdes.list(func(s *rtl.Serdes) bool { return s.Id == rtl.HWP_END }); err != nil {
+		return nil, err
+	}
+	if sw.Converters, err = converters.list(func(c *rtl.SerdesConverter) bool { return c.Chip == rtl.HWP_END }); err != nil {
+		return nil, err
+	}
+	if sw.Phys, err = phys.list(func(p *rtl.Phy) bool { return p.Chip == rtl.HWP_END }); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (f *File) newProfile(d *declaration, switches map[string]*rtl.Switch) (*rtl.HwProfile, error) {
+	if !d.init.isList() {
+		return nil, fmt.Errorf("line %d: expected an initializer list", d.line)
+	}
+	leaves, err := f.flatten(d.init, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hp := &rtl.HwProfile{}
+	units := newTable[*rtl.Switch]("swDescp", rtl.RTK_MAX_NUM_OF_UNIT_LOCAL+1)
+	count := -1
+	for _, a := range leaves {
+		key := a.key()
+		if a.val.isList() {
+			return nil, fmt.Errorf("line %d: unexpected initializer list for %s", a.val.line, key)
+		}
+		v, err := f.eval.eval(a.val.expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		switch key {
+		case "identifier.name":
+			if v.kind != TEXT {
+				err = fmt.Errorf("expected a string, got %s", v)
+			}
+			hp.Identifier.Name = v.text
+		case "swDescp[]":
+			var idx []int
+			var unit **rtl.Switch
+			if idx, err = f.indexes(a); err == nil {
+				unit, err = units.at(idx[0])
+			}
+			switch {
+			case err != nil:
+			case v.kind == REFERENCE:
+				if *unit = switches[v.text]; *unit == nil {
+					err = fmt.Errorf("switch descriptor %s not found", v.text)
+				}
+			case v.kind == NUMERIC && v.num == 0:
+				// NULL terminates the table.
+			default:
+				err = fmt.Errorf("expected a switch descriptor address, got %s", v)
+			}
+		default:
+			if v.kind != NUMERIC {
+				err = fmt.Errorf("expected a number, got %s", v)
+				break
+			}
+			switch key {
+			case "identifier.id":
+				hp.Identifier.Id = uint32(v.num)
+			case "soc.swDescp_index":
+				hp.Soc.SwDescpIndex = uint32(v.num)
+			case "soc.slaveInterruptPin":
+				hp.Soc.SlaveInterruptPin = uint32(v.num)
+			case "sw_count":
+				count = int(v.num)
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", a.val.line, key, err)
+		}
+	}
+
+	list, err := units.list(func(sw **rtl.Switch) bool { return *sw == nil })
+	if err != nil {
+		return nil, err
+	}
+	for _, sw := range list {
+		hp.Units = append(hp.Units, *sw)
+	}
+	if count >= 0 && count != len(hp.Units) {
+		return nil, fmt.Errorf("sw_count is %d but %d units are defined", count, len(hp.Units))
+	}
+	return hp, nil
+}
+
+func uint8Field(v uint64) (uint8, error) {
+	if v > 0xff {
+		return 0, fmt.Errorf("value 0x%x overflows 8 bits", v)
+	}
+	return uint8(v), nil
+}
+
+func setSwitchField(sw *rtl.Switch, field string, v uint64) error {
+	var err error
+	switch field {
+	case "chip_id":
+		sw.ChipId = rtl.RtlChipId(v)
+	case "swcore_supported":
+		sw.SwitchCoreSupported = v != 0
+	case "swcore_access_method":
+		sw.SwitchCoreAccessMethod = rtl.SwitchRegAccMethod(v)
+	case "swcore_spi_chip_select":
+		sw.SwitchCoreSpiChipSelect, err = uint8Field(v)
+	case "nic_supported":
+		sw.NicSupported = v != 0
+	default:
+		return fmt.Errorf("unknown field")
+	}
+	return err
+}
+
+func setPortField(p *rtl.Port, field string, v uint64) error {
+	if field == "sds_idx" {
+		p.SdsIdx = uint32(v)
+		return nil
+	}
+	b, err := uint8Field(v)
+	if err != nil {
+		return err
+	}
+	switch field {
+	case "mac_id":
+		p.MacId = b
+	case "phy_idx":
+		p.PhyIdx = b
+	case "smi":
+		p.Smi = b
+	case "phy_addr":
+		p.PhyAddr = b
+	case "attr":
+		p.Attr = rtl.PortAttr(b)
+	case "eth":
+		p.Eth = rtl.EthType(b)
+	case "medi":
+		p.Medi = rtl.Medium(b)
+	case "sc_idx":
+		p.ScIdx = b
+	case "led_c":
+		p.LedC = rtl.LedSel(b)
+	case "led_f":
+		p.LedF = rtl.LedSel(b)
+	case "led_layout":
+		p.LedLayout = rtl.LedLayout(b)
+	case "phy_mdi_pin_swap":
+		p.PhyMdiPinSwap = b != 0
+	case "phy_mdi_pair_swap":
+		p.PhyMdiPairSwap = b
+	default:
+		return fmt.Errorf("unknown field")
+	}
+	return nil
+}
+
+func polarity(v uint64) (rtl.SerdesPolarity, error) {
+	switch p := rtl.SerdesPolarity(v); p {
+	case rtl.SERDES_POLARITY_NORMAL, rtl.SERDES_POLARITY_CHANGE:
+		return p, nil
+	}
+	return 0, fmt.Errorf("invalid polarity %d", v)
+}
+
+func setSerdesField(s *rtl.Serdes, field string, v uint64) error {
+	var err error
+	switch field {
+	case "sds_id":
+		s.Id, err = uint8Field(v)
+	case "mode":
+		if v >= uint64(rtl.RTK_MII_END) {
+			return fmt.Errorf("invalid mode %d", v)
+		}
+		s.Mode = rtl.SerdesMode(v)
+	case "rx_polarity":
+		s.RxPolarity, err = polarity(v)
+	case "tx_polarity":
+		s.TxPolarity, err = polarity(v)
+	default:
+		return fmt.Errorf("unknown field")
+	}
+	return err
+}
+
+func setConverterField(c *rtl.SerdesConverter, field string, v uint64) error {
+	var err error
+	switch field {
+	case "chip":
+		c.Chip = uint32(v)
+	case "smi":
+		c.Smi, err = uint8Field(v)
+	case "phy_addr":
+		c.PhyAddr, err = uint8Field(v)
+	case "rx_polarity":
+		c.RxPolarity, err = polarity(v)
+	case "tx_polarity":
+		c.TxPolarity, err = polarity(v)
+	default:
+		return fmt.Errorf("unknown field")
+	}
+	return err
+}
+
+func setPhyField(p *rtl.Phy, field string, v uint64) error {
+	var err error
+	switch field {
+	case "chip":
+		p.Chip = rtl.PhyChipId(v)
+	case "mac_id":
+		p.MacId, err = uint8Field(v)
+	case "phy_max":
+		p.PhyMax, err = uint8Field(v)
+	default:
+		return fmt.Errorf("unknown field")
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package csrc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+const profile = `
+/*
+ * Copyright (C) 2009-2016 Realtek Semiconductor Corp.
+ */
+#include <hwp/hw_profile.h>
+
+#define BOARD_LED_10G   0xA01   // link/act on 10G
+#define LED_WORD(sel, \
+                 en)    (((sel) << 4) | (en))
+
+static hwp_swDescp_t board_swDescp = {
+
+    .chip_id                    = RTL9302B_CHIP_ID,
+    .swcore_supported           = TRUE,
+    .swcore_access_method       = HWP_SW_ACC_MEM,
+    .swcore_spi_chip_select     = HWP_NOT_USED,
+    .nic_supported              = TRUE,
+
+    .port.descp = {
+        { .mac_id = 0,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 0, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        { .mac_id = 24, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 6, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 1, .led_layout = SINGLE_SET, },
+        [2] = { .mac_id = 28, .attr = HWP_CPU, .eth = HWP_NONE, .medi = HWP_NONE, .sds_idx = SBM(2) | SBM(3), .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = HWP_NONE, .led_layout = HWP_NONE, },
+        { .mac_id = HWP_END },
+    },  /* port.descp */
+
+    .led.descp = {
+        .led_active = LED_ACTIVE_HIGH,
+        .led_if_sel = LED_IF_SEL_SERIAL,
+        .led_definition_set[0].led[0] = BOARD_LED_10G,
+        .led_definition_set[0].led[1] = LED_WORD(0x1, 1),
+        .led_definition_set[1].led[0] = 0xffff,
+    },/* led.descp */
+
+    .serdes.descp = {
+        [0] = { .sds_id = 2, .mode = RTK_MII_USXGMII_10GQXGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_CHANGE },
+        [1] = { .sds_id = 6, .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_CHANGE, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [2] = { .sds_id = HWP_END },
+    }, /* serdes.descp */
+
+    .phy.descp = {
+        [0] = { .chip = RTK_PHYTYPE_RTL8224, .mac_id = 0, .phy_max = 4 },
+        [1] = { .chip = HWP_END },
+    }
+};
+
+hwp_swDescp_t *board_list[] = { &board_swDescp, NULL };
+
+#define BOARD_PROFILE_ID    0x9302b01
+
+hwp_hwProfile_t board_hwp = {
+    .identifier.name        = "board_" "9302b",
+    .identifier.id          = BOARD_PROFILE_ID,
+    .soc.swDescp_index      = 0,
+    .soc.slaveInterruptPin  = HWP_NONE,
+    .sw_count               = 1,
+    .swDescp = {
+        [0]                 = &board_swDescp,
+        [1]                 = NULL,
+    }
+};
+
+int board_init(void)
+{
+    return 0;
+}
+`
+
+func TestSwitches(t *testing.T) {
+	f, err := Parse([]byte(profile))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	descs, err := f.Switches()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(descs) != 1 || descs[0].Name != "board_swDescp" {
+		t.Fatalf("unexpected descriptors: %v", descs)
+	}
+
+	sw, err := f.Switch("board_swDescp")
+	if err != nil {
+		t.Fatalf("board_swDescp: %v", err)
+	}
+	if sw.ChipId != rtl.RTL9302B_CHIP_ID {
+		t.Errorf("expected chip %v, got %v", rtl.RTL9302B_CHIP_ID, sw.ChipId)
+	}
+	if !sw.SwitchCoreSupported || !sw.NicSupported || sw.SwitchCoreAccessMethod != rtl.HWP_SW_ACC_MEM {
+		t.Errorf("unexpected switch core settings: %+v", sw)
+	}
+	if len(sw.Ports) != 3 {
+		t.Fatalf("expected 3 ports, got %d", len(sw.Ports))
+	}
+	if p := sw.Ports[1]; p.MacId != 24 || p.SdsIdx != 6 || p.Eth != rtl.HWP_XGE || p.Medi != rtl.HWP_FIBER || p.LedF != 1 {
+		t.Errorf("unexpected port 1: %v", p)
+	}
+	if p := sw.Ports[2]; p.Attr != rtl.HWP_CPU || p.SdsIdx != 0xc {
+		t.Errorf("unexpected port 2: %v", p)
+	}
+	if len(sw.Serdes) != 2 {
+		t.Fatalf("expected 2 serdes, got %d", len(sw.Serdes))
+	}
+	if s := sw.Serdes[0]; s.Id != 2 || s.Mode != rtl.RTK_MII_USXGMII_10GQXGMII || s.TxPolarity != rtl.SERDES_POLARITY_CHANGE {
+		t.Errorf("unexpected serdes 0: %v", s)
+	}
+	if len(sw.Phys) != 1 || sw.Phys[0].Chip != rtl.RTK_PHYTYPE_RTL8224 || sw.Phys[0].PhyMax != 4 {
+		t.Errorf("unexpected phys: %v", sw.Phys)
+	}
+	if sw.Leds.Active != rtl.LED_ACTIVE_HIGH || !sw.Leds.HasActive {
+		t.Errorf("unexpected led_active %v", sw.Leds.Active)
+	}
+	if sw.Leds.LedIfSel != rtl.LED_IF_SEL_SERIAL {
+		t.Errorf("unexpected led_if_sel %v", sw.Leds.LedIfSel)
+	}
+	if got := sw.Leds.LedSet[0].Led; got[0] != 0xa01 || got[1] != 0x11 {
+		t.Errorf("unexpected led set 0: %x", got)
+	}
+	if got := sw.Leds.LedSet[1].Led[0]; got != 0xffff {
+		t.Errorf("unexpected led set 1: %x", got)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	f, err := Parse([]byte(profile))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	profiles, err := f.Profiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Name != "board_hwp" {
+		t.Fatalf("unexpected profiles: %v", profiles)
+	}
+	hp := profiles[0].Profile
+	if want := (rtl.Identifier{Name: "board_9302b", Id: 0x9302b01}); hp.Identifier != want {
+		t.Errorf("expected identifier %+v, got %+v", want, hp.Identifier)
+	}
+	if hp.Soc.SlaveInterruptPin != rtl.HWP_NONE {
+		t.Errorf("unexpected soc: %+v", hp.Soc)
+	}
+	sw, err := f.Switch("board_swDescp")
+	if err != nil {
+		t.Fatalf("board_swDescp: %v", err)
+	}
+	if len(hp.Units) != 1 || hp.Units[0].ChipId != sw.ChipId || len(hp.Units[0].Ports) != len(sw.Ports) {
+		t.Errorf("unexpected units: %v", hp.Units)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "UnknownIdentifier",
+			src:  "hwp_swDescp_t s = { .chip_id = RTL0000_CHIP_ID };",
+			want: "unknown identifier",
+		},
+		{
+			name: "UnknownField",
+			src:  "hwp_swDescp_t s = { .port.descp = { { .mac_id = 0, .speed = 1 } } };",
+			want: "port.descp[].speed: unknown field",
+		},
+		{
+			name: "Overflow",
+			src:  "hwp_swDescp_t s = { .port.descp = { { .mac_id = 0x100 } } };",
+			want: "overflows",
+		},
+		{
+			name: "OutOfRange",
+			src:  "hwp_swDescp_t s = { .phy.descp = { [8] = { .chip = 1 } } };",
+			want: "phy.descp[8] out of range",
+		},
+		{
+			name: "MissingEntry",
+			src:  "hwp_swDescp_t s = { .serdes.descp = { [1] = { .sds_id = 1 } } };",
+			want: "serdes.descp[0] is missing",
+		},
+		{
+			name: "UnknownUnit",
+			src:  "hwp_hwProfile_t p = { .swDescp = { &missing_swDescp } };",
+			want: "switch descriptor missing_swDescp not found",
+		},
+		{
+			name: "UnitCount",
+			src:  "hwp_swDescp_t s = { .chip_id = 1 }; hwp_hwProfile_t p = { .sw_count = 2, .swDescp = { &s, NULL } };",
+			want: "sw_count is 2 but 1 units are defined",
+		},
+		{
+			name: "UnterminatedComment",
+			src:  "/* hwp_swDescp_t s = { };",
+			want: "unterminated comment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.src))
+			if err == nil {
+				_, err = f.Profiles()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestCorpus checks that the descriptors of the corpus are the encoding of
+// their source.
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join(corpus.Dir, b.Name+".c"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := Parse(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			descs, err := f.Switches()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(descs) != 1 {
+				t.Fatalf("expected a single descriptor, got %d", len(descs))
+			}
+			if changes := rtl.Diff(descs[0].Switch, b.Switch); len(changes) != 0 || !reflect.DeepEqual(descs[0].Switch, b.Switch) {
+				t.Errorf("descriptor differs from its source: %v", changes)
+			}
+		})
+	}
+}
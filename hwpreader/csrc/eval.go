@@ -0,0 +1,388 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package csrc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type scalarKind int
+
+const (
+	NUMERIC scalarKind = iota
+	TEXT
+	REFERENCE
+)
+
+// scalar is the result of a constant expression: a number, a string literal or
+// the address of a global variable (&name).
+type scalar struct {
+	kind scalarKind
+	num  uint64
+	text string
+}
+
+func (s scalar) String() string {
+	switch s.kind {
+	case TEXT:
+		return strconv.Quote(s.text)
+	case REFERENCE:
+		return "&" + s.text
+	default:
+		return fmt.Sprintf("0x%x", s.num)
+	}
+}
+
+// Maximum depth of nested macro expansions.
+const maxExpansionDepth = 32
+
+// evaluator computes constant expressions made of literals, SDK symbols and
+// macros defined in the source.
+type evaluator struct {
+	symbols map[string]uint64
+	macros  map[string]*macro
+	depth   int
+}
+
+// exprParser is a precedence climbing parser over a slice of tokens.
+type exprParser struct {
+	ev    *evaluator
+	items []item
+	pos   int
+}
+
+func (e *evaluator) eval(items []item) (scalar, error) {
+	if len(items) == 0 {
+		return scalar{}, fmt.Errorf("empty expression")
+	}
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth > maxExpansionDepth {
+		return scalar{}, fmt.Errorf("line %d: macro expansion too deep", items[0].line)
+	}
+	ep := &exprParser{ev: e, items: items}
+	v, err := ep.binary(0)
+	if err != nil {
+		return scalar{}, err
+	}
+	if ep.pos < len(ep.items) {
+		it := ep.items[ep.pos]
+		return scalar{}, fmt.Errorf("line %d: unexpected %s in expression", it.line, it)
+	}
+	return v, nil
+}
+
+func (e *evaluator) number(items []item) (uint64, error) {
+	v, err := e.eval(items)
+	if err != nil {
+		return 0, err
+	}
+	if v.kind != NUMERIC {
+		return 0, fmt.Errorf("line %d: %s is not a number", items[0].line, v)
+	}
+	return v.num, nil
+}
+
+func (ep *exprParser) peek() (item, bool) {
+	if ep.pos >= len(ep.items) {
+		return item{}, false
+	}
+	return ep.items[ep.pos], true
+}
+
+func (ep *exprParser) line() int {
+	if it, ok := ep.peek(); ok {
+		return it.line
+	}
+	if len(ep.items) > 0 {
+		return ep.items[len(ep.items)-1].line
+	}
+	return 0
+}
+
+// Binary operators by increasing precedence.
+var precedences = map[string]int{
+	"|":  1,
+	"^":  2,
+	"&":  3,
+	"<<": 4,
+	">>": 4,
+	"+":  5,
+	"-":  5,
+	"*":  6,
+	"/":  6,
+	"%":  6,
+}
+
+func (ep *exprParser) binary(minPrec int) (scalar, error) {
+	lhs, err := ep.unary()
+	if err != nil {
+		return scalar{}, err
+	}
+	for {
+		it, ok := ep.peek()
+		if !ok || it.tok != PUNCT {
+			return lhs, nil
+		}
+		prec, ok := precedences[it.lit]
+		if !ok || prec <= minPrec {
+			return lhs, nil
+		}
+		ep.pos++
+		rhs, err := ep.binary(prec)
+		if err != nil {
+			return scalar{}, err
+		}
+		if lhs.kind != NUMERIC || rhs.kind != NUMERIC {
+			return scalar{}, fmt.Errorf("line %d: invalid operands to %q", it.line, it.lit)
+		}
+		a, b := lhs.num, rhs.num
+		switch it.lit {
+		case "|":
+			a |= b
+		case "^":
+			a ^= b
+		case "&":
+			a &= b
+		case "<<":
+			a <<= b
+		case ">>":
+			a >>= b
+		case "+":
+			a += b
+		case "-":
+			a -= b
+		case "*":
+			a *= b
+		case "/", "%":
+			if b == 0 {
+				return scalar{}, fmt.Errorf("line %d: division by zero", it.line)
+			}
+			if it.lit == "/" {
+				a /= b
+			} else {
+				a %= b
+			}
+		}
+		lhs = scalar{num: a}
+	}
+}
+
+func (ep *exprParser) unary() (scalar, error) {
+	it, ok := ep.peek()
+	if !ok {
+		return scalar{}, fmt.Errorf("line %d: unexpected end of expression", ep.line())
+	}
+	if it.tok == PUNCT {
+		switch it.lit {
+		case "-", "~", "!", "+":
+			ep.pos++
+			v, err := ep.unary()
+			if err != nil {
+				return scalar{}, err
+			}
+			if v.kind != NUMERIC {
+				return scalar{}, fmt.Errorf("line %d: invalid operand to %q", it.line, it.lit)
+			}
+			switch it.lit {
+			case "-":
+				v.num = -v.num
+			case "~":
+				v.num = ^v.num
+			case "!":
+				if v.num == 0 {
+					v.num = 1
+				} else {
+					v.num = 0
+				}
+			}
+			return v, nil
+		case "&":
+			ep.pos++
+			name, ok := ep.peek()
+			if !ok || name.tok != IDENT {
+				return scalar{}, fmt.Errorf("line %d: expected variable name after '&'", it.line)
+			}
+			ep.pos++
+			return scalar{kind: REFERENCE, text: name.lit}, nil
+		case "(":
+			if ep.isCast() {
+				ep.pos += 3
+				return ep.unary()
+			}
+			ep.pos++
+			v, err := ep.binary(0)
+			if err != nil {
+				return scalar{}, err
+			}
+			if closing, ok := ep.peek(); !ok || closing.lit != ")" {
+				return scalar{}, fmt.Errorf("line %d: missing ')'", it.line)
+			}
+			ep.pos++
+			return v, nil
+		}
+	}
+	return ep.primary()
+}
+
+// isCast detects "(type)" prefixes: an unknown identifier alone in
+// parentheses and followed by an operand.
+func (ep *exprParser) isCast() bool {
+	if ep.pos+3 >= len(ep.items) {
+		return false
+	}
+	name, closing := ep.items[ep.pos+1], ep.items[ep.pos+2]
+	if name.tok != IDENT || closing.lit != ")" {
+		return false
+	}
+	if _, ok := ep.ev.symbols[name.lit]; ok {
+		return false
+	}
+	if _, ok := ep.ev.macros[name.lit]; ok {
+		return false
+	}
+	next := ep.items[ep.pos+3]
+	return next.tok != PUNCT || next.lit == "(" || next.lit == "~" || next.lit == "-"
+}
+
+func (ep *exprParser) primary() (scalar, error) {
+	it := ep.items[ep.pos]
+	ep.pos++
+	switch it.tok {
+	case NUMBER:
+		lit := strings.TrimRight(strings.ToLower(it.lit), "ul")
+		n, err := strconv.ParseUint(lit, 0, 64)
+		if err != nil {
+			return scalar{}, fmt.Errorf("line %d: invalid number %s", it.line, it)
+		}
+		return scalar{num: n}, nil
+	case CHAR:
+		s, err := strconv.Unquote(it.lit)
+		if err != nil || len(s) != 1 {
+			return scalar{}, fmt.Errorf("line %d: invalid character %s", it.line, it)
+		}
+		return scalar{num: uint64(s[0])}, nil
+	case STRING:
+		// Adjacent string literals are concatenated.
+		var b strings.Builder
+		for {
+			s, err := unquote(it.lit)
+			if err != nil {
+				return scalar{}, fmt.Errorf("line %d: invalid string %s", it.line, it)
+			}
+			b.WriteString(s)
+			next, ok := ep.peek()
+			if !ok || next.tok != STRING {
+				break
+			}
+			it = next
+			ep.pos++
+		}
+		return scalar{kind: TEXT, text: b.String()}, nil
+	case IDENT:
+		return ep.identifier(it)
+	}
+	return scalar{}, fmt.Errorf("line %d: unexpected %s in expression", it.line, it)
+}
+
+func (ep *exprParser) identifier(it item) (scalar, error) {
+	if m, ok := ep.ev.macros[it.lit]; ok {
+		if !m.fn {
+			return ep.ev.eval(m.body)
+		}
+		args, err := ep.arguments(it)
+		if err != nil {
+			return scalar{}, err
+		}
+		if len(args) != len(m.params) {
+			return scalar{}, fmt.Errorf("line %d: macro %s expects %d arguments", it.line, it.lit, len(m.params))
+		}
+		var body []item
+		for _, b := range m.body {
+			replaced := false
+			for i, param := range m.params {
+				if b.tok == IDENT && b.lit == param {
+					body = append(body, item{tok: PUNCT, lit: "(", line: it.line})
+					body = append(body, args[i]...)
+					body = append(body, item{tok: PUNCT, lit: ")", line: it.line})
+					replaced = true
+				}
+			}
+			if !replaced {
+				body = append(body, b)
+			}
+		}
+		return ep.ev.eval(body)
+	}
+	if fn, ok := builtins[it.lit]; ok {
+		args, err := ep.arguments(it)
+		if err != nil {
+			return scalar{}, err
+		}
+		var nums []uint64
+		for _, arg := range args {
+			n, err := ep.ev.number(arg)
+			if err != nil {
+				return scalar{}, err
+			}
+			nums = append(nums, n)
+		}
+		n, err := fn(nums)
+		if err != nil {
+			return scalar{}, fmt.Errorf("line %d: %s: %v", it.line, it.lit, err)
+		}
+		return scalar{num: n}, nil
+	}
+	if v, ok := ep.ev.symbols[it.lit]; ok {
+		return scalar{num: v}, nil
+	}
+	return scalar{}, fmt.Errorf("line %d: unknown identifier %s", it.line, it)
+}
+
+// arguments parses the parenthesized arguments of a macro call.
+func (ep *exprParser) arguments(name item) ([][]item, error) {
+	if open, ok := ep.peek(); !ok || open.lit != "(" {
+		return nil, fmt.Errorf("line %d: %s requires arguments", name.line, name.lit)
+	}
+	ep.pos++
+	var args [][]item
+	start, depth := ep.pos, 0
+	for ; ep.pos < len(ep.items); ep.pos++ {
+		it := ep.items[ep.pos]
+		if it.tok != PUNCT {
+			continue
+		}
+		switch {
+		case it.lit == "(":
+			depth++
+		case it.lit == ")" && depth > 0:
+			depth--
+		case it.lit == ")" || (it.lit == "," && depth == 0):
+			if ep.pos > start {
+				args = append(args, ep.items[start:ep.pos])
+			}
+			start = ep.pos + 1
+			if it.lit == ")" {
+				ep.pos++
+				return args, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("line %d: missing ')' after %s arguments", name.line, name.lit)
+}
+
+// Function-like macros of the SDK headers.
+var builtins = map[string]func(args []uint64) (uint64, error){
+	// SBM builds a serdes index bitmap.
+	"SBM": func(args []uint64) (uint64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("expects 1 argument")
+		}
+		if args[0] >= 32 {
+			return 0, fmt.Errorf("serdes %d out of range", args[0])
+		}
+		return 1 << args[0], nil
+	},
+}
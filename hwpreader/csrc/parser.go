@@ -0,0 +1,339 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package csrc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// declaration is a global variable definition with an initializer, e.g.:
+//
+//	static hwp_swDescp_t board_swDescp = { .chip_id = RTL9301_CHIP_ID, ... };
+type declaration struct {
+	typ   string
+	name  string
+	ptr   bool
+	array bool
+	init  *value
+	line  int
+}
+
+// value is either a scalar expression or a brace enclosed initializer list.
+type value struct {
+	expr []item
+	list []*entry
+	line int
+}
+
+func (v *value) isList() bool {
+	return v.list != nil
+}
+
+// entry is one element of an initializer list with its optional designators.
+type entry struct {
+	desig []designator
+	val   *value
+}
+
+// designator is either a field (.name) or an array index ([expr]).
+type designator struct {
+	field string
+	index []item
+}
+
+// macro is a #define directive found in the source.
+type macro struct {
+	params []string
+	body   []item
+	fn     bool
+}
+
+type parser struct {
+	items  []item
+	pos    int
+	macros map[string]*macro
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{macros: make(map[string]*macro)}
+	s := newScanner(src)
+	for {
+		it, err := s.scan()
+		if err != nil {
+			return nil, err
+		}
+		if it.tok == DIRECTIVE {
+			if err := p.directive(it); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.items = append(p.items, it)
+		if it.tok == EOF {
+			return p, nil
+		}
+	}
+}
+
+// directive records #define directives; any other directive is ignored, which
+// means conditional compilation is not evaluated.
+func (p *parser) directive(it item) error {
+	text := strings.TrimSpace(strings.TrimPrefix(it.lit, "#"))
+	if !strings.HasPrefix(text, "define") {
+		return nil
+	}
+	text = strings.TrimPrefix(text, "define")
+	if text == "" || (text[0] != ' ' && text[0] != '\t') {
+		return nil
+	}
+	text = strings.TrimLeft(text, " \t")
+	s := newScanner(text)
+	name, err := s.scan()
+	if err != nil {
+		return fmt.Errorf("line %d: %v", it.line, err)
+	}
+	if name.tok != IDENT {
+		return fmt.Errorf("line %d: invalid macro name %s", it.line, name)
+	}
+	m := &macro{}
+	// A function-like macro has its parameter list glued to its name.
+	if s.pos < len(s.src) && s.src[s.pos] == '(' {
+		m.fn = true
+		s.pos++
+		end := strings.IndexByte(s.src[s.pos:], ')')
+		if end < 0 {
+			return fmt.Errorf("line %d: invalid macro %s parameters", it.line, name.lit)
+		}
+		for _, param := range strings.Split(s.src[s.pos:s.pos+end], ",") {
+			if param = strings.TrimSpace(param); param != "" {
+				m.params = append(m.params, param)
+			}
+		}
+		s.pos += end + 1
+	}
+	for {
+		body, err := s.scan()
+		if err != nil {
+			return fmt.Errorf("line %d: %v", it.line, err)
+		}
+		if body.tok == EOF {
+			break
+		}
+		body.line = it.line
+		m.body = append(m.body, body)
+	}
+	p.macros[name.lit] = m
+	return nil
+}
+
+func (p *parser) peek() item {
+	return p.items[p.pos]
+}
+
+func (p *parser) next() item {
+	it := p.items[p.pos]
+	if it.tok != EOF {
+		p.pos++
+	}
+	return it
+}
+
+func (p *parser) is(lit string) bool {
+	it := p.peek()
+	return it.tok == PUNCT && it.lit == lit
+}
+
+func (p *parser) expect(lit string) error {
+	it := p.next()
+	if it.tok != PUNCT || it.lit != lit {
+		return fmt.Errorf("line %d: expected %q, found %s", it.line, lit, it)
+	}
+	return nil
+}
+
+// skipBalanced skips tokens up to the closing punctuator matching the opening
+// one that was just consumed.
+func (p *parser) skipBalanced(open, close string) error {
+	depth := 1
+	for depth > 0 {
+		it := p.next()
+		switch {
+		case it.tok == EOF:
+			return fmt.Errorf("line %d: missing %q", it.line, close)
+		case it.tok == PUNCT && it.lit == open:
+			depth++
+		case it.tok == PUNCT && it.lit == close:
+			depth--
+		}
+	}
+	return nil
+}
+
+// declarations parses the whole translation unit and returns the initialized
+// variables. Function bodies, type definitions and prototypes are skipped.
+func (p *parser) declarations() ([]*declaration, error) {
+	var decls []*declaration
+	for p.peek().tok != EOF {
+		var specs []item
+		line := p.peek().line
+	declarator:
+		for {
+			it := p.next()
+			switch {
+			case it.tok == EOF:
+				return nil, fmt.Errorf("line %d: unexpected end of file", it.line)
+			case it.tok == PUNCT && it.lit == ";":
+				break declarator
+			case it.tok == PUNCT && it.lit == "{":
+				// Function body or struct/enum definition.
+				if err := p.skipBalanced("{", "}"); err != nil {
+					return nil, err
+				}
+				if isFunction(specs) {
+					break declarator
+				}
+				specs = append(specs, item{tok: IDENT, lit: "{}", line: it.line})
+			case it.tok == PUNCT && (it.lit == "(" || it.lit == "["):
+				closing := ")"
+				if it.lit == "[" {
+					closing = "]"
+				}
+				if err := p.skipBalanced(it.lit, closing); err != nil {
+					return nil, err
+				}
+				specs = append(specs, item{tok: PUNCT, lit: it.lit + closing, line: it.line})
+			case it.tok == PUNCT && it.lit == "=":
+				d, err := newDeclaration(specs, line)
+				if err != nil {
+					return nil, err
+				}
+				if d.init, err = p.initializer(); err != nil {
+					return nil, fmt.Errorf("%s: %v", d.name, err)
+				}
+				decls = append(decls, d)
+				if p.is(",") {
+					return nil, fmt.Errorf("line %d: multiple declarators are not supported", p.peek().line)
+				}
+				if err := p.expect(";"); err != nil {
+					return nil, err
+				}
+				break declarator
+			default:
+				specs = append(specs, it)
+			}
+		}
+	}
+	return decls, nil
+}
+
+// isFunction returns true if the specifiers end with a parameter list.
+func isFunction(specs []item) bool {
+	return len(specs) > 0 && specs[len(specs)-1].lit == "()"
+}
+
+// newDeclaration extracts the type and the name of a declaration from its
+// specifiers, e.g. "static const hwp_swDescp_t name[]".
+func newDeclaration(specs []item, line int) (*declaration, error) {
+	d := &declaration{line: line}
+	var idents []string
+	for _, it := range specs {
+		switch {
+		case it.tok == IDENT:
+			idents = append(idents, it.lit)
+		case it.lit == "*":
+			d.ptr = true
+		case it.lit == "[]":
+			d.array = true
+		}
+	}
+	if len(idents) < 2 {
+		return nil, fmt.Errorf("line %d: invalid declaration", line)
+	}
+	d.typ = idents[len(idents)-2]
+	d.name = idents[len(idents)-1]
+	return d, nil
+}
+
+// initializer parses either a brace enclosed list or an expression ending with
+// a ',', a '}' or a ';'.
+func (p *parser) initializer() (*value, error) {
+	line := p.peek().line
+	if !p.is("{") {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return &value{expr: expr, line: line}, nil
+	}
+	p.next()
+	v := &value{list: []*entry{}, line: line}
+	for !p.is("}") {
+		e := &entry{}
+		for p.is(".") || p.is("[") {
+			if p.next().lit == "." {
+				it := p.next()
+				if it.tok != IDENT {
+					return nil, fmt.Errorf("line %d: expected field name, found %s", it.line, it)
+				}
+				e.desig = append(e.desig, designator{field: it.lit})
+				continue
+			}
+			start := p.pos
+			if err := p.skipBalanced("[", "]"); err != nil {
+				return nil, err
+			}
+			e.desig = append(e.desig, designator{index: p.items[start : p.pos-1]})
+		}
+		if len(e.desig) > 0 {
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+		}
+		val, err := p.initializer()
+		if err != nil {
+			return nil, err
+		}
+		e.val = val
+		v.list = append(v.list, e)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// expression collects the tokens of an expression up to the next ',', '}' or
+// ';' that is not nested in parentheses.
+func (p *parser) expression() ([]item, error) {
+	start := p.pos
+	depth := 0
+	for {
+		it := p.peek()
+		switch {
+		case it.tok == EOF:
+			return nil, fmt.Errorf("line %d: unexpected end of file", it.line)
+		case it.tok == PUNCT && it.lit == "(":
+			depth++
+		case it.tok == PUNCT && it.lit == ")":
+			depth--
+		case it.tok == PUNCT && depth == 0 && (it.lit == "," || it.lit == "}" || it.lit == ";"):
+			if p.pos == start {
+				return nil, fmt.Errorf("line %d: expected expression, found %s", it.line, it)
+			}
+			return p.items[start:p.pos], nil
+		}
+		p.next()
+	}
+}
+
+// unquote decodes a C string literal.
+func unquote(lit string) (string, error) {
+	// C and Go escape sequences are close enough for profile names.
+	return strconv.Unquote(lit)
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package csrc
+
+import (
+	"fmt"
+	"strings"
+)
+
+type token int
+
+const (
+	EOF token = iota
+	IDENT
+	NUMBER
+	STRING
+	CHAR
+	PUNCT
+	DIRECTIVE
+)
+
+func (t token) String() string {
+	switch t {
+	case EOF:
+		return "EOF"
+	case IDENT:
+		return "IDENT"
+	case NUMBER:
+		return "NUMBER"
+	case STRING:
+		return "STRING"
+	case CHAR:
+		return "CHAR"
+	case PUNCT:
+		return "PUNCT"
+	case DIRECTIVE:
+		return "DIRECTIVE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Multi-character punctuators, longest first.
+var punctuators = []string{"...", "<<", ">>", "&&", "||", "==", "!=", "->"}
+
+// item is a lexical element of the source with its position.
+type item struct {
+	tok  token
+	lit  string
+	line int
+}
+
+func (it item) String() string {
+	if it.tok == EOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", it.lit)
+}
+
+// scanner splits a C source into tokens. Comments are dropped and
+// preprocessor directives are returned as a single DIRECTIVE token holding
+// the whole logical line.
+type scanner struct {
+	src  string
+	pos  int
+	line int
+}
+
+func newScanner(src string) *scanner {
+	return &scanner{src: src, line: 1}
+}
+
+func (s *scanner) peekAt(n int) byte {
+	if s.pos+n >= len(s.src) {
+		return 0
+	}
+	return s.src[s.pos+n]
+}
+
+// skip ignores white spaces and comments. It returns true if a new line was
+// crossed, which matters to recognize preprocessor directives.
+func (s *scanner) skip() (bool, error) {
+	newline := s.pos == 0
+	for s.pos < len(s.src) {
+		ch := s.src[s.pos]
+		switch {
+		case ch == '\n':
+			newline = true
+			s.line++
+			s.pos++
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\f' || ch == '\v':
+			s.pos++
+		case ch == '\\' && s.peekAt(1) == '\n':
+			s.pos += 2
+			s.line++
+		case ch == '/' && s.peekAt(1) == '/':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case ch == '/' && s.peekAt(1) == '*':
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				return newline, fmt.Errorf("line %d: unterminated comment", s.line)
+			}
+			s.line += strings.Count(s.src[s.pos:s.pos+2+end], "\n")
+			s.pos += end + 4
+		default:
+			return newline, nil
+		}
+	}
+	return newline, nil
+}
+
+func isLetter(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func (s *scanner) scan() (item, error) {
+	newline, err := s.skip()
+	if err != nil {
+		return item{}, err
+	}
+	if s.pos >= len(s.src) {
+		return item{tok: EOF, line: s.line}, nil
+	}
+
+	start, line := s.pos, s.line
+	ch := s.src[s.pos]
+	switch {
+	case ch == '#' && newline:
+		// Directives span up to the end of the logical line.
+		var b strings.Builder
+		for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+			if s.src[s.pos] == '\\' && s.peekAt(1) == '\n' {
+				b.WriteByte(' ')
+				s.pos += 2
+				s.line++
+				continue
+			}
+			if s.src[s.pos] == '/' && s.peekAt(1) == '/' {
+				for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+					s.pos++
+				}
+				continue
+			}
+			if s.src[s.pos] == '/' && s.peekAt(1) == '*' {
+				end := strings.Index(s.src[s.pos+2:], "*/")
+				if end < 0 {
+					return item{}, fmt.Errorf("line %d: unterminated comment", s.line)
+				}
+				s.line += strings.Count(s.src[s.pos:s.pos+2+end], "\n")
+				s.pos += end + 4
+				b.WriteByte(' ')
+				continue
+			}
+			b.WriteByte(s.src[s.pos])
+			s.pos++
+		}
+		return item{tok: DIRECTIVE, lit: strings.TrimSpace(b.String()), line: line}, nil
+	case isLetter(ch):
+		for s.pos < len(s.src) && (isLetter(s.src[s.pos]) || isDigit(s.src[s.pos])) {
+			s.pos++
+		}
+		return item{tok: IDENT, lit: s.src[start:s.pos], line: line}, nil
+	case isDigit(ch):
+		for s.pos < len(s.src) && (isLetter(s.src[s.pos]) || isDigit(s.src[s.pos])) {
+			s.pos++
+		}
+		return item{tok: NUMBER, lit: s.src[start:s.pos], line: line}, nil
+	case ch == '"' || ch == '\'':
+		s.pos++
+		for s.pos < len(s.src) && s.src[s.pos] != ch {
+			if s.src[s.pos] == '\\' {
+				s.pos++
+			}
+			if s.pos < len(s.src) && s.src[s.pos] == '\n' {
+				return item{}, fmt.Errorf("line %d: unterminated literal", line)
+			}
+			s.pos++
+		}
+		if s.pos >= len(s.src) {
+			return item{}, fmt.Errorf("line %d: unterminated literal", line)
+		}
+		s.pos++
+		tok := STRING
+		if ch == '\'' {
+			tok = CHAR
+		}
+		return item{tok: tok, lit: s.src[start:s.pos], line: line}, nil
+	}
+
+	for _, p := range punctuators {
+		if strings.HasPrefix(s.src[s.pos:], p) {
+			s.pos += len(p)
+			return item{tok: PUNCT, lit: p, line: line}, nil
+		}
+	}
+	s.pos++
+	return item{tok: PUNCT, lit: s.src[start:s.pos], line: line}, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package csrc
+
+import (
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Chip identifiers as named in include/hal/chipdef/chip.h.
+var chipIds = map[string]rtl.RtlChipId{
+	"RTL8351M_CHIP_ID":         rtl.RTL8351M_CHIP_ID,
+	"RTL8352M_CHIP_ID":         rtl.RTL8352M_CHIP_ID,
+	"RTL8353M_CHIP_ID":         rtl.RTL8353M_CHIP_ID,
+	"RTL8390M_CHIP_ID":         rtl.RTL8390M_CHIP_ID,
+	"RTL8391M_CHIP_ID":         rtl.RTL8391M_CHIP_ID,
+	"RTL8392M_CHIP_ID":         rtl.RTL8392M_CHIP_ID,
+	"RTL8393M_CHIP_ID":         rtl.RTL8393M_CHIP_ID,
+	"RTL8396M_CHIP_ID":         rtl.RTL8396M_CHIP_ID,
+	"RTL8352MES_CHIP_ID":       rtl.RTL8352MES_CHIP_ID,
+	"RTL8353MES_CHIP_ID":       rtl.RTL8353MES_CHIP_ID,
+	"RTL8392MES_CHIP_ID":       rtl.RTL8392MES_CHIP_ID,
+	"RTL8393MES_CHIP_ID":       rtl.RTL8393MES_CHIP_ID,
+	"RTL8396MES_CHIP_ID":       rtl.RTL8396MES_CHIP_ID,
+	"RTL8330M_CHIP_ID":         rtl.RTL8330M_CHIP_ID,
+	"RTL8332M_CHIP_ID":         rtl.RTL8332M_CHIP_ID,
+	"RTL8380M_CHIP_ID":         rtl.RTL8380M_CHIP_ID,
+	"RTL8382M_CHIP_ID":         rtl.RTL8382M_CHIP_ID,
+	"RTL8381M_CHIP_ID":         rtl.RTL8381M_CHIP_ID,
+	"RTL9301_CHIP_ID":          rtl.RTL9301_CHIP_ID,
+	"RTL9301_CHIP_ID_24G":      rtl.RTL9301_CHIP_ID_24G,
+	"RTL9301H_CHIP_ID":         rtl.RTL9301H_CHIP_ID,
+	"RTL9301H_CHIP_ID_4X2_5G":  rtl.RTL9301H_CHIP_ID_4X2_5G,
+	"RTL9302A_CHIP_ID":         rtl.RTL9302A_CHIP_ID,
+	"RTL9302A_CHIP_ID_12X2_5G": rtl.RTL9302A_CHIP_ID_12X2_5G,
+	"RTL9302B_CHIP_ID":         rtl.RTL9302B_CHIP_ID,
+	"RTL9302B_CHIP_ID_8X2_5G":  rtl.RTL9302B_CHIP_ID_8X2_5G,
+	"RTL9302C_CHIP_ID":         rtl.RTL9302C_CHIP_ID,
+	"RTL9302C_CHIP_ID_16X2_5G": rtl.RTL9302C_CHIP_ID_16X2_5G,
+	"RTL9302D_CHIP_ID":         rtl.RTL9302D_CHIP_ID,
+	"RTL9302D_CHIP_ID_24X2_5G": rtl.RTL9302D_CHIP_ID_24X2_5G,
+	"RTL9302DE_CHIP_ID":        rtl.RTL9302DE_CHIP_ID,
+	"RTL9302F_CHIP_ID":         rtl.RTL9302F_CHIP_ID,
+	"RTL9303_CHIP_ID":          rtl.RTL9303_CHIP_ID,
+	"RTL9303_CHIP_ID_8XG":      rtl.RTL9303_CHIP_ID_8XG,
+	"RTL9310_CHIP_ID":          rtl.RTL9310_CHIP_ID,
+	"RTL9311_CHIP_ID":          rtl.RTL9311_CHIP_ID,
+	"RTL9311E_CHIP_ID":         rtl.RTL9311E_CHIP_ID,
+	"RTL9311R_CHIP_ID":         rtl.RTL9311R_CHIP_ID,
+	"RTL9312_CHIP_ID":          rtl.RTL9312_CHIP_ID,
+	"RTL9313_CHIP_ID":          rtl.RTL9313_CHIP_ID,
+}
+
+// Plain constants of the SDK headers.
+var constants = map[string]uint64{
+	"NULL":         0,
+	"FALSE":        0,
+	"TRUE":         1,
+	"DISABLED":     0,
+	"ENABLED":      1,
+	"HWP_NONE":     rtl.HWP_NONE,
+	"HWP_END":      rtl.HWP_END,
+	"HWP_NOT_USED": rtl.HWP_NOT_USED,
+	"HWP_ETHER":    uint64(rtl.HWP_ETHER),
+	"HWP_UPLINK":   uint64(rtl.HWP_UPLINK),
+	"HWP_CASCADE":  uint64(rtl.HWP_CASCADE),
+	"HWP_CPU":      uint64(rtl.HWP_CPU),
+	"HWP_SC":       uint64(rtl.HWP_SC),
+	"HWP_FE":       uint64(rtl.HWP_FE),
+	"HWP_GE":       uint64(rtl.HWP_GE),
+	"HWP_2_5GE":    uint64(rtl.HWP_2_5GE),
+	"HWP_5GE":      uint64(rtl.HWP_5GE),
+	"HWP_XGE":      uint64(rtl.HWP_XGE),
+	"HWP_COPPER":   uint64(rtl.HWP_COPPER),
+	"HWP_FIBER":    uint64(rtl.HWP_FIBER),
+	"HWP_COMBO":    uint64(rtl.HWP_COMBO),
+	"HWP_SERDES":   uint64(rtl.HWP_SERDES),
+	"SINGLE_SET":   uint64(rtl.SINGLE_SET),
+	"DOUBLE_SET":   uint64(rtl.DOUBLE_SET),
+}
+
+// symbols returns the table of every identifier a hardware profile source can
+// reference without defining it.
+func symbols() map[string]uint64 {
+	syms := make(map[string]uint64)
+	for name, v := range constants {
+		syms[name] = v
+	}
+	for name, id := range chipIds {
+		syms[name] = uint64(id)
+	}
+	for m := rtl.HWP_SW_ACC_NONE; m < rtl.HWP_SW_ACC_END; m++ {
+		syms[m.String()] = uint64(m)
+	}
+	for m := rtl.RTK_MII_NONE; m < rtl.RTK_MII_END; m++ {
+		syms[m.String()] = uint64(m)
+	}
+	for c := rtl.RTK_PHYTYPE_NONE; c < rtl.RTK_PHYTYPE_UNKNOWN; c++ {
+		syms[c.String()] = uint64(c)
+	}
+	for _, p := range []rtl.SerdesPolarity{rtl.SERDES_POLARITY_NORMAL, rtl.SERDES_POLARITY_CHANGE} {
+		syms[p.String()] = uint64(p)
+	}
+	for _, l := range []rtl.LedIfSel{rtl.LED_IF_SEL_NONE, rtl.LED_IF_SEL_SERIAL, rtl.LED_IF_SEL_SINGLE_COLOR_SCAN, rtl.LED_IF_SEL_BI_COLOR_SCAN} {
+		syms["LED_IF_SEL_"+l.String()] = uint64(l)
+	}
+	for _, a := range []rtl.LedActive{rtl.LED_ACTIVE_LOW, rtl.LED_ACTIVE_HIGH} {
+		syms["LED_ACTIVE_"+a.String()] = uint64(a)
+	}
+	return syms
+}
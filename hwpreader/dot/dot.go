@@ -0,0 +1,241 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package dot renders the topology of a decoded hardware profile as a
+// Graphviz diagram.
+//
+// The diagram reads from left to right: the switch core, the serdes lanes
+// with their mode and polarity, the serdes converters, the PHY packages
+// grouped by SMI bus and the front panel ports. Edges follow the data path of
+// each port, PHY edges are labelled with the MDIO address of the port.
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// generator accumulates the diagram.
+type generator struct {
+	b strings.Builder
+	// edges already written, PHYs and serdes are shared by several ports.
+	edges map[string]bool
+}
+
+func (g *generator) line(indent int, format string, args ...any) {
+	g.b.WriteString(strings.Repeat("\t", indent))
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteByte('\n')
+}
+
+// edge writes the edge from a to b once, attrs are only used the first time.
+func (g *generator) edge(indent int, a, b, attrs string) {
+	key := a + "->" + b
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	if attrs != "" {
+		attrs = " [" + attrs + "]"
+	}
+	g.line(indent, "%s -> %s%s;", a, b, attrs)
+}
+
+// label quotes the lines of a node label.
+func label(lines ...string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for i, l := range lines {
+		lines[i] = r.Replace(l)
+	}
+	return `"` + strings.Join(lines, `\n`) + `"`
+}
+
+// Generate writes the Graphviz diagram of the topology of sw to w.
+func Generate(w io.Writer, sw *rtl.Switch) error {
+	g := &generator{edges: make(map[string]bool)}
+	g.header(sw.ChipId.String())
+	g.unit(1, "", sw)
+	g.line(0, "}")
+	_, err := io.WriteString(w, g.b.String())
+	return err
+}
+
+// GenerateProfile writes the Graphviz diagram of the units of hp to w, each
+// unit in its own cluster, linked by their cascade ports.
+func GenerateProfile(w io.Writer, hp *rtl.HwProfile) error {
+	g := &generator{edges: make(map[string]bool)}
+	g.header(hp.Identifier.Name)
+	for u, sw := range hp.Units {
+		g.line(1, "subgraph cluster_unit%d {", u)
+		title := fmt.Sprintf("unit %d", u)
+		if u == int(hp.Soc.SwDescpIndex) {
+			title += " (CPU)"
+		}
+		g.line(2, "label = %s;", label(title))
+		g.unit(2, fmt.Sprintf("u%d_", u), sw)
+		g.line(1, "}")
+	}
+	// Dangling cascade ports are reported by the validator.
+	cascades, _ := hp.Cascades()
+	for _, c := range cascades {
+		a := fmt.Sprintf("u%d_port%d", c.Unit, c.Port.MacId)
+		b := fmt.Sprintf("u%d_port%d", c.PeerUnit, c.Peer.MacId)
+		g.edge(1, a, b, `dir = both, style = bold, label = "cascade"`)
+	}
+	g.line(0, "}")
+	_, err := io.WriteString(w, g.b.String())
+	return err
+}
+
+func (g *generator) header(name string) {
+	g.line(0, "// Generated by hwpreader.")
+	g.line(0, "digraph %s {", label(name))
+	g.line(1, "rankdir = LR;")
+	g.line(1, `node [shape = box, fontname = "monospace", fontsize = 10];`)
+	g.line(1, `edge [fontname = "monospace", fontsize = 9];`)
+}
+
+// unit writes the nodes and edges of a switch, node names are prefixed by
+// prefix.
+func (g *generator) unit(indent int, prefix string, sw *rtl.Switch) {
+	core := prefix + "core"
+	g.line(indent, "%s [label = %s, shape = box3d];", core, label(sw.ChipId.String(), "switch core"))
+
+	if len(sw.Serdes) > 0 {
+		g.line(indent, "subgraph cluster_%sserdes {", prefix)
+		g.line(indent+1, `label = "serdes";`)
+		for i, s := range sw.Serdes {
+			g.line(indent+1, "%ssds%d [label = %s];", prefix, i, label(
+				fmt.Sprintf("SDS %d", s.Id),
+				strings.TrimPrefix(s.Mode.String(), "RTK_MII_"),
+				polarity(s.RxPolarity, s.TxPolarity)))
+		}
+		g.line(indent, "}")
+	}
+
+	for i, sc := range sw.Converters {
+		g.line(indent, "%ssc%d [label = %s, shape = component];", prefix, i, label(
+			fmt.Sprintf("converter %d (%s)", i, sc.ChipName()),
+			fmt.Sprintf("SMI %d addr %d", sc.Smi, sc.PhyAddr),
+			polarity(sc.RxPolarity, sc.TxPolarity)))
+	}
+
+	// PHY packages are grouped by the SMI bus of their first port.
+	var smis []uint8
+	phys := make(map[uint8][]int)
+	for i := range sw.Phys {
+		smi := uint8(rtl.HWP_NONE)
+		for _, p := range sw.Ports {
+			if int(p.PhyIdx) == i && p.Attr&rtl.HWP_CPU == 0 {
+				smi = p.Smi
+				break
+			}
+		}
+		if _, ok := phys[smi]; !ok {
+			smis = append(smis, smi)
+		}
+		phys[smi] = append(phys[smi], i)
+	}
+	for _, smi := range smis {
+		g.line(indent, "subgraph cluster_%ssmi%d {", prefix, smi)
+		if smi == rtl.HWP_NONE {
+			g.line(indent+1, `label = "unused PHYs";`)
+		} else {
+			g.line(indent+1, "label = %s;", label(fmt.Sprintf("SMI %d", smi)))
+		}
+		for _, i := range phys[smi] {
+			phy := sw.Phys[i]
+			g.line(indent+1, "%sphy%d [label = %s, shape = box, style = rounded];", prefix, i, label(
+				strings.TrimPrefix(phy.Chip.String(), "RTK_PHYTYPE_"),
+				fmt.Sprintf("MAC %d, %d ports", phy.MacId, phy.PhyMax)))
+		}
+		g.line(indent, "}")
+	}
+
+	g.line(indent, "subgraph cluster_%sports {", prefix)
+	g.line(indent+1, `label = "ports";`)
+	for _, p := range sw.Ports {
+		g.port(indent+1, prefix, p)
+	}
+	g.line(indent, "}")
+
+	for _, p := range sw.Ports {
+		g.path(indent, prefix, sw, p)
+	}
+}
+
+func polarity(rx, tx rtl.SerdesPolarity) string {
+	pol := func(p rtl.SerdesPolarity) string {
+		if p == rtl.SERDES_POLARITY_CHANGE {
+			return "swapped"
+		}
+		return "normal"
+	}
+	return fmt.Sprintf("rx %s, tx %s", pol(rx), pol(tx))
+}
+
+// port writes the node of a port.
+func (g *generator) port(indent int, prefix string, p *rtl.Port) {
+	if p.Attr&rtl.HWP_CPU != 0 {
+		g.line(indent, "%sport%d [label = %s, shape = cds];", prefix, p.MacId, label(fmt.Sprintf("CPU port %d", p.MacId)))
+		return
+	}
+	kind := strings.TrimPrefix(p.Eth.String(), "HWP_")
+	if p.Medi < rtl.HWP_MEDI_END {
+		kind += " " + strings.ToLower(strings.TrimPrefix(p.Medi.String(), "HWP_"))
+	}
+	shape := "rect"
+	if p.Attr&rtl.HWP_CASCADE != 0 {
+		shape = "doubleoctagon"
+	} else if p.Attr&rtl.HWP_UPLINK != 0 {
+		shape = "octagon"
+	}
+	g.line(indent, "%sport%d [label = %s, shape = %s];", prefix, p.MacId, label(fmt.Sprintf("port %d", p.MacId), kind), shape)
+}
+
+// path writes the edges from the switch core to the port.
+func (g *generator) path(indent int, prefix string, sw *rtl.Switch, p *rtl.Port) {
+	core := prefix + "core"
+	port := fmt.Sprintf("%sport%d", prefix, p.MacId)
+	if p.Attr&rtl.HWP_CPU != 0 {
+		g.edge(indent, core, port, "")
+		return
+	}
+
+	// A port fed by several serdes has a path through each of them, a PHY
+	// port without serdes through the serdes feeding its PHY.
+	from := []string{core}
+	if idx, _ := sw.PortLanes(p); len(idx) > 0 {
+		from = nil
+		for _, k := range idx {
+			sds := fmt.Sprintf("%ssds%d", prefix, k)
+			g.edge(indent, core, sds, "")
+			from = append(from, sds)
+		}
+	}
+	if p.Attr&rtl.HWP_SC != 0 && int(p.ScIdx) < len(sw.Converters) {
+		sc := fmt.Sprintf("%ssc%d", prefix, p.ScIdx)
+		for _, f := range from {
+			g.edge(indent, f, sc, "")
+		}
+		from = []string{sc}
+	}
+	if p.PhyIdx != rtl.HWP_NONE && int(p.PhyIdx) < len(sw.Phys) {
+		phy := fmt.Sprintf("%sphy%d", prefix, p.PhyIdx)
+		for _, f := range from {
+			g.edge(indent, f, phy, "")
+		}
+		g.edge(indent, phy, port, fmt.Sprintf("label = %s", label(fmt.Sprintf("addr %d", p.PhyAddr))))
+		return
+	}
+	attrs := ""
+	if from[0] == core {
+		// Neither a PHY nor a serdes: the data path is unknown.
+		attrs = "style = dashed"
+	}
+	for _, f := range from {
+		g.edge(indent, f, port, attrs)
+	}
+}
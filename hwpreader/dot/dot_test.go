@@ -0,0 +1,89 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package dot
+
+import (
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// board is the XMG1915-10E board of the corpus, its port 25 turned into a
+// cascade port without serdes and its port 24 moved behind a converter.
+func board(t *testing.T) *rtl.Switch {
+	sw := corpus.Lookup(t, "xmg1915-10e").Switch
+	sw.Converters = []*rtl.SerdesConverter{{Chip: 0x8295, Smi: 2, PhyAddr: 3}}
+	sw.Ports[8].Attr |= rtl.HWP_SC
+	sw.Ports[9].Attr, sw.Ports[9].SdsIdx = rtl.HWP_CASCADE, rtl.HWP_NONE
+	return sw
+}
+
+func TestGenerate(t *testing.T) {
+	var b strings.Builder
+	if err := Generate(&b, board(t)); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`digraph "RTL9302B (0x93021000)" {`,
+		`sds1 [label = "SDS 3\nUSXGMII_10GQXGMII\nrx normal, tx swapped"];`,
+		`sc0 [label = "converter 0 (RTL8295R)\nSMI 2 addr 3\nrx normal, tx normal", shape = component];`,
+		"subgraph cluster_smi0 {",
+		`phy1 [label = "RTL8224\nMAC 8, 4 ports", shape = box, style = rounded];`,
+		`port0 [label = "port 0\n2_5GE copper", shape = rect];`,
+		`port25 [label = "port 25\nXGE fiber", shape = doubleoctagon];`,
+		"core -> sds0;\n\tsds0 -> phy0;\n\tphy0 -> port0 [label = \"addr 0\"];\n\tphy0 -> port1 [label = \"addr 1\"];",
+		"core -> sds2;\n\tsds2 -> sc0;\n\tsc0 -> port24;",
+		"core -> port25 [style = dashed];",
+		"core -> port28;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "sds0 -> phy0;") != 1 {
+		t.Errorf("duplicated edges:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "}\n") || strings.Count(out, "{") != strings.Count(out, "}") {
+		t.Errorf("unbalanced output:\n%s", out)
+	}
+}
+
+func TestGenerateProfile(t *testing.T) {
+	master, slave := board(t), board(t)
+	slave.Ports = slave.Ports[:10]
+	hp := &rtl.HwProfile{
+		Identifier: rtl.Identifier{Name: "board\"48"},
+		Units:      []*rtl.Switch{master, slave},
+	}
+	var b strings.Builder
+	if err := GenerateProfile(&b, hp); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`digraph "board\"48" {`,
+		"subgraph cluster_unit0 {\n\t\tlabel = \"unit 0 (CPU)\";",
+		"subgraph cluster_unit1 {\n\t\tlabel = \"unit 1\";",
+		"u1_phy0 -> u1_port1 [label = \"addr 1\"];",
+		`u0_port25 -> u1_port25 [dir = both, style = bold, label = "cascade"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Generate(&out, b.Switch); err != nil {
+				t.Fatal(err)
+			}
+			corpus.Golden(t, b, "dot", []byte(out.String()))
+		})
+	}
+}
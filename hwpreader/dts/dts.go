@@ -0,0 +1,304 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package dts generates device tree fragments for the OpenWrt realtek target
+// from a decoded hardware profile.
+//
+// The fragment follows the bindings of the rtl83xx/rtl93xx dtsi files: PHYs
+// are children of the &mdio node addressed by their port number with an
+// rtl9300,smi-address property, switch ports are children of the
+// ethernet-ports node of &switch0 and LED definitions live in its led_set
+// node. Elements of the profile that have no equivalent in these bindings are
+// reported and left as comments in the output.
+package dts
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// phyModes maps serdes modes to the Linux phy-mode property values.
+var phyModes = map[rtl.SerdesMode]string{
+	rtl.RTK_MII_SGMII:              "sgmii",
+	rtl.RTK_MII_QSGMII:             "qsgmii",
+	rtl.RTK_MII_XSGMII:             "xgmii",
+	rtl.RTK_MII_HISGMII:            "2500base-x",
+	rtl.RTK_MII_2500Base_X:         "2500base-x",
+	rtl.RTK_MII_1000BX_FIBER:       "1000base-x",
+	rtl.RTK_MII_100BX_FIBER:        "100base-x",
+	rtl.RTK_MII_10GR:               "10gbase-r",
+	rtl.RTK_MII_5GBASEX:            "5gbase-r",
+	rtl.RTK_MII_5GR:                "5gbase-r",
+	rtl.RTK_MII_XAUI:               "xaui",
+	rtl.RTK_MII_RXAUI:              "rxaui",
+	rtl.RTK_MII_RMII:               "rmii",
+	rtl.RTK_MII_USXGMII_10GSXGMII:  "usxgmii",
+	rtl.RTK_MII_USXGMII_10GQXGMII:  "usxgmii",
+	rtl.RTK_MII_USXGMII_5GSXGMII:   "usxgmii",
+	rtl.RTK_MII_USXGMII_2_5GSXGMII: "usxgmii",
+}
+
+// Serdes modes with an automatic fallback: only the first mode can be set.
+var autoModes = map[rtl.SerdesMode]rtl.SerdesMode{
+	rtl.RTK_MII_10GR1000BX_AUTO:  rtl.RTK_MII_10GR,
+	rtl.RTK_MII_10GRSGMII_AUTO:   rtl.RTK_MII_10GR,
+	rtl.RTK_MII_1000BX100BX_AUTO: rtl.RTK_MII_1000BX_FIBER,
+	rtl.RTK_MII_RXAUISGMII_AUTO:  rtl.RTK_MII_RXAUI,
+	rtl.RTK_MII_RXAUI1000BX_AUTO: rtl.RTK_MII_RXAUI,
+}
+
+// generator accumulates the fragment and the unsupported elements.
+type generator struct {
+	sw     *rtl.Switch
+	b      strings.Builder
+	issues []string
+}
+
+// flag records an element that cannot be expressed and leaves a comment at
+// the current position of the output.
+func (g *generator) flag(indent int, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	g.issues = append(g.issues, msg)
+	g.line(indent, "/* FIXME: %s */", msg)
+}
+
+func (g *generator) line(indent int, format string, args ...any) {
+	g.b.WriteString(strings.Repeat("\t", indent))
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteByte('\n')
+}
+
+// Generate writes the DTS fragment describing sw to w. It returns the
+// description of the elements of the profile that the bindings cannot
+// express.
+func Generate(w io.Writer, sw *rtl.Switch) ([]string, error) {
+	g := &generator{sw: sw}
+	g.line(0, "// SPDX-License-Identifier: GPL-2.0-or-later")
+	g.line(0, "/* Generated by hwpreader from a %s hardware profile. */", sw.ChipId)
+	g.mdio()
+	g.switchPorts()
+	g.serdes()
+	g.leds()
+	_, err := io.WriteString(w, g.b.String())
+	return g.issues, err
+}
+
+// serdesOf returns the serdes connecting the port to the switch core, the
+// one feeding its PHY for a PHY port without serdes, and the first one of a
+// port fed by several serdes: they must share their mode.
+func (g *generator) serdesOf(p *rtl.Port) (*rtl.Serdes, error) {
+	idx, err := g.sw.PortLanes(p)
+	if err != nil {
+		return nil, fmt.Errorf("port %d: %v", p.MacId, err)
+	}
+	if len(idx) == 0 {
+		return nil, nil
+	}
+	sds := g.sw.Serdes[idx[0]]
+	for _, k := range idx[1:] {
+		if mode := g.sw.Serdes[k].Mode; mode != sds.Mode {
+			return nil, fmt.Errorf("serdes of port %d have different modes, %s and %s", p.MacId, sds.Mode, mode)
+		}
+	}
+	return sds, nil
+}
+
+// phyOf returns the PHY package of the port.
+func (g *generator) phyOf(p *rtl.Port) *rtl.Phy {
+	if p.PhyIdx == rtl.HWP_NONE || int(p.PhyIdx) >= len(g.sw.Phys) {
+		return nil
+	}
+	return g.sw.Phys[p.PhyIdx]
+}
+
+func (g *generator) mdio() {
+	g.line(0, "")
+	g.line(0, "&mdio {")
+	for _, p := range g.sw.Ports {
+		if p.PhyIdx == rtl.HWP_NONE || p.Attr&rtl.HWP_CPU != 0 {
+			continue
+		}
+		phy := g.phyOf(p)
+		if phy == nil {
+			g.flag(1, "port %d references missing PHY %d", p.MacId, p.PhyIdx)
+			continue
+		}
+		compatible := "ethernet-phy-ieee802.3-c22"
+		if info, ok := phy.Chip.Info(); ok && info.Clause45 {
+			compatible = "ethernet-phy-ieee802.3-c45"
+		}
+		g.line(1, "/* %s */", phy.Chip)
+		g.line(1, "phy%d: ethernet-phy@%d {", p.MacId, p.MacId)
+		g.line(2, "reg = <%d>;", p.MacId)
+		g.line(2, "compatible = \"%s\";", compatible)
+		g.line(2, "rtl9300,smi-address = <%d %d>;", p.Smi, p.PhyAddr)
+		if sds, err := g.serdesOf(p); err != nil {
+			g.flag(2, "%v", err)
+		} else if sds != nil {
+			g.line(2, "sds = <%d>;", sds.Id)
+		}
+		if p.PhyMdiPinSwap || p.PhyMdiPairSwap != 0 {
+			g.flag(2, "MDI swap of port %d (pins: %v, pairs: 0x%x) has no binding", p.MacId, p.PhyMdiPinSwap, p.PhyMdiPairSwap)
+		}
+		g.line(1, "};")
+	}
+	g.line(0, "};")
+}
+
+func (g *generator) switchPorts() {
+	g.line(0, "")
+	g.line(0, "&switch0 {")
+	g.line(1, "ethernet-ports {")
+	g.line(2, "#address-cells = <1>;")
+	g.line(2, "#size-cells = <0>;")
+	lan := 0
+	for _, p := range g.sw.Ports {
+		g.line(0, "")
+		if p.Attr&rtl.HWP_CPU != 0 {
+			g.cpuPort(p)
+			continue
+		}
+		lan++
+		g.line(2, "port@%d {", p.MacId)
+		g.line(3, "reg = <%d>;", p.MacId)
+		g.line(3, "label = \"lan%d\";", lan)
+		g.port(p)
+		g.line(2, "};")
+	}
+	g.line(1, "};")
+	g.line(0, "};")
+}
+
+func (g *generator) port(p *rtl.Port) {
+	if p.Attr&rtl.HWP_CASCADE != 0 {
+		g.flag(3, "port %d is a cascade port", p.MacId)
+	}
+	if p.Attr&rtl.HWP_SC != 0 {
+		g.flag(3, "port %d is behind serdes converter %d", p.MacId, p.ScIdx)
+	}
+	if p.PhyIdx != rtl.HWP_NONE && g.phyOf(p) != nil {
+		g.line(3, "phy-handle = <&phy%d>;", p.MacId)
+	}
+
+	sds, err := g.serdesOf(p)
+	switch {
+	case err != nil:
+		g.flag(3, "%v", err)
+	case sds == nil && p.PhyIdx != rtl.HWP_NONE:
+		g.flag(3, "no serdes feeds the PHY of port %d", p.MacId)
+	case sds == nil:
+		g.flag(3, "port %d has neither a PHY nor a serdes", p.MacId)
+	default:
+		mode := sds.Mode
+		if fallback, ok := autoModes[mode]; ok {
+			g.flag(3, "serdes %d mode %s falls back to %s only", sds.Id, mode, fallback)
+			mode = fallback
+		}
+		if name, ok := phyModes[mode]; ok {
+			g.line(3, "phy-mode = \"%s\";", name)
+		} else {
+			g.flag(3, "serdes %d mode %s has no phy-mode", sds.Id, mode)
+		}
+		if p.PhyIdx == rtl.HWP_NONE {
+			g.line(3, "sds = <%d>;", sds.Id)
+			g.line(3, "managed = \"in-band-status\";")
+		}
+	}
+
+	switch p.Medi {
+	case rtl.HWP_COPPER:
+		g.ledSet(p.LedC)
+	case rtl.HWP_FIBER, rtl.HWP_SERDES:
+		g.ledSet(p.LedF)
+	case rtl.HWP_COMBO:
+		g.ledSet(p.LedC)
+		g.flag(3, "port %d is a combo port, fiber LED set %d ignored", p.MacId, p.LedF)
+	}
+}
+
+func (g *generator) ledSet(set rtl.LedSel) {
+	if set != rtl.HWP_NONE {
+		g.line(3, "led-set = <%d>;", set)
+	}
+}
+
+func (g *generator) cpuPort(p *rtl.Port) {
+	speed := 1000
+	if family := g.sw.ChipId.Family(); family == rtl.RTL930X || family == rtl.RTL931X {
+		speed = 10000
+	}
+	g.line(2, "port@%d {", p.MacId)
+	g.line(3, "ethernet = <&ethernet0>;")
+	g.line(3, "reg = <%d>;", p.MacId)
+	g.line(3, "phy-mode = \"internal\";")
+	g.line(3, "fixed-link {")
+	g.line(4, "speed = <%d>;", speed)
+	g.line(4, "full-duplex;")
+	g.line(3, "};")
+	g.line(2, "};")
+}
+
+func (g *generator) serdes() {
+	for _, c := range g.sw.Converters {
+		if c.RxPolarity == rtl.SERDES_POLARITY_CHANGE || c.TxPolarity == rtl.SERDES_POLARITY_CHANGE {
+			g.line(0, "")
+			g.flag(0, "polarity of serdes converter %s is not expressible", c.ChipName())
+		}
+	}
+	var swapped []*rtl.Serdes
+	for _, s := range g.sw.Serdes {
+		if s.RxPolarity == rtl.SERDES_POLARITY_CHANGE || s.TxPolarity == rtl.SERDES_POLARITY_CHANGE {
+			swapped = append(swapped, s)
+		}
+	}
+	if len(swapped) == 0 {
+		return
+	}
+	g.line(0, "")
+	g.line(0, "&serdes {")
+	for _, s := range swapped {
+		g.line(1, "serdes@%d {", s.Id)
+		g.line(2, "reg = <%d>;", s.Id)
+		if s.RxPolarity == rtl.SERDES_POLARITY_CHANGE {
+			g.line(2, "realtek,pnswap-rx;")
+		}
+		if s.TxPolarity == rtl.SERDES_POLARITY_CHANGE {
+			g.line(2, "realtek,pnswap-tx;")
+		}
+		g.line(1, "};")
+	}
+	g.line(0, "};")
+}
+
+func (g *generator) leds() {
+	g.line(0, "")
+	g.line(0, "&switch0 {")
+	g.line(1, "led_set {")
+	g.line(2, "compatible = \"realtek,rtl9300-leds\";")
+	switch g.sw.Leds.Active {
+	case rtl.LED_ACTIVE_LOW:
+		g.line(2, "active-low;")
+	case rtl.LED_ACTIVE_HIGH:
+	default:
+		g.flag(2, "LED polarity %s is not supported", g.sw.Leds.Active)
+	}
+	switch g.sw.Leds.LedIfSel {
+	case rtl.LED_IF_SEL_SERIAL:
+	case rtl.LED_IF_SEL_NONE:
+		g.flag(2, "profile has no LED interface")
+	default:
+		g.flag(2, "LED interface %s is not supported", g.sw.Leds.LedIfSel)
+	}
+	for i, set := range g.sw.Leds.LedSet {
+		var words []string
+		for j, led := range set.Led {
+			g.line(2, "/* LED %d: %s */", j, led)
+			words = append(words, fmt.Sprintf("0x%04x", uint32(led)))
+		}
+		g.line(2, "led_set%d = <%s>;", i, strings.Join(words, " "))
+	}
+	g.line(1, "};")
+	g.line(0, "};")
+}
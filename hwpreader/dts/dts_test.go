@@ -0,0 +1,129 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package dts
+
+import (
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// autoBoard returns the XMG1915-10E board of the corpus, the serdes of its
+// second SFP+ cage in a mode with an automatic fallback.
+func autoBoard(t *testing.T) *rtl.Switch {
+	sw := corpus.Lookup(t, "xmg1915-10e").Switch
+	sw.Serdes[3].Mode = rtl.RTK_MII_10GR1000BX_AUTO
+	return sw
+}
+
+func TestGenerate(t *testing.T) {
+	var b strings.Builder
+	issues, err := Generate(&b, autoBoard(t))
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"phy0: ethernet-phy@0 {",
+		"compatible = \"ethernet-phy-ieee802.3-c45\";",
+		"rtl9300,smi-address = <0 4>;",
+		"sds = <3>;",
+		"phy-handle = <&phy0>;",
+		"phy-mode = \"usxgmii\";",
+		"label = \"lan9\";",
+		"phy-mode = \"10gbase-r\";",
+		"led-set = <1>;",
+		"speed = <10000>;",
+		"serdes@3 {\n\t\treg = <3>;\n\t\trealtek,pnswap-tx;\n\t};",
+		"led_set0 = <0x0beb 0x0208 0x0000 0x0000 0x0000>;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0], "falls back") {
+		t.Errorf("unexpected issues: %q", issues)
+	}
+}
+
+func TestGenerateFlagsUnsupported(t *testing.T) {
+	sw := autoBoard(t)
+	sw.Ports[0].Attr |= rtl.HWP_SC
+	sw.Ports[8].SdsIdx = 16
+	sw.Ports[9].Medi = rtl.HWP_COMBO
+
+	var b strings.Builder
+	issues, err := Generate(&b, sw)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	want := []string{"serdes converter", "out of range", "combo port"}
+	for _, w := range want {
+		found := false
+		for _, issue := range issues {
+			found = found || strings.Contains(issue, w)
+		}
+		if !found {
+			t.Errorf("no issue about %q in %q", w, issues)
+		}
+	}
+	if !strings.Contains(b.String(), "FIXME") {
+		t.Errorf("issues are not reported in the output")
+	}
+}
+
+func TestPhyWithoutSerdes(t *testing.T) {
+	sw := autoBoard(t)
+	sw.Serdes[0].Mode, sw.Serdes[1].Mode = rtl.RTK_MII_DISABLE, rtl.RTK_MII_DISABLE
+	var b strings.Builder
+	issues, err := Generate(&b, sw)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(issues, "\n"), "no serdes feeds the PHY of port 0") {
+		t.Errorf("PHY port without serdes is not flagged: %q\n%s", issues, b.String())
+	}
+	if strings.Contains(b.String(), "phy-mode = \"usxgmii\";") {
+		t.Errorf("PHY port without serdes has a phy-mode:\n%s", b.String())
+	}
+}
+
+func TestLedPolarity(t *testing.T) {
+	for _, tt := range []struct {
+		active    rtl.LedActive
+		activeLow bool
+		issue     bool
+	}{
+		{rtl.LED_ACTIVE_LOW, true, false},
+		{rtl.LED_ACTIVE_HIGH, false, false},
+		{rtl.LedActive(7), false, true},
+	} {
+		sw := corpus.Lookup(t, "xmg1915-10e").Switch
+		sw.Leds.Active = tt.active
+		var b strings.Builder
+		issues, err := Generate(&b, sw)
+		if err != nil {
+			t.Fatalf("%s: generate failed: %v", tt.active, err)
+		}
+		if got := strings.Contains(b.String(), "active-low;"); got != tt.activeLow {
+			t.Errorf("%s: active-low %v, want %v", tt.active, got, tt.activeLow)
+		}
+		if got := len(issues) > 0; got != tt.issue {
+			t.Errorf("%s: unexpected issues %q", tt.active, issues)
+		}
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			var out strings.Builder
+			if _, err := Generate(&out, b.Switch); err != nil {
+				t.Fatal(err)
+			}
+			corpus.Golden(t, b, "dts", []byte(out.String()))
+		})
+	}
+}
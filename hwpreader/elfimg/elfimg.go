@@ -0,0 +1,218 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package elfimg gives access to the memory of ELF files (kernel modules,
+// vmlinux or U-Boot images) embedding the Realtek SDK, to decode the hardware
+// profiles they define by symbol name.
+//
+// Linked executables are read at the addresses of their sections. Relocatable
+// objects such as kernel modules have no addresses yet: their sections are
+// laid out one after the other and the absolute relocations are applied, so
+// that the pointers between profile parts can be followed.
+package elfimg
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Address of the first section of relocatable objects. It is not zero so that
+// NULL pointers stay invalid.
+const relocBase = 0x10000
+
+// region is an allocated section mapped in memory.
+type region struct {
+	name string
+	addr uint64
+	data []byte
+}
+
+// Symbol is a data object defined by the file.
+type Symbol struct {
+	Name    string
+	Addr    uint32
+	Size    uint64
+	Section string
+}
+
+// Image is the memory of an ELF file. It implements rtl.Memory.
+type Image struct {
+	Order   binary.ByteOrder
+	regions []*region
+	symbols []Symbol
+}
+
+// Open loads the ELF file at path.
+func Open(path string) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return New(f)
+}
+
+// New loads an ELF file.
+func New(r io.ReaderAt) (*Image, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	if f.Class != elf.ELFCLASS32 {
+		return nil, fmt.Errorf("unsupported ELF class %v", f.Class)
+	}
+
+	im := &Image{Order: f.ByteOrder}
+	// Regions indexed by section index.
+	bySection := make(map[int]*region)
+	next := uint64(relocBase)
+	for i, s := range f.Sections {
+		if s.Flags&elf.SHF_ALLOC == 0 || s.Size == 0 {
+			continue
+		}
+		reg := &region{name: s.Name, addr: s.Addr}
+		if s.Type == elf.SHT_NOBITS {
+			reg.data = make([]byte, s.Size)
+		} else if reg.data, err = s.Data(); err != nil {
+			return nil, fmt.Errorf("section %s: %v", s.Name, err)
+		}
+		if f.Type == elf.ET_REL {
+			align := max(s.Addralign, 1)
+			reg.addr = (next + align - 1) / align * align
+			next = reg.addr + s.Size
+		}
+		bySection[i] = reg
+		im.regions = append(im.regions, reg)
+	}
+
+	syms, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return nil, err
+	}
+	// addr returns the address of a symbol, 0 if it is undefined.
+	addr := func(s elf.Symbol) uint64 {
+		switch {
+		case s.Section == elf.SHN_ABS:
+			return s.Value
+		case f.Type != elf.ET_REL:
+			return s.Value
+		}
+		if reg, ok := bySection[int(s.Section)]; ok {
+			return reg.addr + s.Value
+		}
+		return 0
+	}
+	for _, s := range syms {
+		if elf.ST_TYPE(s.Info) != elf.STT_OBJECT || s.Section == elf.SHN_UNDEF {
+			continue
+		}
+		sym := Symbol{Name: s.Name, Addr: uint32(addr(s)), Size: s.Size}
+		if reg, ok := bySection[int(s.Section)]; ok {
+			sym.Section = reg.name
+		}
+		im.symbols = append(im.symbols, sym)
+	}
+	sort.Slice(im.symbols, func(i, j int) bool { return im.symbols[i].Addr < im.symbols[j].Addr })
+
+	if f.Type == elf.ET_REL {
+		for _, s := range f.Sections {
+			if s.Type != elf.SHT_REL && s.Type != elf.SHT_RELA {
+				continue
+			}
+			target, ok := bySection[int(s.Info)]
+			if !ok {
+				continue
+			}
+			if err := im.relocate(f, s, target, syms, addr); err != nil {
+				return nil, fmt.Errorf("section %s: %v", s.Name, err)
+			}
+		}
+	}
+	return im, nil
+}
+
+// isAbs32 returns true for the relocations storing the 32 bits absolute
+// address of a symbol, the only ones found in initialized data.
+func isAbs32(m elf.Machine, typ uint32) bool {
+	switch m {
+	case elf.EM_MIPS:
+		return elf.R_MIPS(typ) == elf.R_MIPS_32
+	case elf.EM_ARM:
+		return elf.R_ARM(typ) == elf.R_ARM_ABS32
+	case elf.EM_386:
+		return elf.R_386(typ) == elf.R_386_32
+	}
+	return false
+}
+
+// relocate applies the absolute relocations of the section s to target.
+// Other relocations only concern code and are ignored.
+func (im *Image) relocate(f *elf.File, s *elf.Section, target *region, syms []elf.Symbol, addr func(elf.Symbol) uint64) error {
+	data, err := s.Data()
+	if err != nil {
+		return err
+	}
+	entSize := 8
+	if s.Type == elf.SHT_RELA {
+		entSize = 12
+	}
+	for i := 0; i+entSize <= len(data); i += entSize {
+		off := uint64(f.ByteOrder.Uint32(data[i:]))
+		info := f.ByteOrder.Uint32(data[i+4:])
+		if !isAbs32(f.Machine, elf.R_TYPE32(info)) {
+			continue
+		}
+		if off+4 > uint64(len(target.data)) {
+			return fmt.Errorf("relocation at 0x%x out of %s", off, target.name)
+		}
+		var value uint64
+		if idx := elf.R_SYM32(info); idx > 0 {
+			if int(idx) > len(syms) {
+				return fmt.Errorf("relocation at 0x%x: invalid symbol %d", off, idx)
+			}
+			value = addr(syms[idx-1])
+		}
+		// REL entries hold the addend in place, RELA ones explicitly.
+		if s.Type == elf.SHT_RELA {
+			value += uint64(int64(int32(f.ByteOrder.Uint32(data[i+8:]))))
+		} else {
+			value += uint64(f.ByteOrder.Uint32(target.data[off:]))
+		}
+		f.ByteOrder.PutUint32(target.data[off:], uint32(value))
+	}
+	return nil
+}
+
+// ReadAt reads the memory at the address addr. Reads stop at the end of the
+// section containing addr.
+func (im *Image) ReadAt(p []byte, addr int64) (int, error) {
+	for _, reg := range im.regions {
+		if addr < int64(reg.addr) || addr >= int64(reg.addr)+int64(len(reg.data)) {
+			continue
+		}
+		n := copy(p, reg.data[addr-int64(reg.addr):])
+		if n < len(p) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("address 0x%x is not mapped", addr)
+}
+
+// Symbols returns the data objects of the file, ordered by address.
+func (im *Image) Symbols() []Symbol {
+	return im.symbols
+}
+
+// Lookup returns the data object called name.
+func (im *Image) Lookup(name string) (Symbol, error) {
+	for _, s := range im.symbols {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Symbol{}, fmt.Errorf("symbol %s not found", name)
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package elfimg
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+)
+
+type testSection struct {
+	name  string
+	typ   elf.SectionType
+	flags elf.SectionFlag
+	link  uint32
+	info  uint32
+	data  []byte
+}
+
+// buildELF assembles a 32 bits big endian relocatable MIPS object. The
+// section name table is appended to the given sections.
+func buildELF(sections []testSection) []byte {
+	be := binary.BigEndian
+	shstrtab := []byte{0}
+	names := make([]uint32, len(sections)+1)
+	for i, s := range sections {
+		names[i] = uint32(len(shstrtab))
+		shstrtab = append(append(shstrtab, s.name...), 0)
+	}
+	names[len(sections)] = uint32(len(shstrtab))
+	shstrtab = append(shstrtab, ".shstrtab\x00"...)
+	sections = append(sections, testSection{name: ".shstrtab", typ: elf.SHT_STRTAB, data: shstrtab})
+
+	var body bytes.Buffer
+	offsets := make([]uint32, len(sections))
+	for i, s := range sections {
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+		offsets[i] = uint32(52 + body.Len())
+		body.Write(s.data)
+	}
+	for body.Len()%4 != 0 {
+		body.WriteByte(0)
+	}
+	shoff := uint32(52 + body.Len())
+
+	var b bytes.Buffer
+	b.Write([]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS32), byte(elf.ELFDATA2MSB), byte(elf.EV_CURRENT)})
+	b.Write(make([]byte, 9))
+	binary.Write(&b, be, uint16(elf.ET_REL))
+	binary.Write(&b, be, uint16(elf.EM_MIPS))
+	binary.Write(&b, be, uint32(elf.EV_CURRENT))
+	binary.Write(&b, be, []uint32{0, 0, shoff, 0})
+	binary.Write(&b, be, []uint16{52, 0, 0, 40, uint16(len(sections) + 1), uint16(len(sections))})
+	b.Write(body.Bytes())
+
+	// Null section header, then the sections.
+	b.Write(make([]byte, 40))
+	for i, s := range sections {
+		entsize := uint32(0)
+		switch s.typ {
+		case elf.SHT_SYMTAB:
+			entsize = 16
+		case elf.SHT_REL:
+			entsize = 8
+		}
+		binary.Write(&b, be, []uint32{names[i], uint32(s.typ), uint32(s.flags), 0, offsets[i], uint32(len(s.data)), s.link, s.info, 4, entsize})
+	}
+	return b.Bytes()
+}
+
+func symbol(name uint32, value, size uint32, typ elf.SymType, section uint16) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, []uint32{name, value, size})
+	binary.Write(&b, binary.BigEndian, []byte{elf.ST_INFO(elf.STB_GLOBAL, typ), 0})
+	binary.Write(&b, binary.BigEndian, section)
+	return b.Bytes()
+}
+
+func TestRelocatable(t *testing.T) {
+	// .data holds two pointers to the "target" string: one relocated
+	// against the symbol, the other against the section with an addend.
+	data := []byte{0, 0, 0, 0, 0, 0, 0, 8, 'h', 'w', 'p', 0}
+	strtab := []byte("\x00pointers\x00target\x00")
+	var symtab bytes.Buffer
+	symtab.Write(make([]byte, 16))
+	symtab.Write(symbol(0, 0, 0, elf.STT_SECTION, 1))
+	symtab.Write(symbol(1, 0, 8, elf.STT_OBJECT, 1))
+	symtab.Write(symbol(10, 8, 4, elf.STT_OBJECT, 1))
+	var rel bytes.Buffer
+	binary.Write(&rel, binary.BigEndian, []uint32{
+		0, elf.R_INFO32(3, uint32(elf.R_MIPS_32)),
+		4, elf.R_INFO32(1, uint32(elf.R_MIPS_32)),
+	})
+
+	img := buildELF([]testSection{
+		{name: ".data", typ: elf.SHT_PROGBITS, flags: elf.SHF_ALLOC | elf.SHF_WRITE, data: data},
+		{name: ".rel.data", typ: elf.SHT_REL, link: 4, info: 1, data: rel.Bytes()},
+		{name: ".strtab", typ: elf.SHT_STRTAB, data: strtab},
+		{name: ".symtab", typ: elf.SHT_SYMTAB, link: 3, info: 1, data: symtab.Bytes()},
+	})
+	im, err := New(bytes.NewReader(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if im.Order != binary.BigEndian {
+		t.Errorf("unexpected byte order %v", im.Order)
+	}
+	if len(im.Symbols()) != 2 {
+		t.Errorf("unexpected symbols: %v", im.Symbols())
+	}
+	target, err := im.Lookup("target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Addr != relocBase+8 || target.Size != 4 || target.Section != ".data" {
+		t.Errorf("unexpected target symbol: %+v", target)
+	}
+	pointers, err := im.Lookup("pointers")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 8)
+	if _, err := im.ReadAt(buf, int64(pointers.Addr)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if ptr := binary.BigEndian.Uint32(buf[4*i:]); ptr != target.Addr {
+			t.Errorf("pointer %d: got 0x%x, want 0x%x", i, ptr, target.Addr)
+		}
+	}
+	if _, err := im.ReadAt(buf, int64(target.Addr)); err == nil {
+		t.Errorf("expected an error reading past the end of .data")
+	}
+	if _, err := im.ReadAt(buf, 0); err == nil {
+		t.Errorf("expected an error reading an unmapped address")
+	}
+	if _, err := im.Lookup("missing"); err == nil {
+		t.Errorf("expected an error looking up a missing symbol")
+	}
+}
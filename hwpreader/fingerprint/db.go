@@ -0,0 +1,129 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package fingerprint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Product is an entry of the fingerprint database. Its features are the ones
+// of Fingerprint, unknown ones being left empty.
+//
+// The database is a text file listing one feature per line, each product
+// starting with its name:
+//
+//	# Comment
+//	product Zyxel XMG1915-10E
+//	note port classes from the product specifications
+//	chip RTL930x
+//	ports 8x2.5G-copper 2x10G-fiber
+//	serdes 2xUSXGMII_10GQXGMII 2x10GR
+//	phys 2xRTL8224
+//	leds 1a2b3c4d
+//	hash 0123456789abcdef
+//
+// The features of a profile are printed in this format by identify -format db.
+type Product struct {
+	Name   string
+	Note   string
+	Chip   string
+	Ports  Set
+	Serdes Set
+	Phys   Set
+	Leds   string
+	Hash   string
+}
+
+// ParseDB reads a fingerprint database.
+func ParseDB(r io.Reader) ([]*Product, error) {
+	var db []*Product
+	var p *Product
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if key == "product" {
+			if value == "" {
+				return nil, fmt.Errorf("line %d: product without name", n)
+			}
+			p = &Product{Name: value}
+			db = append(db, p)
+			continue
+		}
+		if p == nil {
+			return nil, fmt.Errorf("line %d: %s before the first product", n, key)
+		}
+		var err error
+		switch key {
+		case "note":
+			p.Note = value
+		case "chip":
+			p.Chip = value
+		case "ports":
+			p.Ports, err = ParseSet(value)
+		case "serdes":
+			p.Serdes, err = ParseSet(value)
+		case "phys":
+			p.Phys, err = ParseSet(value)
+		case "leds":
+			p.Leds = value
+		case "hash":
+			p.Hash = value
+		default:
+			err = fmt.Errorf("unknown feature %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Entry returns the database entry of a product with the fingerprint.
+func (fp *Fingerprint) Entry(name string) string {
+	return fmt.Sprintf("product %s\n%shash %s\n", name, fp, fp.Hash())
+}
+
+// The products known without a vendor profile only have the features given
+// by their specifications. Entries made from profiles with identify -format db
+// are welcome.
+const builtin = `
+product Zyxel XMG1915-10E
+note port classes from the product specifications
+chip RTL930x
+ports 8x2.5G-copper 2x10G-fiber
+
+product Zyxel XGS1250-12
+note port classes from the product specifications
+chip RTL930x
+ports 8x1G-copper 3x10G-copper 1x10G-fiber
+
+product D-Link DMS-1250-10S
+note port classes from the product specifications
+chip RTL930x
+ports 8x2.5G-copper 2x10G-fiber
+
+product TP-Link TL-ST1008F
+note port classes from the product specifications
+chip RTL930x
+ports 8x10G-fiber
+`
+
+// Builtin returns the database shipped with hwpreader.
+func Builtin() []*Product {
+	db, err := ParseDB(strings.NewReader(builtin))
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
@@ -0,0 +1,245 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package fingerprint identifies the product a switch descriptor comes from.
+//
+// Vendors build their products on the same Realtek reference designs with
+// small changes, so a descriptor is summed up by its topology: the chip
+// family, the front panel ports by speed and medium, the serdes modes, the
+// PHY chips and the LED definitions used by the ports. The fingerprint is
+// matched against a database of known products, whose unknown features are
+// left out of the comparison.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"xioxoz.fr/hwpreader/panel"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Set is a multiset of features, e.g. 8 ports of class 2.5G-copper.
+type Set map[string]int
+
+// ParseSet parses the "<count>x<feature> ..." form of a set.
+func ParseSet(s string) (Set, error) {
+	set := Set{}
+	for _, item := range strings.Fields(s) {
+		count, feature, ok := strings.Cut(item, "x")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil || n <= 0 || feature == "" {
+			return nil, fmt.Errorf("invalid item %q, expected <count>x<feature>", item)
+		}
+		set[feature] += n
+	}
+	return set, nil
+}
+
+// String formats the set as "<count>x<feature> ..." in feature order.
+func (s Set) String() string {
+	var items []string
+	for _, f := range s.features() {
+		items = append(items, fmt.Sprintf("%dx%s", s[f], f))
+	}
+	return strings.Join(items, " ")
+}
+
+func (s Set) features() []string {
+	var features []string
+	for f := range s {
+		features = append(features, f)
+	}
+	sort.Strings(features)
+	return features
+}
+
+func (s Set) size() int {
+	n := 0
+	for _, c := range s {
+		n += c
+	}
+	return n
+}
+
+// similarity returns the Sørensen–Dice coefficient of the sets, from 0 for
+// disjoint sets to 1 for equal ones.
+func (s Set) similarity(o Set) float64 {
+	if s.size()+o.size() == 0 {
+		return 1
+	}
+	common := 0
+	for f, c := range s {
+		common += min(c, o[f])
+	}
+	return 2 * float64(common) / float64(s.size()+o.size())
+}
+
+// minus returns the features of s missing from o.
+func (s Set) minus(o Set) Set {
+	d := Set{}
+	for f, c := range s {
+		if c > o[f] {
+			d[f] = c - o[f]
+		}
+	}
+	return d
+}
+
+// Fingerprint is the normalized topology of a switch descriptor. Nil sets and
+// empty strings are unknown features.
+type Fingerprint struct {
+	Chip   string
+	Ports  Set
+	Serdes Set
+	Phys   Set
+	// Leds is a hash of the LED interface and of the LED sets used by the
+	// front panel ports.
+	Leds string
+}
+
+// Compute returns the fingerprint of a switch descriptor.
+func Compute(sw *rtl.Switch) *Fingerprint {
+	fp := &Fingerprint{
+		Chip:   sw.ChipId.Family().String(),
+		Ports:  Set{},
+		Serdes: Set{},
+		Phys:   Set{},
+	}
+	pn := panel.Derive(sw)
+	for _, p := range pn.Ports {
+		fp.Ports[p.Speed+"-"+p.Medium]++
+	}
+	for _, sd := range sw.Serdes {
+		fp.Serdes[strings.TrimPrefix(sd.Mode.String(), "RTK_MII_")]++
+	}
+	for _, phy := range sw.Phys {
+		fp.Phys[strings.TrimPrefix(phy.Chip.String(), "RTK_PHYTYPE_")]++
+	}
+	fp.Leds = ledsHash(sw.Leds, pn)
+	return fp
+}
+
+// ledsHash hashes the LED definitions of the sets used by the ports, in set
+// order: the numbering of the sets does not matter.
+func ledsHash(leds *rtl.Leds, pn *panel.Panel) string {
+	if leds == nil {
+		return ""
+	}
+	used := make(map[rtl.LedSel]bool)
+	for _, p := range pn.Ports {
+		for _, led := range []*rtl.LedSel{p.CopperLed, p.FiberLed} {
+			if led != nil && int(*led) < len(leds.LedSet) {
+				used[*led] = true
+			}
+		}
+	}
+	var sets []string
+	for sel := range used {
+		var words []string
+		for _, w := range leds.LedSet[sel].Led {
+			words = append(words, fmt.Sprintf("%08x", uint32(w)))
+		}
+		sets = append(sets, strings.Join(words, ","))
+	}
+	sort.Strings(sets)
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, uint32(leds.LedIfSel))
+	h.Write([]byte(strings.Join(sets, ";")))
+	return fmt.Sprintf("%x", h.Sum(nil)[:4])
+}
+
+// Hash returns a short hash of the fingerprint: descriptors sharing it have
+// the same topology.
+func (fp *Fingerprint) Hash() string {
+	sum := sha256.Sum256([]byte(fp.String()))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// String formats the fingerprint as the features of a database entry.
+func (fp *Fingerprint) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "chip %s\n", fp.Chip)
+	fmt.Fprintf(&b, "ports %s\n", fp.Ports)
+	fmt.Fprintf(&b, "serdes %s\n", fp.Serdes)
+	fmt.Fprintf(&b, "phys %s\n", fp.Phys)
+	fmt.Fprintf(&b, "leds %s\n", fp.Leds)
+	return b.String()
+}
+
+// Match is a product of the database compared to a fingerprint.
+type Match struct {
+	Product *Product
+	// Score is the similarity of the known features of the product, in
+	// percent.
+	Score int
+	// Exact is set when the hashes of the fingerprints are equal.
+	Exact bool
+	// Differences lists the features of the product that differ.
+	Differences []string
+}
+
+// Identify compares the fingerprint to the products of db and returns the
+// matches, closest first.
+func Identify(fp *Fingerprint, db []*Product) []Match {
+	var matches []Match
+	for _, p := range db {
+		matches = append(matches, compare(fp, p))
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Exact != matches[j].Exact {
+			return matches[i].Exact
+		}
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+func compare(fp *Fingerprint, p *Product) Match {
+	m := Match{Product: p, Exact: p.Hash != "" && p.Hash == fp.Hash()}
+	known, total := 0, 0.0
+	if p.Chip != "" {
+		known++
+		if p.Chip == fp.Chip {
+			total++
+		} else {
+			m.Differences = append(m.Differences, fmt.Sprintf("chip: %s, profile has %s", p.Chip, fp.Chip))
+		}
+	}
+	for _, s := range []struct {
+		name    string
+		want    Set
+		profile Set
+	}{
+		{"ports", p.Ports, fp.Ports},
+		{"serdes", p.Serdes, fp.Serdes},
+		{"phys", p.Phys, fp.Phys},
+	} {
+		if s.want == nil {
+			continue
+		}
+		known++
+		total += s.want.similarity(s.profile)
+		if missing := s.want.minus(s.profile); len(missing) > 0 {
+			m.Differences = append(m.Differences, fmt.Sprintf("%s: profile lacks %s", s.name, missing))
+		}
+		if extra := s.profile.minus(s.want); len(extra) > 0 {
+			m.Differences = append(m.Differences, fmt.Sprintf("%s: profile has extra %s", s.name, extra))
+		}
+	}
+	if p.Leds != "" {
+		known++
+		if p.Leds == fp.Leds {
+			total++
+		} else {
+			m.Differences = append(m.Differences, fmt.Sprintf("leds: %s, profile has %s", p.Leds, fp.Leds))
+		}
+	}
+	if known > 0 {
+		m.Score = int(100 * total / float64(known))
+	}
+	return m
+}
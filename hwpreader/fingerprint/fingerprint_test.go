@@ -0,0 +1,144 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package fingerprint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+func TestSet(t *testing.T) {
+	s, err := ParseSet("2x10G-fiber 8x2.5G-copper 1x10G-fiber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Set{"10G-fiber": 3, "2.5G-copper": 8}); !reflect.DeepEqual(s, want) {
+		t.Errorf("got %v, want %v", s, want)
+	}
+	if got := s.String(); got != "3x10G-fiber 8x2.5G-copper" {
+		t.Errorf("unexpected string %q", got)
+	}
+	if got := s.similarity(Set{"2.5G-copper": 8}); got != 16.0/19 {
+		t.Errorf("unexpected similarity %v", got)
+	}
+	for _, bad := range []string{"8", "x1G", "0x1G", "ax1G", "2x"} {
+		if _, err := ParseSet(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestParseDB(t *testing.T) {
+	for _, c := range []struct {
+		db  string
+		err string
+	}{
+		{"chip RTL930x\n", "line 1: chip before the first product"},
+		{"product\n", "line 1: product without name"},
+		{"# comment\nproduct A\nports 8\n", "line 3: invalid item"},
+		{"product A\ncolor blue\n", `line 2: unknown feature "color"`},
+	} {
+		if _, err := ParseDB(strings.NewReader(c.db)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%q: expected error %q, got %v", c.db, c.err, err)
+		}
+	}
+	if len(Builtin()) == 0 {
+		t.Errorf("empty builtin database")
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	boards := corpus.Boards(t)
+	var entries strings.Builder
+	for _, b := range boards {
+		entries.WriteString(Compute(b.Switch).Entry(b.Name))
+	}
+	db, err := ParseDB(strings.NewReader(entries.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = append(db, Builtin()...)
+
+	for _, b := range boards {
+		t.Run(b.Name, func(t *testing.T) {
+			matches := Identify(Compute(b.Switch), db)
+			best := matches[0]
+			if best.Product.Name != b.Name || !best.Exact || best.Score != 100 || len(best.Differences) != 0 {
+				t.Errorf("unexpected best match %+v", best)
+			}
+			for _, m := range matches[1:] {
+				if m.Exact || m.Score > best.Score {
+					t.Errorf("%s matches better than the board itself: %+v", m.Product.Name, m)
+				}
+			}
+		})
+	}
+
+	// A XMG1915-10E like board matches the products sharing its port
+	// classes, and differs from the others by its ports.
+	var xmg *corpus.Board
+	for _, b := range boards {
+		if b.Name == "xmg1915-10e" {
+			xmg = b
+		}
+	}
+	matches := Identify(Compute(xmg.Switch), Builtin())
+	var names []string
+	for _, m := range matches[:2] {
+		names = append(names, m.Product.Name)
+		if m.Score != 100 {
+			t.Errorf("%s: unexpected score %d", m.Product.Name, m.Score)
+		}
+	}
+	if want := []string{"Zyxel XMG1915-10E", "D-Link DMS-1250-10S"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("closest products: got %v, want %v", names, want)
+	}
+	last := matches[len(matches)-1]
+	want := []string{"ports: profile lacks 3x10G-copper 8x1G-copper", "ports: profile has extra 1x10G-fiber 8x2.5G-copper"}
+	if last.Product.Name != "Zyxel XGS1250-12" || !reflect.DeepEqual(last.Differences, want) {
+		t.Errorf("unexpected farthest match %+v", last)
+	}
+}
+
+func TestProfileEntry(t *testing.T) {
+	xmg := corpus.Lookup(t, "xmg1915-10e")
+	fp := Compute(xmg.Switch)
+	db, err := ParseDB(strings.NewReader(fp.Entry("profile")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = append(db, Builtin()...)
+
+	// The entry made from the profile matches it exactly, the products of
+	// the builtin database sharing its port classes only by them.
+	matches := Identify(fp, db)
+	if m := matches[0]; m.Product.Name != "profile" || !m.Exact || m.Product.Hash != fp.Hash() {
+		t.Errorf("unexpected best match %+v", m)
+	}
+	for _, m := range matches[1:3] {
+		if m.Exact || m.Score != 100 {
+			t.Errorf("unexpected match %+v", m)
+		}
+	}
+
+	// A board with the same port classes and a 2.5G PHY per port is told
+	// apart from the profile.
+	sw := *xmg.Switch
+	sw.Phys = nil
+	for i := range 8 {
+		sw.Phys = append(sw.Phys, &rtl.Phy{Chip: rtl.RTK_PHYTYPE_RTL8226B, MacId: uint8(i), PhyMax: 1})
+	}
+	matches = Identify(Compute(&sw), db)
+	if m := matches[0]; m.Product.Name == "profile" || m.Exact || m.Score != 100 {
+		t.Errorf("unexpected best match %+v", m)
+	}
+	for _, m := range matches {
+		if m.Product.Name == "profile" && (m.Exact || m.Score == 100) {
+			t.Errorf("unexpected profile match %+v", m)
+		}
+	}
+}
@@ -1,3 +1,8 @@
 module xioxoz.fr/hwpreader
 
 go 1.23.6
+
+require (
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/sys v0.32.0
+)
@@ -0,0 +1,209 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package hexmap prints the raw bytes of a switch descriptor annotated with
+// the fields they are decoded to, to reverse engineer unknown fields.
+//
+// The byte ranges are the ones read by the decoder with the layout of the
+// descriptor, so that the map follows the layouts. Each range is printed on
+// its own lines with its field path and decoded value, a byte of bitfields
+// with the value and mask of each of them, and marked:
+//
+//	~ alignment or padding bytes, noted when they are not zero
+//	- entries following the end marker of a table, merged per table
+//	? values unknown to the decoder, with its warning
+//	! bytes not decoded, after a decoding failure
+//
+// Ranges longer than a line are wrapped, repeated lines being replaced by a
+// star as hexdump -C does.
+package hexmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// bytesPerLine is the number of bytes printed per line.
+const bytesPerLine = 16
+
+// Markers of the byte ranges.
+const (
+	FIELD     = ' '
+	PADDING   = '~'
+	UNUSED    = '-'
+	UNKNOWN   = '?'
+	UNDECODED = '!'
+)
+
+// segment is a range of bytes printed with a label.
+type segment struct {
+	off    int64
+	size   int
+	marker byte
+	label  string
+}
+
+// Generate writes the annotated hexdump of the descriptor sw decoded from
+// data, which holds its raw bytes from the absolute offset base. fields and
+// warnings are the ones returned by rtl.Switch.DecodeFields.
+func Generate(w io.Writer, sw *rtl.Switch, data []byte, base int64, fields []rtl.Field, warnings []rtl.Warning) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s at 0x%x, %d bytes\n", sw.ChipId, base, len(data))
+	fmt.Fprintf(bw, "# %c padding, %c unused entries, %c unknown values, %c undecoded bytes\n", PADDING, UNUSED, UNKNOWN, UNDECODED)
+
+	segments, others := segments(sw, data, base, fields, warnings)
+	for _, s := range segments {
+		start := s.off - base
+		dump(bw, s, data[start:start+int64(s.size)])
+	}
+	for _, w := range others {
+		fmt.Fprintf(bw, "# warning: %s\n", w)
+	}
+	return bw.Flush()
+}
+
+// segments splits the descriptor in labeled byte ranges and returns the
+// warnings not related to a field.
+func segments(sw *rtl.Switch, data []byte, base int64, fields []rtl.Field, warnings []rtl.Warning) ([]segment, []rtl.Warning) {
+	type key struct {
+		off  int64
+		path string
+	}
+	unknown := make(map[key]string)
+	var others []rtl.Warning
+	for _, w := range warnings {
+		if _, ok := rtl.FieldAt(fields, w.Offset); ok {
+			unknown[key{w.Offset, w.Path}] = w.Message
+		} else {
+			others = append(others, w)
+		}
+	}
+	// The values of the entries, by entry path.
+	values := make(map[string]map[string]string)
+	value := func(path string) string {
+		entry, name := "", path
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			entry, name = path[:i], path[i+1:]
+		}
+		if _, ok := values[entry]; !ok {
+			values[entry] = make(map[string]string)
+			for _, v := range sw.Values(entry) {
+				values[entry][v.Name] = v.Value
+			}
+		}
+		return values[entry][name]
+	}
+
+	var segments []segment
+	end := base
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		s := segment{off: f.Offset, size: f.Size, marker: FIELD, label: f.Path}
+		switch {
+		case f.Unused:
+			// Merge the unused entries of the table.
+			table, _, _ := strings.Cut(f.Path, "[")
+			first, last := entry(f.Path), entry(f.Path)
+			for i+1 < len(fields) && fields[i+1].Unused && strings.HasPrefix(fields[i+1].Path, table+"[") {
+				i++
+				s.size += fields[i].Size
+				last = entry(fields[i].Path)
+			}
+			s.marker = UNUSED
+			s.label = first + " unused"
+			if last != first {
+				s.label = first + " to " + last + " unused"
+			}
+		case f.Pad:
+			s.marker = PADDING
+			if !strings.HasSuffix(f.Path, "pad") {
+				s.label = "padding before " + f.Path
+			}
+			if !zero(data[f.Offset-base : f.Offset-base+int64(f.Size)]) {
+				s.label += ", not zero"
+			}
+		case len(f.Bits) > 0:
+			// A byte of bitfields lists them all with their mask.
+			var values []string
+			if msg, ok := unknown[key{f.Offset, f.Path}]; ok {
+				s.marker = UNKNOWN
+				values = append(values, msg)
+			}
+			for _, b := range f.Bits {
+				if v := value(entry(f.Path) + "." + b.Name); v != "" {
+					values = append(values, fmt.Sprintf("%s = %s (0x%02x)", b.Name, v, b.Mask))
+				} else {
+					values = append(values, fmt.Sprintf("%s (0x%02x)", b.Name, b.Mask))
+				}
+			}
+			s.label += ": " + strings.Join(values, ", ")
+		default:
+			if msg, ok := unknown[key{f.Offset, f.Path}]; ok {
+				s.marker = UNKNOWN
+				s.label += ": " + msg
+			} else if v := value(f.Path); v != "" {
+				s.label += " = " + v
+			}
+		}
+		segments = append(segments, s)
+		end = s.off + int64(s.size)
+	}
+	if rest := base + int64(len(data)) - end; rest > 0 {
+		segments = append(segments, segment{off: end, size: int(rest), marker: UNDECODED, label: "undecoded"})
+	}
+	return segments, others
+}
+
+// entry returns the table entry of a field path, e.g. ports[3] for
+// ports[3].mac_id.
+func entry(path string) string {
+	e, _, _ := strings.Cut(path, "].")
+	return e + "]"
+}
+
+func zero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// dump writes the bytes of a segment, labeled on its first line. Lines
+// repeating the previous one are replaced by a star.
+func dump(w *bufio.Writer, s segment, b []byte) {
+	var prev []byte
+	star := false
+	for i := 0; i < len(b); i += bytesPerLine {
+		line := b[i:min(i+bytesPerLine, len(b))]
+		if i > 0 && len(line) == bytesPerLine && string(line) == string(prev) {
+			if !star {
+				fmt.Fprintln(w, "*")
+				star = true
+			}
+			continue
+		}
+		star = false
+		prev = line
+		var hex strings.Builder
+		for j, c := range line {
+			if j > 0 {
+				hex.WriteByte(' ')
+			}
+			fmt.Fprintf(&hex, "%02x", c)
+		}
+		label := ""
+		if i == 0 {
+			label = s.label
+		}
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%08x  %-*s  %c %s", s.off+int64(i), 3*bytesPerLine-1, hex.String(), s.marker, label), " "))
+	}
+	if star {
+		// Show where the repeated lines end.
+		fmt.Fprintf(w, "%08x\n", s.off+int64(len(b)))
+	}
+}
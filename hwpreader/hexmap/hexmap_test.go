@@ -0,0 +1,85 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package hexmap
+
+import (
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// hexmap decodes a copy of the XMG1915-10E board of the corpus, modified by
+// edit, and returns its annotated hexdump.
+func hexmap(t *testing.T, edit func(data []byte) []byte) string {
+	t.Helper()
+	for _, b := range corpus.Boards(t) {
+		if b.Name != "xmg1915-10e" {
+			continue
+		}
+		data := edit(append([]byte(nil), b.Data...))
+		sw := &rtl.Switch{}
+		fields, warnings, _ := sw.DecodeFields(data, b.Layout, 0)
+		var out strings.Builder
+		if err := Generate(&out, sw, data, 0, fields, warnings); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	t.Fatal("no xmg1915-10e board in the corpus")
+	return ""
+}
+
+func TestMarkers(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		edit func(data []byte) []byte
+		want []string
+	}{
+		{"fields", func(data []byte) []byte { return data }, []string{
+			"00000000  93 02 10 00                                        chip_id = RTL9302B (0x93021000)\n",
+			"00000005  00 00 00                                         ~ padding before swcore_access_method\n",
+			"000000d4  ff 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00  - ports[12] to ports[63] unused\n*\n00000414\n",
+			"00000496  00                                               ~ phys[0].pad\n",
+		}},
+		{"padding", func(data []byte) []byte {
+			data[0x06] = 0x5a
+			return data
+		}, []string{
+			"00000005  00 5a 00                                         ~ padding before swcore_access_method, not zero\n",
+		}},
+		{"unknown", func(data []byte) []byte {
+			data[0x416] = 0xfe
+			return data
+		}, []string{
+			"00000416  fe                                               ? serdes[0].mode: ",
+		}},
+		{"undecoded", func(data []byte) []byte {
+			return data[:0x44b]
+		}, []string{
+			"00000449  00 00                                            ! undecoded\n",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := hexmap(t, tt.edit)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Generate(&out, b.Switch, b.Data, 0, b.Fields, nil); err != nil {
+				t.Fatal(err)
+			}
+			corpus.Golden(t, b, "hexmap", []byte(out.String()))
+		})
+	}
+}
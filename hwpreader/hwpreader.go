@@ -3,45 +3,905 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
+	"xioxoz.fr/hwpreader/csrc"
+	"xioxoz.fr/hwpreader/dot"
+	"xioxoz.fr/hwpreader/dts"
+	"xioxoz.fr/hwpreader/elfimg"
+	"xioxoz.fr/hwpreader/fingerprint"
+	"xioxoz.fr/hwpreader/hexmap"
+	"xioxoz.fr/hwpreader/openwrt"
+	"xioxoz.fr/hwpreader/panel"
 	"xioxoz.fr/hwpreader/rtl"
+	"xioxoz.fr/hwpreader/tui"
+	"xioxoz.fr/hwpreader/ubootenv"
+	"xioxoz.fr/hwpreader/uimage"
+	"xioxoz.fr/hwpreader/zyxel"
 )
 
 var (
 	file   = flag.String("f", "", "file to parse")
 	offset = flag.Int64("o", int64(0), "offset in the file")
+	source = flag.String("c", "", "hardware profile C source to parse")
+	elfBin = flag.String("elf", "", "ELF file (kernel module, vmlinux, U-Boot) to extract the symbol from")
+	symbol = flag.String("sym", "", "name of the switch descriptor or hardware profile to use")
+	whole  = flag.Bool("profile", false, "decode a hardware profile (hwp_hwProfile_t) instead of a switch descriptor")
+	layout = flag.String("layout", "auto", "binary layout of the descriptors: auto, "+layoutNames())
+	base   = flag.Uint64("base", 0, "load address of the file, to resolve the pointers of a hardware profile")
+	outDir = flag.String("out", ".", "directory to write the unpacked components to")
+	like   = flag.String("like", "", "firmware image to take the versions, date and kernel header from (pack)")
+	vers   = flag.String("vers", "", "comma separated firmware versions of the supported hardware, e.g. V9.99(AAZI.0) (pack)")
+	date   = flag.String("date", "", "date of the version trailer of the firmware image (pack)")
+	magic  = flag.Uint64("magic", 0, fmt.Sprintf("uImage magic of the kernel, e.g. 0x%x for the GS1900 series, instead of the one of -like, of a uImage kernel or 0x%x (pack)", zyxel.GS1900_MAGIC, uimage.IH_MAGIC))
+	kernel = flag.String("kernel", "", "kernel to put in the firmware image, raw or uImage (pack)")
+	rootfs = flag.String("rootfs", "", "squashfs root filesystem to put in the firmware image (pack)")
+	loadAt = flag.Uint64("load", 0, fmt.Sprintf("load address of the kernel, instead of the one of -like, of a uImage kernel or 0x%x (pack)", zyxel.DefaultLoad))
+	entry  = flag.Uint64("entry", 0, fmt.Sprintf("entry point of the kernel, instead of the one of -like, of a uImage kernel or 0x%x (pack)", zyxel.DefaultLoad))
+	output = flag.String("w", "", "file to write the edited dump to (env)")
+	board  = flag.String("board", "", "board file giving the front panel label of each port (panel, openwrt format)")
+	compat = flag.String("compatible", "vendor,board", "compatible string of the board (openwrt format)")
+	dbFile = flag.String("db", "", "fingerprint database completing the builtin one (identify)")
+	format = flag.String("format", "text", "output format: text, json (diff, panel), dts, openwrt, dot, svg, hexmap, board (panel) or db (identify)")
 )
 
 func main() {
 	// Disable date and timestamps.
 	log.SetFlags(0)
 
-	// Parse the command line flags.
+	// Parse the command line flags. Flags are accepted before and after the
+	// command name.
 	flag.Parse()
-
-	if file == nil || *file == "" {
-		log.Fatal("input file required")
+	cmd := flag.Arg(0)
+	if cmd != "" {
+		flag.CommandLine.Parse(flag.Args()[1:])
 	}
 
-	f, err := os.Open(*file)
+	var err error
+	switch cmd {
+	case "":
+		err = dump()
+	case "validate":
+		err = validate()
+	case "diff":
+		err = diff(flag.Args())
+	case "symbols":
+		err = symbols()
+	case "scan":
+		err = scan()
+	case "unpack":
+		err = unpack()
+	case "pack":
+		err = pack(flag.Args())
+	case "env":
+		err = env(flag.Args())
+	case "panel":
+		err = frontPanel()
+	case "identify":
+		err = identify()
+	case "budget":
+		err = budget()
+	case "tui":
+		err = explore()
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// dump prints the switch descriptor in the requested format.
+func dump() error {
+	if isProfile() {
+		hp, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		return renderProfile(hp, *format)
+	}
+	if *format == "hexmap" {
+		// The undecoded bytes of a descriptor failing to decode are
+		// part of the map.
+		d, err := loadDescriptor("")
+		var perr *rtl.ParseError
+		if errors.As(err, &perr) && d != nil {
+			log.Print(err)
+		} else if err != nil {
+			return err
+		}
+		if d.data == nil {
+			return fmt.Errorf("the hexmap format requires a binary descriptor")
+		}
+		return hexmap.Generate(os.Stdout, d.sw, d.data, d.base, d.fields, d.warnings)
+	}
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	return render(s, *format)
+}
+
+// validate reports the inconsistencies of the switch descriptor and fails if
+// any of them is an error.
+func validate() error {
+	var findings []rtl.Finding
+	if isProfile() {
+		hp, err := loadProfile()
+		if err != nil {
+			return err
+		}
+		findings = hp.Validate()
+	} else {
+		s, err := load()
+		if err != nil {
+			return err
+		}
+		findings = s.Validate()
+	}
+	for _, f := range findings {
+		log.Print(f)
+	}
+	if rtl.HasErrors(findings) {
+		return fmt.Errorf("invalid hardware profile")
+	}
+	return nil
+}
+
+// render prints the switch descriptor in the requested format.
+func render(s *rtl.Switch, format string) error {
+	switch format {
+	case "text":
+		log.Print(s)
+	case "dts":
+		issues, err := dts.Generate(os.Stdout, s)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			log.Printf("warning: %s", issue)
+		}
+	case "openwrt":
+		pn, err := loadPanel(s)
+		if err != nil {
+			return err
+		}
+		issues, err := openwrt.Generate(os.Stdout, *compat, s, pn)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			log.Printf("warning: %s", issue)
+		}
+	case "dot", "svg":
+		return renderGraph(format, func(w io.Writer) error { return dot.Generate(w, s) })
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	return nil
+}
+
+// renderGraph writes the Graphviz diagram produced by gen, converted to SVG
+// by the dot command for the svg format.
+func renderGraph(format string, gen func(w io.Writer) error) error {
+	if format == "dot" {
+		return gen(os.Stdout)
+	}
+	var b bytes.Buffer
+	if err := gen(&b); err != nil {
+		return err
+	}
+	cmd := exec.Command("dot", "-Tsvg")
+	cmd.Stdin = &b
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("dot: %v (use -format dot if Graphviz is not installed)", err)
+	}
+	return nil
+}
+
+// renderProfile prints the hardware profile and the links between its units.
+func renderProfile(hp *rtl.HwProfile, format string) error {
+	switch format {
+	case "text":
+	case "dot", "svg":
+		return renderGraph(format, func(w io.Writer) error { return dot.GenerateProfile(w, hp) })
+	default:
+		return fmt.Errorf("unsupported profile format %q", format)
+	}
+	log.Printf("Profile %q (id 0x%x), CPU on unit %d", hp.Identifier.Name, hp.Identifier.Id, hp.Soc.SwDescpIndex)
+	for i, s := range hp.Units {
+		log.Printf("Unit %d:", i)
+		log.Print(s)
+	}
+	cascades, err := hp.Cascades()
+	for _, c := range cascades {
+		log.Printf("Cascade: %s", c)
+	}
+	if err != nil {
+		log.Printf("warning: %v", err)
+	}
+	return nil
+}
+
+// diff prints the changes between the switch descriptors of two files.
+func diff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("diff requires two files")
+	}
+	a, err := loadDescriptor(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := loadDescriptor(args[1])
+	if err != nil {
+		return err
+	}
+	changes := rtl.Diff(a.sw, b.sw)
+	switch *format {
+	case "text":
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if changes == nil {
+			changes = []rtl.Change{}
+		}
+		return enc.Encode(changes)
+	default:
+		return fmt.Errorf("unsupported diff format %q", *format)
+	}
+	return nil
+}
+
+// frontPanel prints the front panel ports of the switch descriptor: as a
+// faceplate for the text and svg formats, as a mapping for the json and board
+// formats.
+func frontPanel() error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	pn, err := loadPanel(s)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "text":
+		return pn.ASCII(os.Stdout)
+	case "svg":
+		return pn.SVG(os.Stdout, s.ChipId.String())
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(pn.Ports)
+	case "board":
+		return pn.WriteBoard(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported panel format %q", *format)
+	}
+}
+
+// loadPanel labels the front panel ports of the switch descriptor with the
+// board file given by the command line, in profile order without one.
+func loadPanel(s *rtl.Switch) (*panel.Panel, error) {
+	if *board == "" {
+		return panel.Derive(s), nil
+	}
+	f, err := os.Open(*board)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
+	pn, err := panel.ParseBoard(f, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", *board, err)
+	}
+	return pn, nil
+}
 
-	_, err = f.Seek(*offset, 0)
+// budget prints the bandwidth of the serdes and the maximum throughput of the
+// ports of the switch descriptor.
+func budget() error {
+	s, err := load()
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	return s.Budget().WriteTable(os.Stdout)
+}
+
+// explore opens the terminal explorer on the switch descriptor.
+func explore() error {
+	d, err := loadDescriptor("")
+	if err != nil {
+		return err
 	}
+	return tui.Run(tui.New(d.sw, d.data, d.base, d.fields))
+}
 
-	s := &rtl.Switch{}
-	if err := s.UnmarshalBinary(bufio.NewReader(f)); err != nil {
-		log.Fatal(err)
+// identify prints the fingerprint of the switch descriptor and the closest
+// known products, or the fingerprint as a database entry for the db format.
+func identify() error {
+	s, err := load()
+	if err != nil {
+		return err
+	}
+	fp := fingerprint.Compute(s)
+	switch *format {
+	case "text":
+	case "db":
+		fmt.Print(fp.Entry("Vendor Model"))
+		return nil
+	default:
+		return fmt.Errorf("unsupported identify format %q", *format)
 	}
 
-	log.Print(s)
+	db := fingerprint.Builtin()
+	if *dbFile != "" {
+		f, err := os.Open(*dbFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		more, err := fingerprint.ParseDB(f)
+		if err != nil {
+			return fmt.Errorf("%s: %v", *dbFile, err)
+		}
+		db = append(db, more...)
+	}
+	fmt.Printf("fingerprint %s\n%s\n", fp.Hash(), fp)
+	matches := fingerprint.Identify(fp, db)
+	for i, m := range matches {
+		if i == 5 {
+			break
+		}
+		exact := ""
+		if m.Exact {
+			exact = ", exact match"
+		}
+		fmt.Printf("%3d%% %s%s\n", m.Score, m.Product.Name, exact)
+		if m.Product.Note != "" {
+			fmt.Printf("     (%s)\n", m.Product.Note)
+		}
+		for _, d := range m.Differences {
+			fmt.Printf("     %s\n", d)
+		}
+	}
+	return nil
+}
+
+// symbols lists the data objects of the ELF file that may be switch
+// descriptors or hardware profiles, guessed from their size.
+func symbols() error {
+	if *elfBin == "" {
+		return fmt.Errorf("ELF file required")
+	}
+	im, err := elfimg.Open(*elfBin)
+	if err != nil {
+		return err
+	}
+	for _, s := range im.Symbols() {
+		kind := ""
+		if s.Size == rtl.ProfileSize {
+			kind = "hardware profile"
+		}
+		for _, l := range rtl.Layouts {
+			if s.Size == uint64(l.Size()) && l.Order == im.Order {
+				kind = "switch descriptor (" + l.Name + ")"
+			}
+		}
+		if kind != "" {
+			fmt.Printf("0x%08x %6d %-10s %s: %s\n", s.Addr, s.Size, s.Section, s.Name, kind)
+		}
+	}
+	return nil
+}
+
+// scan lists the switch descriptors found at any offset of the file, with
+// their most plausible layout.
+func scan() error {
+	if *file == "" {
+		return fmt.Errorf("input file required")
+	}
+	r, loadAddr, closer, err := openImage(*file)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, math.MaxInt64))
+	if err != nil {
+		return err
+	}
+	for _, c := range rtl.Scan(data, 0) {
+		fmt.Printf("0x%08x 0x%08x %-8s %s: %d ports, score %d\n",
+			c.Offset, loadAddr+uint64(c.Offset), c.Layout, c.Switch.ChipId, len(c.Switch.Ports), c.Score)
+	}
+	return nil
+}
+
+// load decodes the switch descriptor designated by the command line flags.
+func load() (*rtl.Switch, error) {
+	d, err := loadDescriptor("")
+	if err != nil {
+		return nil, err
+	}
+	return d.sw, nil
+}
+
+// descriptor is a switch descriptor and the raw bytes it was decoded from,
+// starting at the offset base of the file. The bytes and fields are nil for
+// descriptors parsed from C sources.
+type descriptor struct {
+	sw       *rtl.Switch
+	data     []byte
+	base     int64
+	fields   []rtl.Field
+	warnings []rtl.Warning
+}
+
+// loadDescriptor decodes the switch descriptor of the file at path, keeping
+// its raw bytes, or the one designated by the command line flags if path is
+// empty. C sources given by path are recognized by their extension and ELF
+// files by their magic number, they both use the symbol given by the command
+// line. Other files are decoded at the offset given by the command line.
+func loadDescriptor(path string) (*descriptor, error) {
+	isSource, isObject := strings.HasSuffix(path, ".c"), path != "" && isELF(path)
+	switch {
+	case path != "":
+	case *source != "":
+		path, isSource = *source, true
+	case *elfBin != "":
+		path, isObject = *elfBin, true
+	case *file != "":
+		path = *file
+	default:
+		return nil, fmt.Errorf("input file required")
+	}
+
+	switch {
+	case isSource:
+		sw, err := loadSource(path, *symbol)
+		if err != nil {
+			return nil, err
+		}
+		return &descriptor{sw: sw}, nil
+	case isObject:
+		im, s, err := openSymbol(path, *symbol)
+		if err != nil {
+			return nil, err
+		}
+		return readDescriptor(im, int64(s.Addr))
+	}
+	r, _, closer, err := openImage(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	return readDescriptor(r, *offset)
+}
+
+// isProfile returns true if the command line designates a hardware profile:
+// either explicitly or by an ELF symbol of the size of a profile.
+func isProfile() bool {
+	if *whole || *elfBin == "" || *symbol == "" {
+		return *whole
+	}
+	im, err := elfimg.Open(*elfBin)
+	if err != nil {
+		return false
+	}
+	s, err := im.Lookup(*symbol)
+	return err == nil && s.Size == rtl.ProfileSize
+}
+
+// loadProfile decodes the hardware profile designated by the command line
+// flags.
+func loadProfile() (*rtl.HwProfile, error) {
+	if *source != "" {
+		return loadSourceProfile(*source, *symbol)
+	}
+	if *elfBin != "" {
+		im, s, err := openSymbol(*elfBin, *symbol)
+		if err != nil {
+			return nil, err
+		}
+		return decodeProfile(im, s.Addr)
+	}
+	if *file == "" {
+		return nil, fmt.Errorf("input file required")
+	}
+	r, loadAddr, closer, err := openImage(*file)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	if *base != 0 {
+		loadAddr = *base
+	}
+	if loadAddr+uint64(*offset) > math.MaxUint32 {
+		return nil, fmt.Errorf("base address 0x%x out of the 32 bits address space", loadAddr)
+	}
+	return decodeProfile(&rtl.Image{R: r, Base: uint32(loadAddr)}, uint32(loadAddr)+uint32(*offset))
+}
+
+// decodeProfile decodes the hardware profile at addr with the layout given by
+// the command line.
+func decodeProfile(mem rtl.Memory, addr uint32) (*rtl.HwProfile, error) {
+	if *layout != "auto" {
+		l, err := rtl.LayoutByName(*layout)
+		if err != nil {
+			return nil, err
+		}
+		hp, warnings, err := rtl.ReadProfile(mem, addr, l)
+		logWarnings(warnings)
+		return hp, err
+	}
+	hp, l, warnings, err := rtl.DetectProfile(mem, addr)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("detected layout %s", l)
+	logWarnings(warnings)
+	return hp, nil
+}
+
+// logWarnings prints the warnings of the decoding of a descriptor.
+func logWarnings(warnings []rtl.Warning) {
+	for _, w := range warnings {
+		log.Printf("warning: %s", w)
+	}
+}
+
+// openImage opens a binary file. Zyxel firmware images, with or without
+// bootloader, are replaced by their uncompressed kernel, whose load address is
+// returned.
+func openImage(path string) (io.ReaderAt, uint64, func(), error) {
+	img, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if zyxel.Find(img) < 0 {
+		return bytes.NewReader(img), 0, func() {}, nil
+	}
+	fw, err := zyxel.Parse(img)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	k := fw.Kernel
+	data, err := k.Decompress()
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%s: kernel: %v", path, err)
+	}
+	log.Printf("using the kernel %q of the firmware, loaded at 0x%x", k.ImageName(), k.Load)
+	return bytes.NewReader(data), uint64(k.Load), func() {}, nil
+}
+
+// loadFirmware decodes a Zyxel firmware image.
+func loadFirmware(path string) (*zyxel.Firmware, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fw, err := zyxel.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return fw, nil
+}
+
+// unpack writes the components of a Zyxel firmware image, and the
+// uncompressed kernel, to the output directory.
+func unpack() error {
+	if *file == "" {
+		return fmt.Errorf("input file required")
+	}
+	fw, err := loadFirmware(*file)
+	if err != nil {
+		return err
+	}
+	log.Printf("firmware %s of %s", strings.Join(fw.Versions, ", "), fw.Date)
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+	write := func(name string, data []byte) error {
+		path := filepath.Join(*outDir, name)
+		log.Printf("%s: %d bytes", path, len(data))
+		return os.WriteFile(path, data, 0644)
+	}
+	if fw.Bootloader == nil {
+		log.Printf("no bootloader in the image, it starts with the kernel")
+	} else if err := write("bootloader.bin", fw.Bootloader); err != nil {
+		return err
+	}
+	k, err := fw.Kernel.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := write("kernel.uimage", k); err != nil {
+		return err
+	}
+	data, err := fw.Kernel.Decompress()
+	if err != nil {
+		return fmt.Errorf("kernel: %v", err)
+	}
+	if err := write("kernel.bin", data); err != nil {
+		return err
+	}
+	if fw.Rootfs == nil {
+		return nil
+	}
+	return write("rootfs.squashfs", fw.Rootfs)
+}
+
+// pack builds a Zyxel firmware image from a kernel and a root filesystem.
+func pack(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("pack requires an output file")
+	}
+	if *kernel == "" {
+		return fmt.Errorf("kernel required")
+	}
+	s := zyxel.Settings{Date: *date, Magic: uint32(*magic), Load: uint32(*loadAt), Entry: uint32(*entry)}
+	if *vers != "" {
+		s.Versions = strings.Split(*vers, ",")
+	}
+	var ref *zyxel.Firmware
+	if *like != "" {
+		var err error
+		if ref, err = loadFirmware(*like); err != nil {
+			return err
+		}
+	}
+	k, err := os.ReadFile(*kernel)
+	if err != nil {
+		return err
+	}
+	var fs []byte
+	if *rootfs != "" {
+		if fs, err = os.ReadFile(*rootfs); err != nil {
+			return err
+		}
+	}
+	fw, err := zyxel.Pack(k, fs, ref, s)
+	if err != nil {
+		return err
+	}
+	img, err := fw.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	// Check that the image unpacks.
+	if _, err := zyxel.Parse(img); err != nil {
+		return fmt.Errorf("generated image: %v", err)
+	}
+	log.Printf("firmware %s of %s: %d bytes", strings.Join(fw.Versions, ", "), fw.Date, len(img))
+	return os.WriteFile(args[0], img, 0644)
+}
+
+// env lists the U-Boot environment blocks of a flash dump, or edits one of
+// them with the "set name=value..." and "unset name..." actions.
+func env(args []string) error {
+	if *file == "" {
+		return fmt.Errorf("input file required")
+	}
+	dump, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	blocks := ubootenv.Locate(dump)
+	if len(blocks) == 0 {
+		return fmt.Errorf("%s: no environment found", *file)
+	}
+	if len(args) == 0 {
+		for _, b := range blocks {
+			e := b.Env
+			kind := "plain"
+			if e.Redundant {
+				kind = fmt.Sprintf("redundant, flags %d", e.Flags)
+			}
+			fmt.Printf("# 0x%x: %d bytes, %s, %v\n", b.Offset, e.Size, kind, e.Order)
+			for _, v := range e.Vars() {
+				fmt.Println(v)
+			}
+		}
+		return nil
+	}
+
+	// Edit the block at the offset given with -o, or else the only
+	// environment: the newest copy of a redundant one.
+	offsetSet := false
+	flag.Visit(func(f *flag.Flag) {
+		offsetSet = offsetSet || f.Name == "o"
+	})
+	idx := -1
+	switch {
+	case offsetSet:
+		for i := range blocks {
+			if blocks[i].Offset == *offset {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("no environment at offset 0x%x, %d found", *offset, len(blocks))
+		}
+	case len(blocks) == 1:
+		idx = 0
+	case len(blocks) == 2 && ubootenv.Pair(blocks, 0) == 1:
+		idx = 0
+		if !ubootenv.Newer(blocks[0].Env, blocks[1].Env) {
+			idx = 1
+		}
+	default:
+		return fmt.Errorf("%d environments found, choose one with -o", len(blocks))
+	}
+	block := &blocks[idx]
+	if *output == "" {
+		return fmt.Errorf("output file required")
+	}
+	switch args[0] {
+	case "set":
+		for _, arg := range args[1:] {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("expected name=value, got %q", arg)
+			}
+			if err := block.Env.Set(name, value); err != nil {
+				return err
+			}
+		}
+	case "unset":
+		for _, name := range args[1:] {
+			if !block.Env.Unset(name) {
+				log.Printf("warning: %s is not defined", name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown env action %q", args[0])
+	}
+	off, err := ubootenv.Save(dump, blocks, idx)
+	if err != nil {
+		return err
+	}
+	log.Printf("environment of 0x%x saved at 0x%x", block.Offset, off)
+	return os.WriteFile(*output, dump, 0644)
+}
+
+// isELF returns true if the file starts with the ELF magic number.
+func isELF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, len(elf.ELFMAG))
+	_, err = io.ReadFull(f, magic)
+	return err == nil && string(magic) == elf.ELFMAG
+}
+
+// openSymbol loads an ELF file and resolves the symbol called name.
+func openSymbol(path, name string) (*elfimg.Image, elfimg.Symbol, error) {
+	if name == "" {
+		return nil, elfimg.Symbol{}, fmt.Errorf("symbol name required, see the symbols command")
+	}
+	im, err := elfimg.Open(path)
+	if err != nil {
+		return nil, elfimg.Symbol{}, fmt.Errorf("%s: %v", path, err)
+	}
+	s, err := im.Lookup(name)
+	if err != nil {
+		return nil, elfimg.Symbol{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return im, s, nil
+}
+
+// readDescriptor decodes the switch descriptor found at off in r, with the
+// layout given by the command line, and records the fields read. When the
+// decoding fails, the descriptor holds the fields read before the failure
+// along with the *rtl.ParseError.
+func readDescriptor(r io.ReaderAt, off int64) (*descriptor, error) {
+	size := 0
+	for _, l := range rtl.Layouts {
+		size = max(size, l.Size())
+	}
+	data := make([]byte, size)
+	n, err := r.ReadAt(data, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+
+	var l *rtl.Layout
+	if *layout == "auto" {
+		if l, err = rtl.DetectLayout(data); err != nil {
+			return nil, err
+		}
+		log.Printf("detected layout %s", l)
+	} else if l, err = rtl.LayoutByName(*layout); err != nil {
+		return nil, err
+	}
+
+	d := &descriptor{sw: &rtl.Switch{}, data: data[:min(len(data), l.Size())], base: off}
+	d.fields, d.warnings, err = d.sw.DecodeFields(d.data, l, off)
+	logWarnings(d.warnings)
+	return d, err
+}
+
+// layoutNames returns the names of the known layouts, for the usage message.
+func layoutNames() string {
+	var names []string
+	for _, l := range rtl.Layouts {
+		names = append(names, l.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// loadSource parses a hardware profile C source and returns the switch
+// descriptor called name, or the first one if name is empty.
+func loadSource(path, name string) (*rtl.Switch, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := csrc.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if name != "" {
+		return f.Switch(name)
+	}
+	descs, err := f.Switches()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(descs) == 0 {
+		return nil, fmt.Errorf("%s: no switch descriptor found", path)
+	}
+	if len(descs) > 1 {
+		log.Printf("%d switch descriptors found, using %s", len(descs), descs[0].Name)
+	}
+	return descs[0].Switch, nil
+}
+
+// loadSourceProfile parses a hardware profile C source and returns the
+// hardware profile called name, or the first one if name is empty.
+func loadSourceProfile(path, name string) (*rtl.HwProfile, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := csrc.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	profiles, err := f.Profiles()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, p := range profiles {
+		if name == "" || p.Name == name {
+			if name == "" && len(profiles) > 1 {
+				log.Printf("%d hardware profiles found, using %s", len(profiles), p.Name)
+			}
+			return p.Profile, nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("%s: hardware profile %s not found", path, name)
+	}
+	return nil, fmt.Errorf("%s: no hardware profile found", path)
 }
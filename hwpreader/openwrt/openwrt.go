@@ -0,0 +1,173 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package openwrt generates the board.d snippets of the OpenWrt realtek target
+// from a decoded hardware profile: the case of realtek_setup_interfaces() in
+// 02_network listing the front panel ports, and the case of 01_leds setting a
+// netdev trigger for each port LED matching its vendor LED definition.
+//
+// Interfaces are named after the front panel labels: numeric labels become
+// lanN, other labels are lowercased, e.g. SFP1 becomes sfp1. The LED class
+// devices are expected to be named <interface>:<led>, or <interface>_c:<led>
+// and <interface>_f:<led> for combo ports with a LED set per medium: as the
+// DTS gives no name to the port LEDs, they must be matched by hand.
+package openwrt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"xioxoz.fr/hwpreader/panel"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// generator accumulates the snippets and the unsupported elements.
+type generator struct {
+	b      strings.Builder
+	issues []string
+}
+
+// flag records an element that cannot be expressed and leaves a comment at
+// the current position of the output.
+func (g *generator) flag(indent int, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	g.issues = append(g.issues, msg)
+	g.line(indent, "# FIXME: %s", msg)
+}
+
+func (g *generator) line(indent int, format string, args ...any) {
+	g.b.WriteString(strings.Repeat("\t", indent))
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteByte('\n')
+}
+
+// Interface returns the name of the network interface of a front panel port.
+func Interface(p *panel.Port) string {
+	numeric := true
+	for _, r := range p.Label {
+		numeric = numeric && unicode.IsDigit(r)
+	}
+	if numeric {
+		return "lan" + p.Label
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(p.Label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Generate writes the 02_network and 01_leds snippets of the board, named by
+// its compatible string, to w. It returns the description of the elements of
+// the profile that the snippets cannot express.
+func Generate(w io.Writer, board string, sw *rtl.Switch, pn *panel.Panel) ([]string, error) {
+	g := &generator{}
+	g.network(board, pn)
+	g.line(0, "")
+	g.leds(board, sw, pn)
+	_, err := io.WriteString(w, g.b.String())
+	return g.issues, err
+}
+
+func (g *generator) network(board string, pn *panel.Panel) {
+	var ifaces []string
+	for i := range pn.Ports {
+		ifaces = append(ifaces, Interface(&pn.Ports[i]))
+	}
+	g.line(0, "# target/linux/realtek/base-files/etc/board.d/02_network")
+	g.line(0, "# realtek_setup_interfaces()")
+	g.line(1, "%s)", board)
+	g.line(2, "ucidef_set_interface_lan \"%s\"", strings.Join(ifaces, " "))
+	g.line(2, ";;")
+}
+
+func (g *generator) leds(board string, sw *rtl.Switch, pn *panel.Panel) {
+	g.line(0, "# target/linux/realtek/base-files/etc/board.d/01_leds")
+	g.line(1, "%s)", board)
+	if sw.Leds == nil || sw.Leds.LedIfSel == rtl.LED_IF_SEL_NONE {
+		g.line(2, "# no port LEDs")
+		g.line(2, ";;")
+		return
+	}
+	for i := range pn.Ports {
+		p := &pn.Ports[i]
+		iface := Interface(p)
+		// LEDs shared by both media follow the copper set.
+		switch {
+		case p.CopperLed != nil && p.FiberLed != nil && p.SeparateLeds():
+			g.ledSet(iface, "copper ", iface+"_c", sw.Leds, *p.CopperLed)
+			g.ledSet(iface, "fiber ", iface+"_f", sw.Leds, *p.FiberLed)
+		case p.CopperLed != nil:
+			g.ledSet(iface, "", iface, sw.Leds, *p.CopperLed)
+		case p.FiberLed != nil:
+			g.ledSet(iface, "", iface, sw.Leds, *p.FiberLed)
+		}
+	}
+	g.line(2, ";;")
+}
+
+// ledSet writes the triggers of the LEDs of a port defined by a LED set.
+func (g *generator) ledSet(iface, kind, prefix string, leds *rtl.Leds, set rtl.LedSel) {
+	if int(set) >= len(leds.LedSet) {
+		g.flag(2, "%s %sLED set %d is out of range", iface, kind, set)
+		return
+	}
+	for j, led := range leds.LedSet[set].Led {
+		if led.Off() {
+			continue
+		}
+		name := fmt.Sprintf("%s_%d", prefix, j)
+		g.line(2, "# %s", led)
+		g.line(2, "ucidef_set_led_netdev \"%s\" \"%s %sLED %d\" \"%s:%d\" \"%s\" \"%s\"",
+			name, iface, kind, j, prefix, j, iface, g.modes(iface, led))
+	}
+}
+
+// Trigger modes of the link speeds.
+var linkModes = []struct {
+	cond rtl.LedCond
+	mode string
+}{
+	{rtl.LED_10M, "link_10"},
+	{rtl.LED_100M, "link_100"},
+	{rtl.LED_1G, "link_1000"},
+	{rtl.LED_2_5G, "link_2500"},
+	{rtl.LED_5G, "link_5000"},
+	{rtl.LED_10G, "link_10000"},
+}
+
+// modes returns the netdev trigger modes equivalent to the LED definition.
+func (g *generator) modes(iface string, led rtl.LedWord) string {
+	cond := led.Conditions()
+	speeds := cond & rtl.LED_ALL_SPEEDS
+	var modes []string
+	if cond&rtl.LED_LINK != 0 {
+		if speeds == rtl.LED_ALL_SPEEDS {
+			modes = append(modes, "link")
+		} else {
+			for _, l := range linkModes {
+				if speeds&l.cond != 0 {
+					modes = append(modes, l.mode)
+				}
+			}
+			if speeds&rtl.LED_500M != 0 {
+				g.flag(2, "%s 500M link speed has no netdev trigger mode", iface)
+			}
+		}
+	}
+	// The trigger blinks on activity whatever the link speed: restricting
+	// the lit link speeds is the closest it gets.
+	if cond&rtl.LED_ACT != 0 {
+		modes = append(modes, "tx", "rx")
+		if cond&rtl.LED_LINK == 0 && speeds != rtl.LED_ALL_SPEEDS {
+			g.flag(2, "%s activity limited to %s blinks at any speed", iface, strings.Join(led.Speeds(), ","))
+		}
+	}
+	if cond&rtl.LED_LINK_FLASH != 0 {
+		g.flag(2, "%s link flash condition has no netdev trigger mode", iface)
+	}
+	return strings.Join(modes, " ")
+}
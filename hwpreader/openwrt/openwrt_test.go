@@ -0,0 +1,83 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package openwrt
+
+import (
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/panel"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// comboBoard returns the XMG1915-10E board of the corpus, its first SFP+
+// cage turned into a combo port and a fiber LED flashing on link at 1G.
+func comboBoard(t *testing.T) *rtl.Switch {
+	sw := corpus.Lookup(t, "xmg1915-10e").Switch
+	p := sw.Ports[8]
+	p.Medi, p.LedC, p.LedLayout = rtl.HWP_COMBO, 0, rtl.DOUBLE_SET
+	sw.Leds.LedSet[1].Led[1] = rtl.LedWord(rtl.LED_ACT | rtl.LED_LINK_FLASH | rtl.LED_1G)
+	return sw
+}
+
+func TestInterface(t *testing.T) {
+	for label, want := range map[string]string{"1": "lan1", "10": "lan10", "SFP1": "sfp1", "Combo-2": "combo2"} {
+		if got := Interface(&panel.Port{Label: label}); got != want {
+			t.Errorf("%s: expected %s, got %s", label, want, got)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	sw := comboBoard(t)
+	pn, err := panel.ParseBoard(strings.NewReader("1 0\n2 1\nSFP1 24\n"), sw)
+	if err != nil {
+		t.Fatalf("board: %v", err)
+	}
+	var b strings.Builder
+	issues, err := Generate(&b, "zyxel,xmg1915-10e", sw, pn)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"\tzyxel,xmg1915-10e)\n\t\tucidef_set_interface_lan \"lan1 lan2 sfp1\"\n\t\t;;",
+		`ucidef_set_led_netdev "lan1_0" "lan1 LED 0" "lan1:0" "lan1" "link tx rx"`,
+		`ucidef_set_led_netdev "lan2_1" "lan2 LED 1" "lan2:1" "lan2" "link_2500"`,
+		`ucidef_set_led_netdev "sfp1_c_0" "sfp1 copper LED 0" "sfp1_c:0" "sfp1" "link tx rx"`,
+		`ucidef_set_led_netdev "sfp1_f_0" "sfp1 fiber LED 0" "sfp1_f:0" "sfp1" "link_10000 tx rx"`,
+		`ucidef_set_led_netdev "sfp1_f_1" "sfp1 fiber LED 1" "sfp1_f:1" "sfp1" "tx rx"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if len(issues) != 2 || !strings.Contains(issues[0], "activity limited to 1G") || !strings.Contains(issues[1], "link flash") {
+		t.Errorf("unexpected issues: %q", issues)
+	}
+}
+
+func TestGenerateWithoutLeds(t *testing.T) {
+	sw := comboBoard(t)
+	sw.Leds.LedIfSel = rtl.LED_IF_SEL_NONE
+	var b strings.Builder
+	if _, err := Generate(&b, "vendor,board", sw, panel.Derive(sw)); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if out := b.String(); strings.Contains(out, "ucidef_set_led_netdev") || !strings.Contains(out, "# no port LEDs") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			var out strings.Builder
+			if _, err := Generate(&out, "vendor,"+b.Name, b.Switch, panel.Derive(b.Switch)); err != nil {
+				t.Fatal(err)
+			}
+			corpus.Golden(t, b, "openwrt", []byte(out.String()))
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package panel
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// A faceplate is made of blocks of consecutive ports sharing their medium and
+// speed. Blocks of at least stackedMin ports are drawn on two rows, odd
+// positions on top as on stacked RJ45 cages.
+const stackedMin = 8
+
+// block is a group of ports of the faceplate.
+type block struct {
+	ports []*Port
+	rows  int
+}
+
+// cols returns the number of columns of the block.
+func (b *block) cols() int {
+	return (len(b.ports) + b.rows - 1) / b.rows
+}
+
+// at returns the port at the row and column of the block, nil if none.
+func (b *block) at(row, col int) *Port {
+	i := col*b.rows + row
+	if i >= len(b.ports) {
+		return nil
+	}
+	return b.ports[i]
+}
+
+func (pn *Panel) blocks() []*block {
+	var blocks []*block
+	for i := range pn.Ports {
+		p := &pn.Ports[i]
+		if n := len(blocks); n > 0 {
+			last := blocks[n-1].ports[0]
+			if last.medi == p.medi && last.eth == p.eth {
+				blocks[n-1].ports = append(blocks[n-1].ports, p)
+				continue
+			}
+		}
+		blocks = append(blocks, &block{ports: []*Port{p}})
+	}
+	for _, b := range blocks {
+		b.rows = 1
+		if len(b.ports) >= stackedMin {
+			b.rows = 2
+		}
+	}
+	return blocks
+}
+
+// connector returns the cage of the port.
+func (p *Port) connector() string {
+	switch p.medi {
+	case rtl.HWP_COPPER:
+		return "RJ45"
+	case rtl.HWP_FIBER:
+		if p.eth == rtl.HWP_XGE {
+			return "SFP+"
+		}
+		return "SFP"
+	case rtl.HWP_COMBO:
+		return "COMBO"
+	default:
+		return "?"
+	}
+}
+
+// leds describes the LED sets of the port: cN for copper, fN for fiber, the
+// sets of combo ports are joined by = when they share the port LEDs and by |
+// when each has its own LEDs.
+func (p *Port) leds() string {
+	set := func(prefix string, led *rtl.LedSel) string {
+		if led == nil {
+			return prefix + "-"
+		}
+		return fmt.Sprintf("%s%d", prefix, *led)
+	}
+	switch p.medi {
+	case rtl.HWP_COPPER:
+		return set("c", p.CopperLed)
+	case rtl.HWP_FIBER:
+		return set("f", p.FiberLed)
+	case rtl.HWP_COMBO:
+		sep := "="
+		if p.SeparateLeds() {
+			sep = "|"
+		}
+		return set("c", p.CopperLed) + sep + set("f", p.FiberLed)
+	default:
+		return ""
+	}
+}
+
+// lines returns the text of the cell of the port.
+func (p *Port) lines() []string {
+	return []string{p.Label, p.Speed, p.connector(), p.leds()}
+}
+
+// cellLines is the number of lines of text of a cell.
+const cellLines = 4
+
+// ASCII draws the faceplate with ASCII characters.
+func (pn *Panel) ASCII(w io.Writer) error {
+	width := 6
+	for i := range pn.Ports {
+		for _, l := range pn.Ports[i].lines() {
+			width = max(width, len(l)+2)
+		}
+	}
+	center := func(s string) string {
+		left := (width - len(s)) / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-len(s)-left)
+	}
+
+	// Each block is drawn in its own column of text, then the columns are
+	// pasted side by side.
+	var columns [][]string
+	height := 0
+	for _, b := range pn.blocks() {
+		var col []string
+		border := "+" + strings.Repeat(strings.Repeat("-", width)+"+", b.cols())
+		for row := 0; row < b.rows; row++ {
+			col = append(col, border)
+			for l := 0; l < cellLines; l++ {
+				line := "|"
+				for c := 0; c < b.cols(); c++ {
+					text := ""
+					if p := b.at(row, c); p != nil {
+						text = p.lines()[l]
+					}
+					line += center(text) + "|"
+				}
+				col = append(col, line)
+			}
+		}
+		col = append(col, border)
+		columns = append(columns, col)
+		height = max(height, len(col))
+	}
+
+	var out strings.Builder
+	for l := 0; l < height; l++ {
+		var line strings.Builder
+		for i, col := range columns {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			if l < len(col) {
+				line.WriteString(col[l])
+			} else {
+				line.WriteString(strings.Repeat(" ", len(col[0])))
+			}
+		}
+		out.WriteString(strings.TrimRight(line.String(), " "))
+		out.WriteByte('\n')
+	}
+	out.WriteString("LEDs: cN copper set, fN fiber set, = shared (SINGLE_SET), | separate (DOUBLE_SET)\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Dimensions of the SVG faceplate, in pixels.
+const (
+	svgCellWidth  = 56
+	svgCellHeight = 64
+	svgGap        = 16
+	svgMargin     = 12
+	svgLine       = 13
+)
+
+// Fill colors of the cells by medium.
+var svgFills = map[rtl.Medium]string{
+	rtl.HWP_COPPER: "#dbe8f3",
+	rtl.HWP_FIBER:  "#f6e3c6",
+	rtl.HWP_COMBO:  "#dcefd5",
+}
+
+// SVG draws the faceplate as an SVG image titled title.
+func (pn *Panel) SVG(w io.Writer, title string) error {
+	var body strings.Builder
+	x, height := svgMargin, 0
+	top := svgMargin + 2*svgLine
+	for _, b := range pn.blocks() {
+		for c := 0; c < b.cols(); c++ {
+			for row := 0; row < b.rows; row++ {
+				p := b.at(row, c)
+				if p == nil {
+					continue
+				}
+				cx, cy := x+c*svgCellWidth, top+row*svgCellHeight
+				fill, ok := svgFills[p.medi]
+				if !ok {
+					fill = "#eeeeee"
+				}
+				fmt.Fprintf(&body, "  <g id=\"port-%s\">\n", html.EscapeString(p.Label))
+				fmt.Fprintf(&body, "    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#333\"/>\n",
+					cx, cy, svgCellWidth, svgCellHeight, fill)
+				for l, text := range p.lines() {
+					weight := ""
+					if l == 0 {
+						weight = " font-weight=\"bold\""
+					}
+					fmt.Fprintf(&body, "    <text x=\"%d\" y=\"%d\" text-anchor=\"middle\"%s>%s</text>\n",
+						cx+svgCellWidth/2, cy+(l+1)*svgLine+2, weight, html.EscapeString(text))
+				}
+				body.WriteString("  </g>\n")
+			}
+		}
+		x += b.cols()*svgCellWidth + svgGap
+		height = max(height, b.rows*svgCellHeight)
+	}
+	width := max(x-svgGap+svgMargin, 2*svgMargin)
+	height += top + svgMargin
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"monospace\" font-size=\"11\">\n", width, height)
+	fmt.Fprintf(&out, "  <rect width=\"%d\" height=\"%d\" fill=\"#555\" rx=\"4\"/>\n", width, height)
+	fmt.Fprintf(&out, "  <text x=\"%d\" y=\"%d\" fill=\"#fff\" font-weight=\"bold\">%s</text>\n", svgMargin, svgMargin+svgLine, html.EscapeString(title))
+	out.WriteString(body.String())
+	out.WriteString("</svg>\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package panel maps the ports of a switch descriptor to their front panel
+// labels and draws the faceplate of the board.
+//
+// Labels are either derived from the order of the ports in the profile, or
+// given by a board file listing one "<label> <mac_id>" pair per line:
+//
+//	# XMG1915-10E
+//	1   0
+//	2   1
+//	SFP1 24
+//
+// Blank lines and lines starting with # are ignored, the faceplate follows
+// the order of the file.
+package panel
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Port is a front panel port.
+type Port struct {
+	Label  string `json:"label"`
+	MacId  uint8  `json:"mac_id"`
+	Speed  string `json:"speed"`
+	Medium string `json:"medium"`
+	// LedLayout is only set for combo ports.
+	LedLayout string      `json:"led_layout,omitempty"`
+	CopperLed *rtl.LedSel `json:"copper_led,omitempty"`
+	FiberLed  *rtl.LedSel `json:"fiber_led,omitempty"`
+
+	eth    rtl.EthType
+	medi   rtl.Medium
+	layout rtl.LedLayout
+}
+
+// Panel is the list of the front panel ports, in faceplate order.
+type Panel struct {
+	Ports []Port
+}
+
+// Speed classes of the ethernet types.
+var speeds = map[rtl.EthType]string{
+	rtl.HWP_FE:    "100M",
+	rtl.HWP_GE:    "1G",
+	rtl.HWP_2_5GE: "2.5G",
+	rtl.HWP_5GE:   "5G",
+	rtl.HWP_XGE:   "10G",
+}
+
+func newPort(label string, p *rtl.Port) Port {
+	fp := Port{Label: label, MacId: p.MacId, Speed: speeds[p.Eth], eth: p.Eth, medi: p.Medi, layout: p.LedLayout}
+	if fp.Speed == "" {
+		fp.Speed = "?"
+	}
+	switch p.Medi {
+	case rtl.HWP_COPPER:
+		fp.Medium = "copper"
+	case rtl.HWP_FIBER:
+		fp.Medium = "fiber"
+	case rtl.HWP_COMBO:
+		fp.Medium = "combo"
+		fp.LedLayout = p.LedLayout.String()
+	default:
+		fp.Medium = "unknown"
+	}
+	if p.LedC != rtl.HWP_NONE {
+		led := p.LedC
+		fp.CopperLed = &led
+	}
+	if p.LedF != rtl.HWP_NONE {
+		led := p.LedF
+		fp.FiberLed = &led
+	}
+	return fp
+}
+
+// SeparateLeds returns true for the combo ports having their own LEDs for
+// each medium (DOUBLE_SET).
+func (p *Port) SeparateLeds() bool {
+	return p.medi == rtl.HWP_COMBO && p.layout == rtl.DOUBLE_SET
+}
+
+// isFront returns true for the ports reaching the front panel: CPU and
+// cascade ports are internal.
+func isFront(p *rtl.Port) bool {
+	return p.Attr&(rtl.HWP_CPU|rtl.HWP_CASCADE) == 0
+}
+
+// Derive labels the front panel ports from 1 in the order of the profile.
+func Derive(sw *rtl.Switch) *Panel {
+	pn := &Panel{}
+	for _, p := range sw.Ports {
+		if isFront(p) {
+			pn.Ports = append(pn.Ports, newPort(strconv.Itoa(len(pn.Ports)+1), p))
+		}
+	}
+	return pn
+}
+
+// ParseBoard reads the labels of the ports of sw from a board file.
+func ParseBoard(r io.Reader, sw *rtl.Switch) (*Panel, error) {
+	ports := make(map[uint8]*rtl.Port)
+	for _, p := range sw.Ports {
+		ports[p.MacId] = p
+	}
+	pn := &Panel{}
+	labels := make(map[string]bool)
+	macs := make(map[uint8]bool)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<label> <mac_id>\"", n)
+		}
+		label := fields[0]
+		mac, err := strconv.ParseUint(fields[1], 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid MAC ID %q", n, fields[1])
+		}
+		p, ok := ports[uint8(mac)]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("line %d: no port with MAC ID %d", n, mac)
+		case !isFront(p):
+			return nil, fmt.Errorf("line %d: port %d is not a front panel port", n, mac)
+		case labels[label]:
+			return nil, fmt.Errorf("line %d: label %q already used", n, label)
+		case macs[p.MacId]:
+			return nil, fmt.Errorf("line %d: port %d already labelled", n, mac)
+		}
+		labels[label], macs[p.MacId] = true, true
+		pn.Ports = append(pn.Ports, newPort(label, p))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return pn, nil
+}
+
+// WriteBoard writes the mapping in the board file format.
+func (pn *Panel) WriteBoard(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# label mac_id\n")
+	for _, p := range pn.Ports {
+		fmt.Fprintf(&b, "%s %d\n", p.Label, p.MacId)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ByMac returns the front panel port of the MAC mac.
+func (pn *Panel) ByMac(mac uint8) (*Port, bool) {
+	for i := range pn.Ports {
+		if pn.Ports[i].MacId == mac {
+			return &pn.Ports[i], true
+		}
+	}
+	return nil, false
+}
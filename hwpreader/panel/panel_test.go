@@ -0,0 +1,173 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package panel
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// comboBoard returns the XMG1915-10E board of the corpus, its SFP+ cages
+// turned into combo ports, one for each LED layout.
+func comboBoard(t *testing.T) *rtl.Switch {
+	sw := corpus.Lookup(t, "xmg1915-10e").Switch
+	for _, p := range sw.Ports[8:10] {
+		p.Medi, p.LedC, p.LedF = rtl.HWP_COMBO, 1, 2
+	}
+	sw.Ports[8].LedLayout = rtl.DOUBLE_SET
+	return sw
+}
+
+func TestDerive(t *testing.T) {
+	pn := Derive(comboBoard(t))
+	if len(pn.Ports) != 10 {
+		t.Fatalf("expected 10 front ports, got %d", len(pn.Ports))
+	}
+	p, ok := pn.ByMac(24)
+	if !ok || p.Label != "9" || p.Speed != "10G" || p.Medium != "combo" || p.LedLayout != "DOUBLE_SET" {
+		t.Errorf("unexpected port 24: %+v", p)
+	}
+	if p.CopperLed == nil || *p.CopperLed != 1 || p.FiberLed == nil || *p.FiberLed != 2 {
+		t.Errorf("unexpected LED sets of port 24: %+v", p)
+	}
+	if p, _ := pn.ByMac(0); p.FiberLed != nil || p.LedLayout != "" {
+		t.Errorf("unexpected port 0: %+v", p)
+	}
+	if _, ok := pn.ByMac(28); ok {
+		t.Errorf("CPU port on the front panel")
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	board := `
+# test board
+SFP1 24
+  1 0
+2 0x1
+`
+	pn, err := ParseBoard(strings.NewReader(board), comboBoard(t))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(pn.Ports) != 3 || pn.Ports[0].Label != "SFP1" || pn.Ports[0].MacId != 24 || pn.Ports[2].MacId != 1 {
+		t.Errorf("unexpected ports: %+v", pn.Ports)
+	}
+	var b strings.Builder
+	if err := pn.WriteBoard(&b); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if want := "# label mac_id\nSFP1 24\n1 0\n2 1\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestParseBoardErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		want  string
+	}{
+		{"Syntax", "1 0 copper", "line 1: expected"},
+		{"InvalidMac", "1 x", "invalid MAC ID"},
+		{"UnknownMac", "\n1 12", "line 2: no port with MAC ID 12"},
+		{"CpuPort", "cpu 28", "not a front panel port"},
+		{"DuplicateLabel", "1 0\n1 1", "label \"1\" already used"},
+		{"DuplicateMac", "1 0\n2 0", "port 0 already labelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBoard(strings.NewReader(tt.board), comboBoard(t))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestASCII(t *testing.T) {
+	var b strings.Builder
+	if err := Derive(comboBoard(t)).ASCII(&b); err != nil {
+		t.Fatalf("draw failed: %v", err)
+	}
+	want := `+-------+-------+-------+-------+  +-------+-------+
+|   1   |   3   |   5   |   7   |  |   9   |  10   |
+| 2.5G  | 2.5G  | 2.5G  | 2.5G  |  |  10G  |  10G  |
+| RJ45  | RJ45  | RJ45  | RJ45  |  | COMBO | COMBO |
+|  c0   |  c0   |  c0   |  c0   |  | c1|f2 | c1=f2 |
++-------+-------+-------+-------+  +-------+-------+
+|   2   |   4   |   6   |   8   |
+| 2.5G  | 2.5G  | 2.5G  | 2.5G  |
+| RJ45  | RJ45  | RJ45  | RJ45  |
+|  c0   |  c0   |  c0   |  c0   |
++-------+-------+-------+-------+
+LEDs: cN copper set, fN fiber set, = shared (SINGLE_SET), | separate (DOUBLE_SET)
+`
+	if b.String() != want {
+		t.Errorf("unexpected faceplate:\n%s", b.String())
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var b strings.Builder
+	if err := Derive(comboBoard(t)).SVG(&b, "XMG1915 <10E>"); err != nil {
+		t.Fatalf("draw failed: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="376" height="178"`,
+		"XMG1915 &lt;10E&gt;",
+		`<g id="port-2">`,
+		`<rect x="12" y="102" width="56" height="64" fill="#dbe8f3" stroke="#333"/>`,
+		">c1|f2</text>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "<g id=") != 10 {
+		t.Errorf("expected 10 ports:\n%s", out)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			pn := Derive(b.Switch)
+			for _, f := range []struct {
+				format string
+				write  func(w *strings.Builder) error
+			}{
+				{"panel.txt", func(w *strings.Builder) error { return pn.ASCII(w) }},
+				{"panel.svg", func(w *strings.Builder) error { return pn.SVG(w, b.Name) }},
+				{"board", func(w *strings.Builder) error { return pn.WriteBoard(w) }},
+				{"panel.json", func(w *strings.Builder) error {
+					enc := json.NewEncoder(w)
+					enc.SetIndent("", "  ")
+					return enc.Encode(pn.Ports)
+				}},
+			} {
+				var out strings.Builder
+				if err := f.write(&out); err != nil {
+					t.Fatal(err)
+				}
+				corpus.Golden(t, b, f.format, []byte(out.String()))
+			}
+
+			// The board file of the derived mapping gives it back.
+			var board strings.Builder
+			pn.WriteBoard(&board)
+			parsed, err := ParseBoard(strings.NewReader(board.String()), b.Switch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, pn) {
+				t.Errorf("board file round trip: got %v, want %v", parsed, pn)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Mbps returns the link speed of the ethernet type, 0 for unknown types.
+func (e EthType) Mbps() int {
+	switch e {
+	case HWP_FE:
+		return 100
+	case HWP_GE:
+		return 1000
+	case HWP_2_5GE:
+		return 2500
+	case HWP_5GE:
+		return 5000
+	case HWP_XGE:
+		return 10000
+	default:
+		return 0
+	}
+}
+
+// speed formats a speed in Mbps, e.g. 2.5G.
+func speed(mbps int) string {
+	if mbps < 1000 {
+		return fmt.Sprintf("%dM", mbps)
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(mbps)/1000), ".0") + "G"
+}
+
+// SerdesCapacity is what a serdes mode carries: up to Ports ports linking at
+// most at Speed each.
+type SerdesCapacity struct {
+	Ports int
+	Speed EthType
+}
+
+// Mbps returns the bandwidth of the serdes.
+func (c SerdesCapacity) Mbps() int {
+	return c.Ports * c.Speed.Mbps()
+}
+
+func (c SerdesCapacity) String() string {
+	return fmt.Sprintf("%dx%s", c.Ports, speed(c.Speed.Mbps()))
+}
+
+// serdesCapacities gives the port multiplexing of the serdes modes. The
+// disabled modes carry no port, auto modes are counted at their fastest
+// speed and modes without entry are unknown.
+var serdesCapacities = map[SerdesMode]SerdesCapacity{
+	RTK_MII_NONE:               {0, HWP_FE},
+	RTK_MII_DISABLE:            {0, HWP_FE},
+	RTK_MII_10GR:               {1, HWP_XGE},
+	RTK_MII_RXAUI:              {1, HWP_XGE},
+	RTK_MII_RXAUI_LITE:         {1, HWP_XGE},
+	RTK_MII_RXAUISGMII_AUTO:    {1, HWP_XGE},
+	RTK_MII_RXAUI1000BX_AUTO:   {1, HWP_XGE},
+	RTK_MII_RSGMII_PLUS:        {2, HWP_GE},
+	RTK_MII_SGMII:              {1, HWP_GE},
+	RTK_MII_QSGMII:             {4, HWP_GE},
+	RTK_MII_1000BX_FIBER:       {1, HWP_GE},
+	RTK_MII_100BX_FIBER:        {1, HWP_FE},
+	RTK_MII_1000BX100BX_AUTO:   {1, HWP_GE},
+	RTK_MII_10GR1000BX_AUTO:    {1, HWP_XGE},
+	RTK_MII_10GRSGMII_AUTO:     {1, HWP_XGE},
+	RTK_MII_XAUI:               {1, HWP_XGE},
+	RTK_MII_RMII:               {1, HWP_FE},
+	RTK_MII_SMII:               {1, HWP_FE},
+	RTK_MII_SSSMII:             {1, HWP_FE},
+	RTK_MII_RSGMII:             {2, HWP_GE},
+	RTK_MII_XSMII:              {8, HWP_FE},
+	RTK_MII_XSGMII:             {8, HWP_GE},
+	RTK_MII_QHSGMII:            {4, HWP_2_5GE},
+	RTK_MII_HISGMII:            {1, HWP_2_5GE},
+	RTK_MII_HISGMII_5G:         {1, HWP_5GE},
+	RTK_MII_DUAL_HISGMII:       {2, HWP_2_5GE},
+	RTK_MII_2500Base_X:         {1, HWP_2_5GE},
+	RTK_MII_RXAUI_PLUS:         {1, HWP_XGE},
+	RTK_MII_USXGMII_10GSXGMII:  {1, HWP_XGE},
+	RTK_MII_USXGMII_10GDXGMII:  {2, HWP_5GE},
+	RTK_MII_USXGMII_10GQXGMII:  {4, HWP_2_5GE},
+	RTK_MII_USXGMII_5GSXGMII:   {1, HWP_5GE},
+	RTK_MII_USXGMII_5GDXGMII:   {2, HWP_2_5GE},
+	RTK_MII_USXGMII_2_5GSXGMII: {1, HWP_2_5GE},
+	RTK_MII_USXGMII_1G:         {1, HWP_GE},
+	RTK_MII_USXGMII_100M:       {1, HWP_FE},
+	RTK_MII_5GBASEX:            {1, HWP_5GE},
+	RTK_MII_5GR:                {1, HWP_5GE},
+	RTK_MII_XFI_5G_ADAPT:       {1, HWP_5GE},
+	RTK_MII_XFI_5G_CPRI:        {1, HWP_5GE},
+	RTK_MII_XFI_2P5G_ADAPT:     {1, HWP_2_5GE},
+	RTK_MII_QUSGMII:            {4, HWP_GE},
+	RTK_MII_OUSGMII:            {8, HWP_GE},
+}
+
+// Capacity returns the capacity of the serdes mode, ok being false for the
+// modes of unknown capacity.
+func (m SerdesMode) Capacity() (c SerdesCapacity, ok bool) {
+	c, ok = serdesCapacities[m]
+	return c, ok
+}
+
+// SerdesBudget is the use of the bandwidth of a serdes.
+type SerdesBudget struct {
+	Serdes *Serdes
+	// Capacity is only meaningful if Known is set.
+	Capacity SerdesCapacity
+	Known    bool
+	// Ports are the indexes of the ports fed by the serdes.
+	Ports []int
+	// Demand is the bandwidth the ports take, in Mbps: the link speed of
+	// the ports behind a PHY or a converter, the bandwidth of the serdes for
+	// the ports linked to it directly.
+	Demand int
+}
+
+// PortBudget is the maximum throughput of a port.
+type PortBudget struct {
+	Port *Port
+	// Serdes are the indexes of the serdes feeding the port, none if
+	// unknown.
+	Serdes []int
+	// Mbps is the link speed of the port limited by its serdes.
+	Mbps int
+
+	index int
+}
+
+// Budget is the bandwidth analysis of a switch descriptor.
+type Budget struct {
+	Serdes []SerdesBudget
+	Ports  []PortBudget
+}
+
+// direct reports whether the port links its medium to its serdes without PHY
+// nor converter, e.g. an SFP cage: the serdes carries the port alone, at
+// its own speed.
+func (p *Port) direct() bool {
+	return (p.Medi == HWP_FIBER || p.Medi == HWP_SERDES) && p.PhyIdx == HWP_NONE && p.Attr&HWP_SC == 0
+}
+
+// Budget computes the bandwidth of the serdes and the maximum throughput of
+// the ports they feed, the ports behind a PHY counting against the serdes
+// feeding the PHY. CPU and cascade ports are left out: they do not link to
+// the front panel.
+func (sw *Switch) Budget() *Budget {
+	b := &Budget{}
+	for _, sd := range sw.Serdes {
+		c, ok := sd.Mode.Capacity()
+		b.Serdes = append(b.Serdes, SerdesBudget{Serdes: sd, Capacity: c, Known: ok})
+	}
+	for i, p := range sw.Ports {
+		if p.Attr&(HWP_CPU|HWP_CASCADE) != 0 {
+			continue
+		}
+		pb := PortBudget{Port: p, Mbps: p.Eth.Mbps(), index: i}
+		pb.Serdes, _ = sw.PortLanes(p)
+		// The traffic of a port fed by several serdes is spread over
+		// them, a disabled serdes carrying nothing.
+		lanes, known := 0, true
+		for _, k := range pb.Serdes {
+			sb := &b.Serdes[k]
+			sb.Ports = append(sb.Ports, i)
+			demand := pb.Mbps / len(pb.Serdes)
+			if sb.Known && p.direct() {
+				demand = max(demand, sb.Capacity.Mbps())
+			}
+			sb.Demand += demand
+			if !sb.Known {
+				known = false
+			} else if sb.Capacity.Ports > 0 {
+				lanes += sb.Capacity.Speed.Mbps()
+			}
+		}
+		if len(pb.Serdes) > 0 && known {
+			pb.Mbps = min(pb.Mbps, lanes)
+		}
+		b.Ports = append(b.Ports, pb)
+	}
+	return b
+}
+
+// WriteTable writes the budget as tables of the serdes and of the ports.
+func (b *Budget) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERDES\tID\tMODE\tCAPACITY\tBANDWIDTH\tPORTS\tDEMAND\tUSE")
+	for i, sb := range b.Serdes {
+		mode := strings.TrimPrefix(sb.Serdes.Mode.String(), "RTK_MII_")
+		capacity, bandwidth, use := "?", "?", "?"
+		if sb.Known {
+			capacity, bandwidth = sb.Capacity.String(), speed(sb.Capacity.Mbps())
+			if sb.Capacity.Mbps() > 0 {
+				use = fmt.Sprintf("%d%%", 100*sb.Demand/sb.Capacity.Mbps())
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			i, sb.Serdes.Id, mode, capacity, bandwidth, len(sb.Ports), speed(sb.Demand), use)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "PORT\tETH\tMEDIUM\tSERDES\tMAX")
+	total := 0
+	for _, pb := range b.Ports {
+		serdes := "-"
+		if len(pb.Serdes) > 0 {
+			serdes = indexes(pb.Serdes)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+			pb.Port.MacId, speed(pb.Port.Eth.Mbps()), strings.TrimPrefix(pb.Port.Medi.String(), "HWP_"), serdes, speed(pb.Mbps))
+		total += pb.Mbps
+	}
+	fmt.Fprintf(tw, "total\t\t\t\t%s\n", speed(total))
+	return tw.Flush()
+}
+
+// budget checks that the serdes can carry the ports they feed: the number of
+// ports must not exceed the multiplexing of the serdes mode, nor their link
+// speed the speed of the serdes per port, and a port linked to its serdes
+// without PHY must have it to itself.
+func (v *validator) budget() {
+	b := v.sw.Budget()
+	for i, sb := range b.Serdes {
+		if sb.Known && len(sb.Ports) > sb.Capacity.Ports {
+			v.report(ERROR, fmt.Sprintf("serdes[%d].mode", i), "%s carries %d ports, %d ports are attached",
+				sb.Serdes.Mode, sb.Capacity.Ports, len(sb.Ports))
+		}
+	}
+	for _, pb := range b.Ports {
+		if len(pb.Serdes) == 0 {
+			continue
+		}
+		for _, k := range pb.Serdes {
+			if sb := b.Serdes[k]; sb.Known && pb.Port.direct() && (sb.Capacity.Ports > 1 || len(sb.Ports) > 1) {
+				v.report(ERROR, fmt.Sprintf("ports[%d].medi", pb.index), "%s port without PHY takes serdes[%d] alone, %s multiplexes %d ports and %d are attached",
+					pb.Port.Medi, k, sb.Serdes.Mode, sb.Capacity.Ports, len(sb.Ports))
+			}
+		}
+		if pb.Mbps == 0 || pb.Mbps >= pb.Port.Eth.Mbps() {
+			continue
+		}
+		v.report(ERROR, fmt.Sprintf("ports[%d].eth", pb.index), "%s exceeds the %s per port of serdes[%s] in mode %s",
+			pb.Port.Eth, speed(pb.Mbps), indexes(pb.Serdes), b.Serdes[pb.Serdes[0]].Serdes.Mode)
+	}
+}
+
+// indexes formats table indexes, e.g. 0,1.
+func indexes(idx []int) string {
+	s := make([]string, len(idx))
+	for i, k := range idx {
+		s[i] = strconv.Itoa(k)
+	}
+	return strings.Join(s, ",")
+}
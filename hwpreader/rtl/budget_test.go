@@ -0,0 +1,147 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSerdesCapacity(t *testing.T) {
+	tests := []struct {
+		mode SerdesMode
+		want string
+		mbps int
+	}{
+		{RTK_MII_10GR, "1x10G", 10000},
+		{RTK_MII_QSGMII, "4x1G", 4000},
+		{RTK_MII_USXGMII_10GQXGMII, "4x2.5G", 10000},
+		{RTK_MII_HISGMII, "1x2.5G", 2500},
+		{RTK_MII_XSMII, "8x100M", 800},
+	}
+	for _, tt := range tests {
+		c, ok := tt.mode.Capacity()
+		if !ok || c.String() != tt.want || c.Mbps() != tt.mbps {
+			t.Errorf("%s: capacity %s (%d Mbps, %v), want %s (%d Mbps)", tt.mode, c, c.Mbps(), ok, tt.want, tt.mbps)
+		}
+	}
+	if c, ok := RTK_MII_DISABLE.Capacity(); !ok || c.Mbps() != 0 {
+		t.Errorf("disabled serdes carries %s", c)
+	}
+	if _, ok := RTK_MII_END.Capacity(); ok {
+		t.Errorf("RTK_MII_END has a capacity")
+	}
+}
+
+func TestBudget(t *testing.T) {
+	sw := validSwitch()
+	sw.Ports[2].Eth = HWP_XGE
+	sw.Serdes[1].Mode = RTK_MII_HISGMII
+	b := sw.Budget()
+
+	if len(b.Ports) != 3 {
+		t.Fatalf("budget of %d ports, want 3 without the CPU port", len(b.Ports))
+	}
+	for i, want := range []int{2500, 2500, 2500} {
+		if b.Ports[i].Mbps != want {
+			t.Errorf("port %d: %d Mbps, want %d", i, b.Ports[i].Mbps, want)
+		}
+	}
+	if sb := b.Serdes[0]; len(sb.Ports) != 2 || sb.Demand != 5000 {
+		t.Errorf("serdes 0: %d ports, demand %d Mbps", len(sb.Ports), sb.Demand)
+	}
+
+	// A 1G SFP takes the whole bandwidth of its serdes.
+	sw = validSwitch()
+	sw.Ports[2].Eth = HWP_GE
+	if sb := sw.Budget().Serdes[1]; sb.Demand != 10000 {
+		t.Errorf("serdes 1: demand %d Mbps of a 1G fiber port, want 10000", sb.Demand)
+	}
+	sw.Ports[2].PhyIdx = 0
+	if sb := sw.Budget().Serdes[1]; sb.Demand != 1000 {
+		t.Errorf("serdes 1: demand %d Mbps of a 1G port behind a PHY, want 1000", sb.Demand)
+	}
+
+	// PHY ports without serdes count against the serdes feeding their PHY.
+	sw = validSwitch()
+	sw.Ports[0].SdsIdx, sw.Ports[1].SdsIdx = HWP_NONE, HWP_NONE
+	sw.Phys[0].PhyMax = 8
+	sw.Serdes = append(sw.Serdes, &Serdes{Id: 3, Mode: RTK_MII_USXGMII_10GQXGMII})
+	sw.Ports[1].MacId = 4
+	if got := fmt.Sprint(sw.PhySerdes()); got != "[[0 2]]" {
+		t.Errorf("PHY serdes %s, want [[0 2]]", got)
+	}
+	pb := sw.Budget()
+	if s0, s2 := pb.Serdes[0], pb.Serdes[2]; len(s0.Ports) != 1 || s0.Demand != 2500 || len(s2.Ports) != 1 || s2.Demand != 2500 {
+		t.Errorf("PHY serdes: %d ports, demand %d Mbps and %d ports, demand %d Mbps, want one 2.5G port each",
+			len(s0.Ports), s0.Demand, len(s2.Ports), s2.Demand)
+	}
+
+	var out strings.Builder
+	if err := b.WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"0       2   USXGMII_10GQXGMII  4x2.5G    10G        2      5G      50%",
+		"1       6   HISGMII            1x2.5G    2.5G       1      10G     400%",
+		"24     10G   FIBER   1       2.5G",
+		"total                        7.5G",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestValidateBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sw *Switch)
+		want   Finding
+	}{
+		{
+			name: "TooManyPorts",
+			modify: func(sw *Switch) {
+				sw.Serdes[0].Mode = RTK_MII_SGMII
+				sw.Ports[0].Eth, sw.Ports[1].Eth = HWP_GE, HWP_GE
+			},
+			want: Finding{ERROR, "serdes[0].mode", "RTK_MII_SGMII carries 1 ports, 2 ports are attached"},
+		},
+		{
+			name: "FiberOnSharedSerdes",
+			modify: func(sw *Switch) {
+				sw.Ports[1].PhyIdx, sw.Ports[1].Medi = HWP_NONE, HWP_FIBER
+			},
+			want: Finding{ERROR, "ports[1].medi", "HWP_FIBER port without PHY takes serdes[0] alone, RTK_MII_USXGMII_10GQXGMII multiplexes 4 ports and 2 are attached"},
+		},
+		{
+			name: "PhyLinkOversubscribed",
+			modify: func(sw *Switch) {
+				sw.Ports[0].SdsIdx, sw.Ports[1].SdsIdx = HWP_NONE, HWP_NONE
+				sw.Serdes[0].Mode = RTK_MII_SGMII
+			},
+			want: Finding{ERROR, "serdes[0].mode", "RTK_MII_SGMII carries 1 ports, 2 ports are attached"},
+		},
+		{
+			name:   "PortFasterThanSerdes",
+			modify: func(sw *Switch) { sw.Serdes[1].Mode = RTK_MII_USXGMII_10GQXGMII },
+			want:   Finding{ERROR, "ports[2].eth", "HWP_XGE exceeds the 2.5G per port of serdes[1] in mode RTK_MII_USXGMII_10GQXGMII"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := validSwitch()
+			tt.modify(sw)
+			found := false
+			for _, f := range sw.Validate() {
+				if f == tt.want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("missing finding %v in %v", tt.want, sw.Validate())
+			}
+		})
+	}
+}
@@ -56,90 +56,9 @@ const (
 )
 
 func (cid RtlChipId) String() string {
-	var name string
-	switch cid {
-	case RTL8351M_CHIP_ID:
-		name = "RTL8351M"
-	case RTL8352M_CHIP_ID:
-		name = "RTL8352M"
-	case RTL8353M_CHIP_ID:
-		name = "RTL8353M"
-	case RTL8390M_CHIP_ID:
-		name = "RTL8390M"
-	case RTL8391M_CHIP_ID:
-		name = "RTL8391M"
-	case RTL8392M_CHIP_ID:
-		name = "RTL8392M"
-	case RTL8393M_CHIP_ID:
-		name = "RTL8393M"
-	case RTL8396M_CHIP_ID:
-		name = "RTL8396M"
-	case RTL8352MES_CHIP_ID:
-		name = "RTL8352MES"
-	case RTL8353MES_CHIP_ID:
-		name = "RTL8353MES"
-	case RTL8392MES_CHIP_ID:
-		name = "RTL8392MES"
-	case RTL8393MES_CHIP_ID:
-		name = "RTL8393MES"
-	case RTL8396MES_CHIP_ID:
-		name = "RTL8396MES"
-	case RTL8330M_CHIP_ID:
-		name = "RTL8330M"
-	case RTL8332M_CHIP_ID:
-		name = "RTL8332M"
-	case RTL8380M_CHIP_ID:
-		name = ""
-	case RTL8382M_CHIP_ID:
-		name = "RTL8382M"
-	case RTL8381M_CHIP_ID:
-		name = "RTL8381M"
-	case RTL9301_CHIP_ID:
-		name = "RTL9301"
-	case RTL9301_CHIP_ID_24G:
-		name = "RTL9301_24G"
-	case RTL9301H_CHIP_ID:
-		name = "RTL9301H"
-	case RTL9301H_CHIP_ID_4X2_5G:
-		name = "RTL9301H_4X2_5G"
-	case RTL9302A_CHIP_ID:
-		name = "RTL9302A"
-	case RTL9302A_CHIP_ID_12X2_5G:
-		name = "RTL9302A_12X2_5G"
-	case RTL9302B_CHIP_ID:
-		name = "RTL9302B"
-	case RTL9302B_CHIP_ID_8X2_5G:
-		name = "RTL9302B_8X2_5G"
-	case RTL9302C_CHIP_ID:
-		name = "RTL9302C"
-	case RTL9302C_CHIP_ID_16X2_5G:
-		name = "RTL9302C_16X2_5G"
-	case RTL9302D_CHIP_ID:
-		name = "RTL9302D"
-	case RTL9302D_CHIP_ID_24X2_5G:
-		name = "RTL9302D_24X2_5G"
-	case RTL9302DE_CHIP_ID:
-		name = "RTL9302DE"
-	case RTL9302F_CHIP_ID:
-		name = "RTL9302F"
-	case RTL9303_CHIP_ID:
-		name = "RTL9303"
-	case RTL9303_CHIP_ID_8XG:
-		name = "RTL9303_8XG"
-	case RTL9310_CHIP_ID:
-		name = "RTL9310"
-	case RTL9311_CHIP_ID:
-		name = "RTL9311"
-	case RTL9311E_CHIP_ID:
-		name = "RTL9311E"
-	case RTL9311R_CHIP_ID:
-		name = "RTL9311R"
-	case RTL9312_CHIP_ID:
-		name = "RTL9312"
-	case RTL9313_CHIP_ID:
-		name = "RTL9313"
-	default:
-		name = "UNKNOWN"
+	name := "UNKNOWN"
+	if info, ok := chips[cid]; ok {
+		name = info.Name
 	}
 	return fmt.Sprintf("%s (0x%x)", name, uint32(cid))
 }
@@ -0,0 +1,218 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Family of switch chips.
+type Family uint32
+
+const (
+	RTL838X Family = 0x8380
+	RTL839X Family = 0x8390
+	RTL930X Family = 0x9300
+	RTL931X Family = 0x9310
+)
+
+func (f Family) String() string {
+	switch f {
+	case RTL838X:
+		return "RTL838x"
+	case RTL839X:
+		return "RTL839x"
+	case RTL930X:
+		return "RTL930x"
+	case RTL931X:
+		return "RTL931x"
+	default:
+		return fmt.Sprintf("UNKNOWN (0x%x)", uint32(f))
+	}
+}
+
+// ChipInfo describes the hardware limits of a chip.
+type ChipInfo struct {
+	Id     RtlChipId
+	Name   string
+	Family Family
+	// MaxPorts is the number of MAC ports of the family, CPU port excluded:
+	// MAC IDs of front ports are below.
+	MaxPorts int
+	// MaxSerdes is the number of serdes of the family: serdes IDs are below.
+	MaxSerdes int
+	CpuPort   uint8
+	// Ports is the number of ports of the chip, CPU port excluded, 0 if
+	// unknown.
+	Ports int
+	// Serdes is the number of serdes the chip brings out, 0 if unknown.
+	Serdes int
+	// MultiGig is the number of 2.5G ports of the chips whose name gives it,
+	// e.g. 8 for RTL9302B_8X2_5G, 0 if unknown.
+	MultiGig int
+	// Order is the byte order of the embedded CPU. Descriptors are laid out
+	// in the byte order of the CPU running the SDK, which is not the
+	// embedded one when the switch core is accessed over PCIe or SPI.
+	Order binary.ByteOrder
+	// Modes are the serdes modes supported by the chip.
+	Modes []SerdesMode
+}
+
+// Supports returns true if the chip supports the serdes mode m.
+func (ci *ChipInfo) Supports(m SerdesMode) bool {
+	for _, mode := range ci.Modes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Limits shared by the chips of a family.
+var families = map[Family]ChipInfo{
+	RTL838X: {Family: RTL838X, MaxPorts: 28, MaxSerdes: 6, CpuPort: 28, Order: binary.BigEndian},
+	RTL839X: {Family: RTL839X, MaxPorts: 52, MaxSerdes: 14, CpuPort: 52, Order: binary.BigEndian},
+	RTL930X: {Family: RTL930X, MaxPorts: 28, MaxSerdes: 12, CpuPort: 28, Order: binary.BigEndian},
+	RTL931X: {Family: RTL931X, MaxPorts: 56, MaxSerdes: 14, CpuPort: 56, Order: binary.BigEndian},
+}
+
+// Serdes modes supported by each chip family.
+var familyModes = map[Family][]SerdesMode{
+
+	RTL838X: {
+		RTK_MII_NONE, RTK_MII_DISABLE, RTK_MII_SGMII, RTK_MII_QSGMII, RTK_MII_RSGMII,
+		RTK_MII_RSGMII_PLUS, RTK_MII_1000BX_FIBER, RTK_MII_100BX_FIBER, RTK_MII_1000BX100BX_AUTO,
+	},
+	RTL839X: {
+		RTK_MII_NONE, RTK_MII_DISABLE, RTK_MII_SGMII, RTK_MII_QSGMII, RTK_MII_RSGMII,
+		RTK_MII_RSGMII_PLUS, RTK_MII_1000BX_FIBER, RTK_MII_100BX_FIBER, RTK_MII_1000BX100BX_AUTO,
+		RTK_MII_10GR, RTK_MII_RXAUI, RTK_MII_RXAUI_LITE, RTK_MII_RXAUI_PLUS, RTK_MII_RXAUISGMII_AUTO,
+		RTK_MII_RXAUI1000BX_AUTO, RTK_MII_10GR1000BX_AUTO, RTK_MII_10GRSGMII_AUTO, RTK_MII_XAUI,
+	},
+	RTL930X: {
+		RTK_MII_NONE, RTK_MII_DISABLE, RTK_MII_SGMII, RTK_MII_QSGMII, RTK_MII_XSGMII,
+		RTK_MII_1000BX_FIBER, RTK_MII_100BX_FIBER, RTK_MII_1000BX100BX_AUTO, RTK_MII_10GR,
+		RTK_MII_10GR1000BX_AUTO, RTK_MII_10GRSGMII_AUTO, RTK_MII_HISGMII, RTK_MII_2500Base_X,
+		RTK_MII_USXGMII_10GSXGMII, RTK_MII_USXGMII_10GDXGMII, RTK_MII_USXGMII_10GQXGMII,
+		RTK_MII_USXGMII_5GSXGMII, RTK_MII_USXGMII_5GDXGMII, RTK_MII_USXGMII_2_5GSXGMII,
+		RTK_MII_USXGMII_1G, RTK_MII_USXGMII_100M, RTK_MII_USXGMII_10M, RTK_MII_5GBASEX,
+		RTK_MII_XSMII,
+	},
+	RTL931X: {
+		RTK_MII_NONE, RTK_MII_DISABLE, RTK_MII_SGMII, RTK_MII_QSGMII, RTK_MII_XSGMII,
+		RTK_MII_QHSGMII, RTK_MII_XSMII, RTK_MII_1000BX_FIBER, RTK_MII_100BX_FIBER,
+		RTK_MII_1000BX100BX_AUTO, RTK_MII_10GR, RTK_MII_10GR1000BX_AUTO, RTK_MII_10GRSGMII_AUTO,
+		RTK_MII_HISGMII, RTK_MII_HISGMII_5G, RTK_MII_DUAL_HISGMII, RTK_MII_2500Base_X,
+		RTK_MII_USXGMII_10GSXGMII, RTK_MII_USXGMII_10GDXGMII, RTK_MII_USXGMII_10GQXGMII,
+		RTK_MII_USXGMII_5GSXGMII, RTK_MII_USXGMII_5GDXGMII, RTK_MII_USXGMII_2_5GSXGMII,
+		RTK_MII_USXGMII_1G, RTK_MII_USXGMII_100M, RTK_MII_USXGMII_10M, RTK_MII_5GBASEX,
+		RTK_MII_5GR, RTK_MII_XFI_5G_ADAPT, RTK_MII_XFI_5G_CPRI, RTK_MII_XFI_2P5G_ADAPT,
+		RTK_MII_QUSGMII, RTK_MII_OUSGMII,
+	},
+}
+
+// chips is the database of the known chips.
+var chips = map[RtlChipId]*ChipInfo{}
+
+// chipCounts are the numbers of ports and serdes of the chips documenting
+// them, and the number of 2.5G ports of the chips named after it. The counts
+// left out are unknown.
+var chipCounts = map[RtlChipId]struct{ ports, serdes, multiGig int }{
+	RTL8380M_CHIP_ID:         {ports: 10},
+	RTL9301_CHIP_ID:          {ports: 28},
+	RTL9301H_CHIP_ID_4X2_5G:  {multiGig: 4},
+	RTL9302A_CHIP_ID_12X2_5G: {multiGig: 12},
+	RTL9302B_CHIP_ID_8X2_5G:  {multiGig: 8},
+	RTL9302C_CHIP_ID_16X2_5G: {multiGig: 16},
+	RTL9302D_CHIP_ID_24X2_5G: {multiGig: 24},
+	RTL9303_CHIP_ID:          {ports: 8, serdes: 8},
+	RTL9303_CHIP_ID_8XG:      {ports: 8, serdes: 8},
+}
+
+func init() {
+	for _, c := range []struct {
+		id     RtlChipId
+		name   string
+		family Family
+	}{
+		{RTL8351M_CHIP_ID, "RTL8351M", RTL839X},
+		{RTL8352M_CHIP_ID, "RTL8352M", RTL839X},
+		{RTL8353M_CHIP_ID, "RTL8353M", RTL839X},
+		{RTL8390M_CHIP_ID, "RTL8390M", RTL839X},
+		{RTL8391M_CHIP_ID, "RTL8391M", RTL839X},
+		{RTL8392M_CHIP_ID, "RTL8392M", RTL839X},
+		{RTL8393M_CHIP_ID, "RTL8393M", RTL839X},
+		{RTL8396M_CHIP_ID, "RTL8396M", RTL839X},
+		{RTL8352MES_CHIP_ID, "RTL8352MES", RTL839X},
+		{RTL8353MES_CHIP_ID, "RTL8353MES", RTL839X},
+		{RTL8392MES_CHIP_ID, "RTL8392MES", RTL839X},
+		{RTL8393MES_CHIP_ID, "RTL8393MES", RTL839X},
+		{RTL8396MES_CHIP_ID, "RTL8396MES", RTL839X},
+		{RTL8330M_CHIP_ID, "RTL8330M", RTL838X},
+		{RTL8332M_CHIP_ID, "RTL8332M", RTL838X},
+		{RTL8380M_CHIP_ID, "RTL8380M", RTL838X},
+		{RTL8382M_CHIP_ID, "RTL8382M", RTL838X},
+		{RTL8381M_CHIP_ID, "RTL8381M", RTL838X},
+		{RTL9301_CHIP_ID, "RTL9301", RTL930X},
+		{RTL9301_CHIP_ID_24G, "RTL9301_24G", RTL930X},
+		{RTL9301H_CHIP_ID, "RTL9301H", RTL930X},
+		{RTL9301H_CHIP_ID_4X2_5G, "RTL9301H_4X2_5G", RTL930X},
+		{RTL9302A_CHIP_ID, "RTL9302A", RTL930X},
+		{RTL9302A_CHIP_ID_12X2_5G, "RTL9302A_12X2_5G", RTL930X},
+		{RTL9302B_CHIP_ID, "RTL9302B", RTL930X},
+		{RTL9302B_CHIP_ID_8X2_5G, "RTL9302B_8X2_5G", RTL930X},
+		{RTL9302C_CHIP_ID, "RTL9302C", RTL930X},
+		{RTL9302C_CHIP_ID_16X2_5G, "RTL9302C_16X2_5G", RTL930X},
+		{RTL9302D_CHIP_ID, "RTL9302D", RTL930X},
+		{RTL9302D_CHIP_ID_24X2_5G, "RTL9302D_24X2_5G", RTL930X},
+		{RTL9302DE_CHIP_ID, "RTL9302DE", RTL930X},
+		{RTL9302F_CHIP_ID, "RTL9302F", RTL930X},
+		{RTL9303_CHIP_ID, "RTL9303", RTL930X},
+		{RTL9303_CHIP_ID_8XG, "RTL9303_8XG", RTL930X},
+		{RTL9310_CHIP_ID, "RTL9310", RTL931X},
+		{RTL9311_CHIP_ID, "RTL9311", RTL931X},
+		{RTL9311E_CHIP_ID, "RTL9311E", RTL931X},
+		{RTL9311R_CHIP_ID, "RTL9311R", RTL931X},
+		{RTL9312_CHIP_ID, "RTL9312", RTL931X},
+		{RTL9313_CHIP_ID, "RTL9313", RTL931X},
+	} {
+		info := families[c.family]
+		info.Id, info.Name, info.Modes = c.id, c.name, familyModes[c.family]
+		n := chipCounts[c.id]
+		info.Ports, info.Serdes, info.MultiGig = n.ports, n.serdes, n.multiGig
+		chips[c.id] = &info
+	}
+}
+
+// Family returns the family of the chip, guessed from the model number for
+// unknown chips.
+func (cid RtlChipId) Family() Family {
+	if info, ok := chips[cid]; ok {
+		return info.Family
+	}
+	model := uint32(cid) >> 16
+	switch model {
+	case 0x8351, 0x8352, 0x8353:
+		// RTL835x are RTL839x derivatives.
+		return RTL839X
+	case 0x8330, 0x8332:
+		// RTL833x are RTL838x derivatives.
+		return RTL838X
+	}
+	return Family(model & 0xfff0)
+}
+
+// Info returns the capabilities of the chip. Unknown chips of a known family
+// get the limits of their family, with ok set to false.
+func (cid RtlChipId) Info() (info *ChipInfo, ok bool) {
+	if info, ok := chips[cid]; ok {
+		return info, true
+	}
+	family, known := families[cid.Family()]
+	if !known {
+		return nil, false
+	}
+	family.Id, family.Name, family.Modes = cid, "UNKNOWN", familyModes[family.Family]
+	return &family, false
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChipInfo(t *testing.T) {
+	tests := []struct {
+		id     RtlChipId
+		name   string
+		family Family
+		known  bool
+	}{
+		{RTL8380M_CHIP_ID, "RTL8380M", RTL838X, true},
+		{RTL8332M_CHIP_ID, "RTL8332M", RTL838X, true},
+		{RTL8352M_CHIP_ID, "RTL8352M", RTL839X, true},
+		{RTL9302B_CHIP_ID, "RTL9302B", RTL930X, true},
+		{RTL9313_CHIP_ID, "RTL9313", RTL931X, true},
+		{0x93027000, "UNKNOWN", RTL930X, false},
+	}
+	for _, tt := range tests {
+		info, ok := tt.id.Info()
+		if info == nil || ok != tt.known || info.Name != tt.name || info.Family != tt.family || info.Id != tt.id {
+			t.Errorf("0x%x: got %+v, %v", uint32(tt.id), info, ok)
+		}
+		if tt.known && tt.id.String() != fmt.Sprintf("%s (0x%x)", tt.name, uint32(tt.id)) {
+			t.Errorf("0x%x: unexpected name %s", uint32(tt.id), tt.id)
+		}
+	}
+	if info, ok := RtlChipId(0x12345678).Info(); info != nil || ok {
+		t.Errorf("unexpected info for an unknown family: %+v", info)
+	}
+
+	info, _ := RTL9302B_CHIP_ID.Info()
+	if !info.Supports(RTK_MII_USXGMII_10GQXGMII) || info.Supports(RTK_MII_RXAUI) {
+		t.Errorf("unexpected RTL930x serdes modes")
+	}
+	if info.CpuPort != 28 || info.MaxSerdes != 12 || info.Ports != 0 || info.Serdes != 0 || info.MultiGig != 0 {
+		t.Errorf("unexpected RTL930x limits: %+v", info)
+	}
+	for _, tt := range []struct {
+		id                      RtlChipId
+		ports, serdes, multiGig int
+	}{
+		{RTL8380M_CHIP_ID, 10, 0, 0},
+		{RTL9301_CHIP_ID, 28, 0, 0},
+		{RTL9302B_CHIP_ID_8X2_5G, 0, 0, 8},
+		{RTL9302D_CHIP_ID_24X2_5G, 0, 0, 24},
+		{RTL9303_CHIP_ID, 8, 8, 0},
+		{RTL9303_CHIP_ID_8XG, 8, 8, 0},
+	} {
+		if info, _ := tt.id.Info(); info.Ports != tt.ports || info.Serdes != tt.serdes || info.MultiGig != tt.multiGig {
+			t.Errorf("%s: got %d ports, %d serdes, %d 2.5G ports", tt.id, info.Ports, info.Serdes, info.MultiGig)
+		}
+	}
+}
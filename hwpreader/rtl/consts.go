@@ -11,4 +11,13 @@ const (
 	RTK_MAX_LED_PER_PORT  = 5
 	RTK_MAX_LED_MOD       = 4
 	RTK_MAX_SDS_PER_PHY   = 3
+
+	RTK_MAX_NUM_OF_UNIT_LOCAL = 4
+)
+
+// include/hwp/hw_profile.h
+const (
+	HWP_NONE     = 0xff // field not used by the entry
+	HWP_END      = 0xff // terminates a descriptor table
+	HWP_NOT_USED = 0xff // same as HWP_NONE, used for scalar fields
 )
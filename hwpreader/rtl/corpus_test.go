@@ -0,0 +1,34 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl_test
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+)
+
+func TestCorpus(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	for _, b := range corpus.Boards(t) {
+		t.Run(b.Name, func(t *testing.T) {
+			// The text format is printed to the log.
+			var out strings.Builder
+			log.SetOutput(&out)
+			_ = b.Switch.String()
+			corpus.Golden(t, b, "txt", []byte(out.String()))
+
+			out.Reset()
+			if err := b.Switch.Budget().WriteTable(&out); err != nil {
+				t.Fatal(err)
+			}
+			corpus.Golden(t, b, "budget", []byte(out.String()))
+
+			for _, f := range b.Switch.Validate() {
+				t.Errorf("unexpected finding: %s", f)
+			}
+		})
+	}
+}
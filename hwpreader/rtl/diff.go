@@ -0,0 +1,243 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of changes between two switch descriptors.
+const (
+	ADDED    = "added"
+	REMOVED  = "removed"
+	MODIFIED = "modified"
+	// DUPLICATE is an entry whose key is already taken by a previous entry
+	// of its descriptor, held in Old for the first descriptor and in New
+	// for the second one. It is not compared.
+	DUPLICATE = "duplicate"
+)
+
+// Change is a difference between two switch descriptors.
+type Change struct {
+	// Path designates the entry or the field, e.g. ports[mac_id=3].smi.
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ADDED:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.New)
+	case REMOVED:
+		return fmt.Sprintf("- %s: %s", c.Path, c.Old)
+	case DUPLICATE:
+		if c.Old != "" {
+			return fmt.Sprintf("! %s: duplicated in the first descriptor: %s", c.Path, c.Old)
+		}
+		return fmt.Sprintf("! %s: duplicated in the second descriptor: %s", c.Path, c.New)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// field is a named value of a descriptor entry, formatted for comparison.
+type field struct {
+	name  string
+	value string
+}
+
+func (p *Port) fields() []field {
+	return []field{
+		{"mac_id", fmt.Sprint(p.MacId)},
+		{"phy_idx", fmt.Sprint(p.PhyIdx)},
+		{"smi", fmt.Sprint(p.Smi)},
+		{"phy_addr", fmt.Sprint(p.PhyAddr)},
+		{"sds_idx", fmt.Sprint(p.SdsIdx)},
+		{"attr", p.Attr.String()},
+		{"eth", p.Eth.String()},
+		{"medi", p.Medi.String()},
+		{"sc_idx", fmt.Sprint(p.ScIdx)},
+		{"led_c", p.LedC.String()},
+		{"led_f", p.LedF.String()},
+		{"led_layout", p.LedLayout.String()},
+		{"phy_mdi_pin_swap", fmt.Sprint(p.PhyMdiPinSwap)},
+		{"phy_mdi_pair_swap", fmt.Sprint(p.PhyMdiPairSwap)},
+	}
+}
+
+// portFields returns the fields of a port of sw, its serdes and its PHY being
+// designated by the keys of the entries it indexes: reordering the serdes or
+// PHY table of a descriptor does not change its ports. Indexes out of the
+// tables are kept as is.
+func (sw *Switch) portFields(p *Port) []field {
+	fields := p.fields()
+	for i := range fields {
+		switch fields[i].name {
+		case "sds_idx":
+			if idx, err := sw.PortSerdes(p); err == nil && len(idx) > 0 {
+				var keys []string
+				for _, k := range idx {
+					keys = append(keys, fmt.Sprintf("serdes[sds_id=%d]", sw.Serdes[k].Id))
+				}
+				fields[i].value = strings.Join(keys, "|")
+			}
+		case "phy_idx":
+			if int(p.PhyIdx) < len(sw.Phys) {
+				fields[i].value = fmt.Sprintf("phys[mac_id=%d]", sw.Phys[p.PhyIdx].MacId)
+			}
+		}
+	}
+	return fields
+}
+
+func (sd *Serdes) fields() []field {
+	return []field{
+		{"sds_id", fmt.Sprint(sd.Id)},
+		{"mode", sd.Mode.String()},
+		{"rx_polarity", sd.RxPolarity.String()},
+		{"tx_polarity", sd.TxPolarity.String()},
+	}
+}
+
+func (sc *SerdesConverter) fields() []field {
+	return []field{
+		{"chip", sc.ChipName()},
+		{"smi", fmt.Sprint(sc.Smi)},
+		{"phy_addr", fmt.Sprint(sc.PhyAddr)},
+		{"rx_polarity", sc.RxPolarity.String()},
+		{"tx_polarity", sc.TxPolarity.String()},
+	}
+}
+
+func (p *Phy) fields() []field {
+	return []field{
+		{"chip", p.Chip.String()},
+		{"mac_id", fmt.Sprint(p.MacId)},
+		{"phy_max", fmt.Sprint(p.PhyMax)},
+	}
+}
+
+func (sw *Switch) fields() []field {
+	return []field{
+		{"chip_id", sw.ChipId.String()},
+		{"swcore_supported", fmt.Sprint(sw.SwitchCoreSupported)},
+		{"swcore_access_method", sw.SwitchCoreAccessMethod.String()},
+		{"swcore_spi_chip_select", fmt.Sprintf("0x%x", sw.SwitchCoreSpiChipSelect)},
+		{"nic_supported", fmt.Sprint(sw.NicSupported)},
+	}
+}
+
+// fields of the LEDs, led_active only if the sources give it.
+func (l *Leds) fields() []field {
+	var fields []field
+	if l.HasActive {
+		fields = append(fields, field{"led_active", l.Active.String()})
+	}
+	fields = append(fields, field{"led_if_sel", l.LedIfSel.String()})
+	for i, set := range l.LedSet {
+		for j, led := range set.Led {
+			fields = append(fields, field{fmt.Sprintf("led_definition_set[%d].led[%d]", i, j), fmt.Sprintf("0x%04x (%s)", uint32(led), led)})
+		}
+	}
+	return fields
+}
+
+func summary(fields []field) string {
+	var values []string
+	for _, f := range fields {
+		values = append(values, f.name+": "+f.value)
+	}
+	return "{" + strings.Join(values, ", ") + "}"
+}
+
+// differ accumulates the changes between two descriptors.
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) fields(path string, a, b []field) {
+	for i := range a {
+		if a[i].value != b[i].value {
+			d.changes = append(d.changes, Change{Path: path + a[i].name, Kind: MODIFIED, Old: a[i].value, New: b[i].value})
+		}
+	}
+}
+
+// entries matches the entries of two tables by key and compares them. The
+// order of the first table prevails, then the added entries are reported in
+// the order of the second one, then its duplicates. fieldsA and fieldsB
+// format the entries of a and b.
+func entries[T any](d *differ, name string, a, b []T, key func(int, T) string, fieldsA, fieldsB func(T) []field) {
+	// The index of the first entry of b of each key.
+	bKeys := make(map[string]int)
+	var bDups []Change
+	for i, e := range b {
+		k := key(i, e)
+		if _, ok := bKeys[k]; ok {
+			bDups = append(bDups, Change{Path: fmt.Sprintf("%s[%s]", name, k), Kind: DUPLICATE, New: summary(fieldsB(e))})
+			continue
+		}
+		bKeys[k] = i
+	}
+	aKeys := make(map[string]bool)
+	for i, e := range a {
+		k := key(i, e)
+		path := fmt.Sprintf("%s[%s]", name, k)
+		if aKeys[k] {
+			d.changes = append(d.changes, Change{Path: path, Kind: DUPLICATE, Old: summary(fieldsA(e))})
+			continue
+		}
+		aKeys[k] = true
+		j, ok := bKeys[k]
+		if !ok {
+			d.changes = append(d.changes, Change{Path: path, Kind: REMOVED, Old: summary(fieldsA(e))})
+			continue
+		}
+		d.fields(path+".", fieldsA(e), fieldsB(b[j]))
+	}
+	for i, e := range b {
+		if k := key(i, e); !aKeys[k] && bKeys[k] == i {
+			d.changes = append(d.changes, Change{Path: fmt.Sprintf("%s[%s]", name, k), Kind: ADDED, New: summary(fieldsB(e))})
+		}
+	}
+	d.changes = append(d.changes, bDups...)
+}
+
+// Diff returns the changes from a to b. Ports and PHYs are matched by MAC ID,
+// serdes by identifier and converters by position. The serdes and PHY of the
+// ports are compared by the entries they index. Entries sharing a key with a
+// previous one of their table are reported as duplicates.
+func Diff(a, b *Switch) []Change {
+	d := &differ{}
+	d.fields("", a.fields(), b.fields())
+	entries(d, "ports", a.Ports, b.Ports,
+		func(_ int, p *Port) string { return fmt.Sprintf("mac_id=%d", p.MacId) },
+		a.portFields, b.portFields)
+	entries(d, "serdes", a.Serdes, b.Serdes,
+		func(_ int, s *Serdes) string { return fmt.Sprintf("sds_id=%d", s.Id) },
+		(*Serdes).fields, (*Serdes).fields)
+	entries(d, "converters", a.Converters, b.Converters,
+		func(i int, _ *SerdesConverter) string { return fmt.Sprint(i) },
+		(*SerdesConverter).fields, (*SerdesConverter).fields)
+	entries(d, "phys", a.Phys, b.Phys,
+		func(_ int, p *Phy) string { return fmt.Sprintf("mac_id=%d", p.MacId) },
+		(*Phy).fields, (*Phy).fields)
+	if a.Leds != nil && b.Leds != nil {
+		fa, fb := a.Leds.fields(), b.Leds.fields()
+		// led_active is only known from sources: it is reported when one
+		// descriptor only gives it.
+		switch {
+		case a.Leds.HasActive && !b.Leds.HasActive:
+			d.changes = append(d.changes, Change{Path: "leds.led_active", Kind: REMOVED, Old: fa[0].value})
+			fa = fa[1:]
+		case b.Leds.HasActive && !a.Leds.HasActive:
+			d.changes = append(d.changes, Change{Path: "leds.led_active", Kind: ADDED, New: fb[0].value})
+			fb = fb[1:]
+		}
+		d.fields("leds.", fa, fb)
+	}
+	return d.changes
+}
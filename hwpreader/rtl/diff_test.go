@@ -0,0 +1,98 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	if changes := Diff(validSwitch(), validSwitch()); len(changes) != 0 {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a, b := validSwitch(), validSwitch()
+	// Reordering entries is not a change.
+	b.Serdes[0], b.Serdes[1] = b.Serdes[1], b.Serdes[0]
+	for _, p := range b.Ports {
+		if p.SdsIdx != HWP_NONE {
+			p.SdsIdx = 1 - p.SdsIdx
+		}
+	}
+	b.Ports[0].Smi = 1
+	b.Serdes[0].Mode = RTK_MII_10GR1000BX_AUTO
+	b.Ports = append(b.Ports[:2], b.Ports[3:]...)
+	b.Ports = append(b.Ports, &Port{MacId: 25, PhyIdx: HWP_NONE, SdsIdx: 0, LedC: HWP_NONE, LedF: 1})
+	b.Leds.LedSet[1].Led[2] = 0xa08
+
+	want := []Change{
+		{Path: "ports[mac_id=0].smi", Kind: MODIFIED, Old: "0", New: "1"},
+		{Path: "ports[mac_id=24]", Kind: REMOVED, Old: summary(a.portFields(a.Ports[2]))},
+		{Path: "ports[mac_id=25]", Kind: ADDED, New: summary(b.portFields(b.Ports[3]))},
+		{Path: "serdes[sds_id=6].mode", Kind: MODIFIED, Old: "RTK_MII_10GR", New: "RTK_MII_10GR1000BX_AUTO"},
+		{Path: "leds.led_definition_set[1].led[2]", Kind: MODIFIED, Old: "0x0000 (off)", New: "0x0a08 (link act speed(2.5G))"},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestDiffLedActive(t *testing.T) {
+	// led_active is only given by the sources.
+	binary, source := validSwitch(), validSwitch()
+	source.Leds.Active, source.Leds.HasActive = LED_ACTIVE_HIGH, true
+	if got, want := Diff(binary, source), []Change{{Path: "leds.led_active", Kind: ADDED, New: "HIGH"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("binary to source: got %v, want %v", got, want)
+	}
+	if got, want := Diff(source, binary), []Change{{Path: "leds.led_active", Kind: REMOVED, Old: "HIGH"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("source to binary: got %v, want %v", got, want)
+	}
+	other := validSwitch()
+	other.Leds.HasActive = true
+	if got, want := Diff(other, source), []Change{{Path: "leds.led_active", Kind: MODIFIED, Old: "LOW", New: "HIGH"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("between sources: got %v, want %v", got, want)
+	}
+}
+
+func TestDiffIndexedEntries(t *testing.T) {
+	a, b := validSwitch(), validSwitch()
+	// The ports keep their indexes but now use other serdes and PHYs.
+	b.Serdes[0], b.Serdes[1] = b.Serdes[1], b.Serdes[0]
+	b.Phys = append(b.Phys, &Phy{Chip: RTK_PHYTYPE_RTL8224, MacId: 16, PhyMax: 2})
+	b.Phys[0], b.Phys[1] = b.Phys[1], b.Phys[0]
+
+	want := []Change{
+		{Path: "ports[mac_id=0].phy_idx", Kind: MODIFIED, Old: "phys[mac_id=0]", New: "phys[mac_id=16]"},
+		{Path: "ports[mac_id=0].sds_idx", Kind: MODIFIED, Old: "serdes[sds_id=2]", New: "serdes[sds_id=6]"},
+		{Path: "ports[mac_id=1].phy_idx", Kind: MODIFIED, Old: "phys[mac_id=0]", New: "phys[mac_id=16]"},
+		{Path: "ports[mac_id=1].sds_idx", Kind: MODIFIED, Old: "serdes[sds_id=2]", New: "serdes[sds_id=6]"},
+		{Path: "ports[mac_id=24].sds_idx", Kind: MODIFIED, Old: "serdes[sds_id=6]", New: "serdes[sds_id=2]"},
+		{Path: "phys[mac_id=16]", Kind: ADDED, New: summary(b.Phys[0].fields())},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestDiffDuplicates(t *testing.T) {
+	a, b := validSwitch(), validSwitch()
+	a.Ports[1].MacId = 0
+	b.Serdes[1].Id = 2
+
+	want := []Change{
+		{Path: "ports[mac_id=0]", Kind: DUPLICATE, Old: summary(a.portFields(a.Ports[1]))},
+		{Path: "ports[mac_id=24].sds_idx", Kind: MODIFIED, Old: "serdes[sds_id=6]", New: "serdes[sds_id=2]"},
+		{Path: "ports[mac_id=1]", Kind: ADDED, New: summary(b.portFields(b.Ports[1]))},
+		{Path: "serdes[sds_id=6]", Kind: REMOVED, Old: summary(a.Serdes[1].fields())},
+		{Path: "serdes[sds_id=2]", Kind: DUPLICATE, New: summary(b.Serdes[1].fields())},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\ngot:  %v\nwant: %v", got, want)
+	}
+	if s := want[0].String(); s != "! ports[mac_id=0]: duplicated in the first descriptor: "+want[0].Old {
+		t.Errorf("unexpected duplicate description %q", s)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import "fmt"
+
+// ParseError is a failure to decode a field of a binary descriptor.
+type ParseError struct {
+	// Offset is the absolute offset of the field in the decoded image.
+	Offset int64
+	// Path designates the field, e.g. serdes[7].mode.
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("offset 0x%x: %s: %v", e.Offset, e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Warning is a suspicious value met while decoding a binary descriptor. The
+// descriptor is decoded anyway, the value being kept as is.
+type Warning struct {
+	// Offset is the absolute offset of the field in the decoded image.
+	Offset int64
+	// Path designates the field, e.g. serdes[7].mode.
+	Path    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("offset 0x%x: %s: %s", w.Offset, w.Path, w.Message)
+}
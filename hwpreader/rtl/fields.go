@@ -0,0 +1,121 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is a byte range of a descriptor and the field decoded from it.
+type Field struct {
+	// Offset is the absolute offset of the field, as the offsets of the
+	// parse errors.
+	Offset int64
+	Size   int
+	// Path names the field as the parse errors, e.g. ports[3].sds_idx.
+	// Padding is named after the field it aligns.
+	Path string
+	// Pad is set for alignment and padding bytes.
+	Pad bool
+	// Unused is set for the entries following the end marker of a table.
+	Unused bool
+	// Bits are the bitfields packed in the byte of the field, if any.
+	Bits []Bitfield
+}
+
+// Bitfield is a bitfield of a byte: its mask in the byte as laid out and the
+// name of its value, e.g. rx_polarity.
+type Bitfield struct {
+	Mask uint8
+	Name string
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("0x%x+%d %s", f.Offset, f.Size, f.Path)
+}
+
+// DecodeFields decodes the descriptor as DecodeBytes does and also returns
+// the fields read, in offset order. The fields cover the descriptor up to the
+// decoding failure, if any.
+func (sw *Switch) DecodeFields(data []byte, l *Layout, base int64) ([]Field, []Warning, error) {
+	d := newDecoder(data, l, base)
+	d.record = true
+	d.fields = make([]Field, 0, l.Size())
+	sw.decode(d, l)
+	return d.fields, d.warnings, d.err()
+}
+
+// FieldAt returns the index of the field holding the byte at the absolute
+// offset off, false if none. fields must be in offset order.
+func FieldAt(fields []Field, off int64) (int, bool) {
+	lo, hi := 0, len(fields)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch f := fields[m]; {
+		case off < f.Offset:
+			hi = m
+		case off >= f.Offset+int64(f.Size):
+			lo = m + 1
+		default:
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// Value is a decoded value of a descriptor entry.
+type Value struct {
+	Name  string
+	Value string
+}
+
+// Values returns the decoded values of the entry designated by path: the
+// descriptor header for an empty path, ports[i], serdes[i], converters[i],
+// phys[i], leds or leds.led_definition_set[i]. Values of unknown entries are
+// nil.
+func (sw *Switch) Values(path string) []Value {
+	var fields []field
+	table, index, indexed := entryPath(path)
+	switch {
+	case path == "":
+		fields = sw.fields()
+	case table == "ports" && indexed && index < len(sw.Ports):
+		fields = sw.Ports[index].fields()
+	case table == "serdes" && indexed && index < len(sw.Serdes):
+		fields = sw.Serdes[index].fields()
+	case table == "converters" && indexed && index < len(sw.Converters):
+		fields = sw.Converters[index].fields()
+	case table == "phys" && indexed && index < len(sw.Phys):
+		fields = sw.Phys[index].fields()
+	case path == "leds" && sw.Leds != nil:
+		fields = sw.Leds.fields()
+	case table == "leds.led_definition_set" && indexed && index < RTK_MAX_LED_MOD && sw.Leds != nil:
+		prefix := fmt.Sprintf("led_definition_set[%d].", index)
+		for _, f := range sw.Leds.fields() {
+			if name, ok := strings.CutPrefix(f.name, prefix); ok {
+				fields = append(fields, field{name, f.value})
+			}
+		}
+	}
+	var values []Value
+	for _, f := range fields {
+		values = append(values, Value{f.name, f.value})
+	}
+	return values
+}
+
+// entryPath splits the path of a table entry, e.g. ports[3], in its table
+// and index.
+func entryPath(path string) (string, int, bool) {
+	table, rest, ok := strings.Cut(path, "[")
+	if !ok || !strings.HasSuffix(rest, "]") {
+		return path, 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimSuffix(rest, "]"))
+	if err != nil || index < 0 {
+		return path, 0, false
+	}
+	return table, index, true
+}
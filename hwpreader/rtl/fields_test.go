@@ -0,0 +1,113 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeFields(t *testing.T) {
+	port := &Port{MacId: 24, PhyIdx: HWP_NONE, Smi: HWP_NONE, PhyAddr: HWP_NONE, SdsIdx: 0, Attr: HWP_ETHER, Eth: HWP_XGE, Medi: HWP_FIBER, LedC: HWP_NONE, LedF: 0}
+	for _, l := range Layouts {
+		t.Run(l.Name, func(t *testing.T) {
+			data := encodeSwitch(l, RTL9302B_CHIP_ID, port)
+			sw := &Switch{}
+			fields, _, err := sw.DecodeFields(data, l, 0x100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sw.Ports) != 1 || sw.Ports[0].MacId != 24 {
+				t.Errorf("unexpected ports %v", sw.Ports)
+			}
+
+			// The fields cover the descriptor without gap nor overlap.
+			off := int64(0x100)
+			for _, f := range fields {
+				if f.Offset != off {
+					t.Fatalf("field %s at 0x%x, want 0x%x", f.Path, f.Offset, off)
+				}
+				off += int64(f.Size)
+			}
+			if off != 0x100+int64(l.Size()) {
+				t.Errorf("fields end at 0x%x, want 0x%x", off, 0x100+l.Size())
+			}
+
+			for _, tt := range []struct {
+				off    int64
+				path   string
+				pad    bool
+				unused bool
+			}{
+				{0x100, "chip_id", false, false},
+				{0x105, "swcore_access_method", true, false},
+				{0x108, "swcore_access_method", false, false},
+				{0x111, "ports", true, false},
+				{0x114 + 4, "ports[0].sds_idx", false, false},
+				{0x114 + portSize, "ports[1].mac_id", false, false},
+				{0x114 + 2*portSize, "ports[2].mac_id", false, true},
+				{0x100 + int64(l.Size()) - 1, "leds.led_definition_set[3].led[4]", false, false},
+			} {
+				i, ok := FieldAt(fields, tt.off)
+				if !ok {
+					t.Errorf("no field at 0x%x", tt.off)
+					continue
+				}
+				if f := fields[i]; f.Path != tt.path || f.Pad != tt.pad || f.Unused != tt.unused {
+					t.Errorf("field at 0x%x is %s (pad %v, unused %v), want %s (pad %v, unused %v)",
+						tt.off, f.Path, f.Pad, f.Unused, tt.path, tt.pad, tt.unused)
+				}
+			}
+			if _, ok := FieldAt(fields, 0xff); ok {
+				t.Errorf("field found before the descriptor")
+			}
+
+			// The bitfields of a byte are recorded with their mask.
+			i, _ := FieldAt(fields, 0x114+portSize-1)
+			want := []Bitfield{{0x08, "phy_mdi_pin_swap"}, {0x0f, "phy_mdi_pair_swap"}}
+			if l.Bits == LSB_FIRST {
+				want = []Bitfield{{0x10, "phy_mdi_pin_swap"}, {0xf0, "phy_mdi_pair_swap"}}
+			}
+			if got := fields[i].Bits; fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("bitfields of %s: got %v, want %v", fields[i].Path, got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeFieldsTruncated(t *testing.T) {
+	data := encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})
+	fields, _, err := (&Switch{}).DecodeFields(data[:100], SDK3_BE, 0)
+	if err == nil {
+		t.Fatal("truncated descriptor decoded")
+	}
+	if f := fields[len(fields)-1]; f.Offset+int64(f.Size) > 100 {
+		t.Errorf("last field %s beyond the data", f)
+	}
+}
+
+func TestValues(t *testing.T) {
+	sw := validSwitch()
+	for _, tt := range []struct {
+		path string
+		want Value
+		n    int
+	}{
+		{"", Value{"chip_id", "RTL9302B (0x93021000)"}, 5},
+		{"ports[2]", Value{"mac_id", "24"}, 14},
+		{"serdes[1]", Value{"sds_id", "6"}, 4},
+		{"phys[0]", Value{"chip", "RTK_PHYTYPE_RTL8224"}, 3},
+		{"leds", Value{"led_if_sel", "SERIAL"}, 1 + RTK_MAX_LED_MOD*RTK_MAX_LED_PER_PORT},
+		{"leds.led_definition_set[3]", Value{"led[0]", "0x0000 (" + LedWord(0).String() + ")"}, RTK_MAX_LED_PER_PORT},
+	} {
+		values := sw.Values(tt.path)
+		if len(values) != tt.n || values[0] != tt.want {
+			t.Errorf("%q: %d values starting with %v, want %d starting with %v", tt.path, len(values), values, tt.n, tt.want)
+		}
+	}
+	for _, path := range []string{"ports[4]", "converters[0]", "ports", "ports[x]", "unknown[0]"} {
+		if values := sw.Values(path); values != nil {
+			t.Errorf("%q: unexpected values %v", path, values)
+		}
+	}
+}
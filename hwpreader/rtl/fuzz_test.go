@@ -0,0 +1,146 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// addSeeds adds the descriptors of the corpus and a synthetic one to the seed
+// corpus of a fuzz target, whole and truncated.
+func addSeeds(f *testing.F) {
+	seeds := [][]byte{encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})}
+	blobs, _ := filepath.Glob("../testdata/*.bin")
+	for _, blob := range blobs {
+		data, err := os.ReadFile(blob)
+		if err != nil {
+			f.Fatal(err)
+		}
+		seeds = append(seeds, data)
+	}
+	for _, data := range seeds {
+		f.Add(data)
+		f.Add(data[:len(data)/2])
+	}
+	f.Add([]byte{})
+}
+
+// checkParseError checks that err is a *ParseError located within data.
+func checkParseError(t *testing.T, err error, data []byte) {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if pe.Offset < 0 || pe.Offset > int64(len(data)) {
+		t.Fatalf("parse error %v out of the %d bytes of data", err, len(data))
+	}
+}
+
+func FuzzUnmarshalBinary(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sw := &Switch{}
+		err := sw.UnmarshalBinary(bufio.NewReader(bytes.NewReader(data)))
+		if len(data) < SDK3_BE.Size() {
+			checkParseError(t, err, data)
+		} else if err != nil {
+			t.Fatalf("unexpected error on a whole descriptor: %v", err)
+		}
+
+		for _, l := range Layouts {
+			sw := &Switch{}
+			if _, err := sw.DecodeBytes(data, l, 0); err != nil {
+				checkParseError(t, err, data)
+				continue
+			}
+			if len(sw.Ports) > l.Ports || len(sw.Serdes) > l.Serdes || len(sw.Converters) > l.Converters || len(sw.Phys) > l.Phys {
+				t.Fatalf("%s: tables beyond their capacity", l)
+			}
+			sw.Validate()
+		}
+		DetectLayout(data)
+		Scan(data, 2)
+	})
+}
+
+// addEntrySeeds adds the first entries of a table of the corpus descriptors to
+// the seed corpus of a fuzz target, then the synthetic seeds.
+func addEntrySeeds(f *testing.F, table string, size int, seeds ...[]byte) {
+	blobs, _ := filepath.Glob("../testdata/*.bin")
+	for _, blob := range blobs {
+		data, err := os.ReadFile(blob)
+		if err != nil {
+			f.Fatal(err)
+		}
+		l, err := DetectLayout(data)
+		if err != nil {
+			f.Fatalf("%s: %v", blob, err)
+		}
+		fields, _, err := (&Switch{}).DecodeFields(data, l, 0)
+		if err != nil {
+			f.Fatalf("%s: %v", blob, err)
+		}
+		for _, fd := range fields {
+			if strings.HasPrefix(fd.Path, table+"[0].") {
+				f.Add(data[fd.Offset : fd.Offset+int64(4*size)])
+				break
+			}
+		}
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+}
+
+// fuzzRead checks that read decodes the entries of size bytes of data one
+// after the other, up to io.EOF or to a parse error on a truncated entry.
+func fuzzRead(t *testing.T, data []byte, size int, read func(r *bufio.Reader) error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	for i := range len(data) / size {
+		if err := read(r); err != nil {
+			t.Fatalf("entry %d: unexpected error: %v", i, err)
+		}
+	}
+	err := read(r)
+	if len(data)%size == 0 {
+		if err != io.EOF {
+			t.Fatalf("expected io.EOF after the entries, got %v", err)
+		}
+		return
+	}
+	checkParseError(t, err, data[len(data)/size*size:])
+}
+
+func FuzzPortRead(f *testing.F) {
+	addEntrySeeds(f, "ports", portSize,
+		[]byte{0, 0, 0, 0, 0, 0, 0, 0, byte(HWP_ETHER), byte(HWP_XGE), byte(HWP_FIBER), 0, 0, 0, 0, 0},
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRead(t, data, portSize, func(r *bufio.Reader) error { return (&Port{}).Read(r) })
+	})
+}
+
+func FuzzSerdesRead(f *testing.F) {
+	addEntrySeeds(f, "serdes", serdesSize,
+		[]byte{2, byte(RTK_MII_10GR) << SERDES_MODE_OFFSET},
+		[]byte{0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRead(t, data, serdesSize, func(r *bufio.Reader) error { return (&Serdes{}).Read(r) })
+	})
+}
+
+func FuzzSerdesConverterRead(f *testing.F) {
+	addEntrySeeds(f, "converters", converterSize,
+		[]byte{0, 0, 0, 1, 0, 4, CONVERTER_RX_POLARITY_MASK, 0},
+		[]byte{0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzRead(t, data, converterSize, func(r *bufio.Reader) error { return (&SerdesConverter{}).Read(r) })
+	})
+}
@@ -0,0 +1,328 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+// BitOrder is the order in which the compiler allocates the bitfields of a
+// storage unit.
+type BitOrder int
+
+const (
+	// MSB_FIRST allocates the first bitfield at the most significant bits,
+	// as GCC does for big endian targets.
+	MSB_FIRST BitOrder = iota
+	// LSB_FIRST allocates the first bitfield at the least significant bits,
+	// as GCC does for little endian targets.
+	LSB_FIRST
+)
+
+// Layout describes how a switch descriptor (hwp_swDescp_t) is laid out in
+// memory. It depends on the SDK release the firmware was built with, which
+// sets the size of the tables, and on the endianness of the target, which
+// sets the order of the bytes of the integers and of the bitfields of the
+// serdes, converter and port entries.
+type Layout struct {
+	Name  string
+	Order binary.ByteOrder
+	Bits  BitOrder
+
+	// Capacities of the descriptor tables.
+	Ports      int
+	Serdes     int
+	Converters int
+	Phys       int
+
+	// Alignment padding following swcore_supported, nic_supported and the
+	// entry counts of the port, serdes, converter and PHY tables.
+	SwcorePad    int
+	NicPad       int
+	PortCountPad int
+	SdsCountPad  int
+	ScCountPad   int
+	PhyCountPad  int
+}
+
+// Size of a table entry in the descriptor.
+const (
+	portSize      = 16
+	serdesSize    = 2
+	converterSize = 8
+	phySize       = 8
+	ledsSize      = 4 + 4*RTK_MAX_LED_MOD*RTK_MAX_LED_PER_PORT
+)
+
+// Size returns the size of a descriptor with this layout.
+func (l *Layout) Size() int {
+	return 4 + 1 + l.SwcorePad + 4 + 1 + 1 + l.NicPad +
+		1 + l.PortCountPad + l.Ports*portSize +
+		1 + l.SdsCountPad + l.Serdes*serdesSize +
+		1 + l.ScCountPad + l.Converters*converterSize +
+		1 + l.PhyCountPad + l.Phys*phySize +
+		ledsSize
+}
+
+func (l *Layout) String() string {
+	return l.Name
+}
+
+// Layouts known by the decoder.
+var (
+	// SDK 3.x for MIPS targets: RTL838x, RTL839x, RTL930x and RTL931x
+	// switches with an embedded CPU. This is the layout of the D-Link
+	// DMS-1250 SDK.
+	SDK3_BE = &Layout{
+		Name: "sdk3-be", Order: binary.BigEndian, Bits: MSB_FIRST,
+		Ports: RTK_MAX_PORT_PER_UNIT, Serdes: RTK_MAX_SDS_PER_UNIT, Converters: RTK_MAX_SC_PER_UNIT, Phys: RTK_MAX_PHY_PER_UNIT,
+		SwcorePad: 3, NicPad: 2, PortCountPad: 3, SdsCountPad: 0, ScCountPad: 3, PhyCountPad: 6,
+	}
+	// SDK 3.x built for little endian hosts, e.g. an external ARM CPU
+	// driving a RTL931x switch core.
+	SDK3_LE = &Layout{
+		Name: "sdk3-le", Order: binary.LittleEndian, Bits: LSB_FIRST,
+		Ports: RTK_MAX_PORT_PER_UNIT, Serdes: RTK_MAX_SDS_PER_UNIT, Converters: RTK_MAX_SC_PER_UNIT, Phys: RTK_MAX_PHY_PER_UNIT,
+		SwcorePad: 3, NicPad: 2, PortCountPad: 3, SdsCountPad: 0, ScCountPad: 3, PhyCountPad: 6,
+	}
+	// SDK 2.x, only supporting RTL838x and RTL839x: 57 ports and 14 serdes
+	// per unit.
+	SDK2_BE = &Layout{
+		Name: "sdk2-be", Order: binary.BigEndian, Bits: MSB_FIRST,
+		Ports: 57, Serdes: 14, Converters: RTK_MAX_SC_PER_UNIT, Phys: RTK_MAX_PHY_PER_UNIT,
+		SwcorePad: 3, NicPad: 2, PortCountPad: 3, SdsCountPad: 0, ScCountPad: 3, PhyCountPad: 6,
+	}
+
+	Layouts = []*Layout{SDK3_BE, SDK3_LE, SDK2_BE}
+)
+
+// LayoutByName returns the known layout called name.
+func LayoutByName(name string) (*Layout, error) {
+	for _, l := range Layouts {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown layout %q", name)
+}
+
+// DetectLayout returns the known layout that decodes the most plausible
+// switch descriptor from data, which starts with the descriptor.
+func DetectLayout(data []byte) (*Layout, error) {
+	var best *Layout
+	bestScore := 0
+	for _, l := range Layouts {
+		if len(data) < l.Size() {
+			continue
+		}
+		sw := &Switch{}
+		sw.decode(newDecoder(data[:l.Size()], l, 0), l)
+		if score := plausibility(sw, l); score > bestScore {
+			best, bestScore = l, score
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no layout matches the descriptor")
+	}
+	return best, nil
+}
+
+// plausibility scores a descriptor decoded with a layout: the higher, the
+// more likely the layout is the right one.
+func plausibility(sw *Switch, l *Layout) int {
+	score := 0
+	// The byte order of the chip is no hint: the SDK may run on an
+	// external CPU.
+	if info, _ := sw.ChipId.Info(); info != nil {
+		score += 100
+	}
+	if sw.SwitchCoreAccessMethod < HWP_SW_ACC_END {
+		score += 10
+	}
+	// A table filled up to its capacity has no end marker: the capacity is
+	// likely wrong.
+	for _, full := range []bool{
+		len(sw.Ports) == l.Ports, len(sw.Serdes) == l.Serdes,
+		len(sw.Converters) == l.Converters, len(sw.Phys) == l.Phys,
+	} {
+		if !full {
+			score += 10
+		}
+	}
+	for _, p := range sw.Ports {
+		if p.Eth <= HWP_XGE || p.Eth == HWP_NONE {
+			score++
+		}
+		if p.SdsIdx == HWP_NONE || p.SdsIdx < uint32(l.Serdes) || p.Attr&HWP_CPU != 0 {
+			score++
+		}
+	}
+	for _, sd := range sw.Serdes {
+		if sd.Mode < RTK_MII_END {
+			score++
+		}
+	}
+	for _, p := range sw.Phys {
+		if p.Chip < RTK_PHYTYPE_END {
+			score++
+		}
+	}
+	if sw.Leds.LedIfSel <= LED_IF_SEL_BI_COLOR_SCAN {
+		score++
+	}
+	return score
+}
+
+// decoder reads the fields of a descriptor from a window of an image, with the
+// byte and bit orders of a layout. Reads are named after the field of the entry being
+// decoded to locate errors and warnings. The first failure is recorded and
+// makes the following reads no-ops; the ParseError is only built by err. As
+// scanning an image rejects most candidates, a truncated window is decoded
+// dry: nothing is allocated but the ParseError and its path.
+type decoder struct {
+	data  []byte
+	order binary.ByteOrder
+	bits  BitOrder
+	// base is the absolute offset of the window, off the offset of the next
+	// field and last the offset of the last field read.
+	base      int64
+	off, last int
+	// entry and index locate the entry being decoded, e.g. ports[3]; index
+	// is negative for the entries that are not part of a table.
+	entry string
+	index int
+	// readErr is the error that truncated the window, io.EOF by default.
+	readErr error
+	// quiet drops the warnings about the entries following an end marker,
+	// dry all the warnings and the decoded entries of a truncated window.
+	quiet    bool
+	dry      bool
+	failed   bool
+	failure  failure
+	warnings []Warning
+	// fields records the byte ranges read when record is set, padding being
+	// set while skipping alignment bytes.
+	record  bool
+	padding bool
+	fields  []Field
+}
+
+// failure locates the field that could not be read.
+type failure struct {
+	off     int
+	entry   string
+	index   int
+	field   string
+	partial bool
+}
+
+func newDecoder(data []byte, l *Layout, base int64) *decoder {
+	return &decoder{data: data, order: l.Order, bits: l.Bits, base: base, index: -1}
+}
+
+func (d *decoder) path(entry string, index int, field string) string {
+	switch {
+	case entry == "":
+		return field
+	case index < 0:
+		return entry + "." + field
+	default:
+		return entry + "[" + strconv.Itoa(index) + "]." + field
+	}
+}
+
+// err returns the failure of the decoding as a *ParseError, nil if none.
+func (d *decoder) err() error {
+	if !d.failed {
+		return nil
+	}
+	f := &d.failure
+	err := d.readErr
+	switch {
+	case err != nil:
+	case f.partial:
+		err = io.ErrUnexpectedEOF
+	default:
+		err = io.EOF
+	}
+	return &ParseError{Offset: d.base + int64(f.off), Path: d.path(f.entry, f.index, f.field), Err: err}
+}
+
+// warn records a warning about the last field read.
+func (d *decoder) warn(field string, format string, args ...any) {
+	if d.quiet || d.dry {
+		return
+	}
+	d.warnings = append(d.warnings, Warning{
+		Offset:  d.base + int64(d.last),
+		Path:    d.path(d.entry, d.index, field),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// next returns the n bytes of the field, nil once the decoding failed.
+func (d *decoder) next(field string, n int) []byte {
+	if d.failed {
+		return nil
+	}
+	if len(d.data)-d.off < n {
+		d.failed = true
+		d.failure = failure{off: d.off, entry: d.entry, index: d.index, field: field, partial: d.off < len(d.data)}
+		return nil
+	}
+	d.last = d.off
+	d.off += n
+	if d.record && n > 0 {
+		d.fields = append(d.fields, Field{
+			Offset: d.base + int64(d.last),
+			Size:   n,
+			Path:   d.path(d.entry, d.index, field),
+			Pad:    d.padding || field == "pad",
+			Unused: d.quiet,
+		})
+	}
+	return d.data[d.last:d.off:d.off]
+}
+
+func (d *decoder) u8(field string) uint8 {
+	if b := d.next(field, 1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u32(field string) uint32 {
+	if b := d.next(field, 4); b != nil {
+		return d.order.Uint32(b)
+	}
+	return 0
+}
+
+// bitfield is a bitfield of a byte, given by its mask on big endian targets.
+type bitfield uint8
+
+// unpack returns the value of the bitfield f, called name, of the byte b
+// read last. The fields take mirrored positions in the byte when the
+// bitfields are allocated from the least significant bit.
+func (d *decoder) unpack(b uint8, f bitfield, name string) uint8 {
+	mask := uint8(f)
+	if d.bits == LSB_FIRST {
+		mask = bits.Reverse8(mask)
+	}
+	if d.record && !d.failed && len(d.fields) > 0 {
+		last := &d.fields[len(d.fields)-1]
+		last.Bits = append(last.Bits, Bitfield{mask, name})
+	}
+	return b & mask >> bits.TrailingZeros8(mask)
+}
+
+// skip reads the n padding bytes preceding a field.
+func (d *decoder) skip(field string, n int) {
+	d.padding = true
+	d.next(field, n)
+	d.padding = false
+}
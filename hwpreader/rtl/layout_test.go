@@ -0,0 +1,250 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+	"reflect"
+	"testing"
+)
+
+// pack sets the bitfield f of b to v, laid out as l.
+func pack(l *Layout, b byte, f bitfield, v uint8) byte {
+	mask := uint8(f)
+	if l.Bits == LSB_FIRST {
+		mask = bits.Reverse8(mask)
+	}
+	return b&^mask | v<<bits.TrailingZeros8(mask)&mask
+}
+
+// encodeSwitch returns the switch descriptor of a chip with the given ports,
+// one 10GR serdes with its rx polarity swapped and no converter nor PHY, laid
+// out as l.
+func encodeSwitch(l *Layout, chip RtlChipId, ports ...*Port) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, l.Order, chip)
+	b.WriteByte(1)
+	b.Write(make([]byte, l.SwcorePad))
+	binary.Write(&b, l.Order, HWP_SW_ACC_MEM)
+	b.Write([]byte{HWP_NOT_USED, 1})
+	b.Write(make([]byte, l.NicPad))
+	b.WriteByte(byte(len(ports)))
+	b.Write(make([]byte, l.PortCountPad))
+	for i := range l.Ports {
+		p := &Port{MacId: HWP_END}
+		if i < len(ports) {
+			p = ports[i]
+		}
+		b.Write([]byte{p.MacId, p.PhyIdx, p.Smi, p.PhyAddr})
+		binary.Write(&b, l.Order, p.SdsIdx)
+		swap := pack(l, 0, pairSwapField, p.PhyMdiPairSwap)
+		if p.PhyMdiPinSwap {
+			swap = pack(l, swap, pinSwapField, 1)
+		}
+		b.Write([]byte{byte(p.Attr), byte(p.Eth), byte(p.Medi), p.ScIdx, byte(p.LedC), byte(p.LedF), byte(p.LedLayout), swap})
+	}
+	b.WriteByte(1)
+	b.Write(make([]byte, l.SdsCountPad))
+	b.Write([]byte{0, pack(l, pack(l, 0, serdesModeField, uint8(RTK_MII_10GR)), serdesRxField, 1)})
+	for range l.Serdes - 1 {
+		b.Write([]byte{HWP_END, 0})
+	}
+	b.WriteByte(0)
+	b.Write(make([]byte, l.ScCountPad))
+	for range l.Converters {
+		binary.Write(&b, l.Order, []uint32{HWP_END, 0})
+	}
+	b.WriteByte(0)
+	b.Write(make([]byte, l.PhyCountPad))
+	for range l.Phys {
+		binary.Write(&b, l.Order, []uint32{HWP_END, 0})
+	}
+	binary.Write(&b, l.Order, LED_IF_SEL_SERIAL)
+	for i := range RTK_MAX_LED_MOD * RTK_MAX_LED_PER_PORT {
+		binary.Write(&b, l.Order, uint32(i))
+	}
+	return b.Bytes()
+}
+
+func TestLayouts(t *testing.T) {
+	ports := []*Port{
+		{MacId: 0, PhyIdx: HWP_NONE, Smi: HWP_NONE, PhyAddr: HWP_NONE, SdsIdx: 0, Attr: HWP_ETHER, Eth: HWP_XGE, Medi: HWP_FIBER, LedC: HWP_NONE, LedF: 0, PhyMdiPinSwap: true, PhyMdiPairSwap: 0x9},
+		{MacId: 28, PhyIdx: HWP_NONE, Smi: HWP_NONE, PhyAddr: HWP_NONE, SdsIdx: HWP_NONE, Attr: HWP_CPU, Eth: HWP_NONE, Medi: HWP_NONE, LedC: HWP_NONE, LedF: HWP_NONE},
+	}
+	for _, l := range Layouts {
+		t.Run(l.Name, func(t *testing.T) {
+			data := encodeSwitch(l, RTL9302B_CHIP_ID, ports...)
+			if len(data) != l.Size() {
+				t.Fatalf("encoded %d bytes, layout size is %d", len(data), l.Size())
+			}
+
+			sw := &Switch{}
+			warnings, err := sw.Decode(bytes.NewReader(data), l, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+			if sw.ChipId != RTL9302B_CHIP_ID || sw.SwitchCoreAccessMethod != HWP_SW_ACC_MEM || !sw.NicSupported {
+				t.Errorf("unexpected switch core settings: %v, %v", sw.ChipId, sw.SwitchCoreAccessMethod)
+			}
+			if !reflect.DeepEqual(sw.Ports, ports) {
+				t.Errorf("unexpected ports: %v", sw.Ports)
+			}
+			if len(sw.Serdes) != 1 || sw.Serdes[0].Mode != RTK_MII_10GR || sw.Serdes[0].RxPolarity != SERDES_POLARITY_CHANGE || sw.Serdes[0].TxPolarity != SERDES_POLARITY_NORMAL {
+				t.Errorf("unexpected serdes: %v", sw.Serdes)
+			}
+			if len(sw.Converters) != 0 || len(sw.Phys) != 0 {
+				t.Errorf("unexpected converters %v or PHYs %v", sw.Converters, sw.Phys)
+			}
+			if sw.Leds.LedIfSel != LED_IF_SEL_SERIAL || sw.Leds.LedSet[3].Led[4] != 19 {
+				t.Errorf("unexpected LEDs: %v", sw.Leds)
+			}
+
+			// Trailing data must not confuse the detection.
+			detected, err := DetectLayout(append(data, make([]byte, 256)...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected != l {
+				t.Errorf("detected layout %s", detected)
+			}
+		})
+	}
+}
+
+func TestBitOrder(t *testing.T) {
+	tests := []struct {
+		l          *Layout
+		b          byte
+		mode       SerdesMode
+		rx, tx     SerdesPolarity
+		scRx, scTx SerdesPolarity
+		pin        bool
+		pair       uint8
+	}{
+		// Big endian: mode:6, rx:1, tx:1 from the most significant bit.
+		{SDK3_BE, byte(RTK_MII_10GR)<<2 | 0x2, RTK_MII_10GR, SERDES_POLARITY_CHANGE, SERDES_POLARITY_NORMAL, SERDES_POLARITY_CHANGE, SERDES_POLARITY_NORMAL, true, 0xa},
+		{SDK3_BE, 0x0d, RTK_MII_RXAUI, SERDES_POLARITY_NORMAL, SERDES_POLARITY_CHANGE, SERDES_POLARITY_CHANGE, SERDES_POLARITY_CHANGE, true, 0xd},
+		// Little endian: the same bitfields from the least significant bit.
+		{SDK3_LE, byte(RTK_MII_10GR) | 0x40, RTK_MII_10GR, SERDES_POLARITY_CHANGE, SERDES_POLARITY_NORMAL, SERDES_POLARITY_NORMAL, SERDES_POLARITY_NORMAL, false, 0x4},
+		{SDK3_LE, 0xb0, SerdesMode(0x30), SERDES_POLARITY_NORMAL, SERDES_POLARITY_CHANGE, SERDES_POLARITY_CHANGE, SERDES_POLARITY_CHANGE, true, 0xb},
+	}
+	for _, tt := range tests {
+		sd := &Serdes{}
+		sd.decode(newDecoder([]byte{0, tt.b}, tt.l, 0))
+		if sd.Mode != tt.mode || sd.RxPolarity != tt.rx || sd.TxPolarity != tt.tx {
+			t.Errorf("%s 0x%02x: unexpected serdes %v", tt.l, tt.b, sd)
+		}
+		sc := &SerdesConverter{}
+		sc.decode(newDecoder([]byte{0, 0, 0, 0, 0, 0, tt.b, 0}, tt.l, 0))
+		if sc.RxPolarity != tt.scRx || sc.TxPolarity != tt.scTx {
+			t.Errorf("%s 0x%02x: unexpected converter %v", tt.l, tt.b, sc)
+		}
+		p := &Port{}
+		p.decode(newDecoder(append(make([]byte, portSize-1), tt.b), tt.l, 0))
+		if p.PhyMdiPinSwap != tt.pin || p.PhyMdiPairSwap != tt.pair {
+			t.Errorf("%s 0x%02x: unexpected MDI swap %v 0x%x", tt.l, tt.b, p.PhyMdiPinSwap, p.PhyMdiPairSwap)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	r := bufio.NewReader(bytes.NewReader([]byte{
+		// Serdes 6 in 10GR with its rx polarity swapped.
+		6, byte(RTK_MII_10GR)<<SERDES_MODE_OFFSET | SERDES_RX_POLARITY_MASK,
+		// Converter on SMI 1 at address 4, tx polarity swapped.
+		0, 0, 0x82, 0x95, 1, 4, CONVERTER_TX_POLARITY_MASK, 0,
+		// Port 24 on serdes 6 with its MDI pairs swapped.
+		24, HWP_NONE, HWP_NONE, HWP_NONE, 0, 0, 0, 6, byte(HWP_ETHER), byte(HWP_XGE), byte(HWP_FIBER), 0, HWP_NONE, 1, byte(SINGLE_SET), 0x9,
+	}))
+	sd := &Serdes{}
+	if err := sd.Read(r); err != nil || sd.Id != 6 || sd.Mode != RTK_MII_10GR || sd.RxPolarity != SERDES_POLARITY_CHANGE {
+		t.Errorf("unexpected serdes %v: %v", sd, err)
+	}
+	sc := &SerdesConverter{}
+	if err := sc.Read(r); err != nil || sc.Chip != 0x8295 || sc.PhyAddr != 4 || sc.TxPolarity != SERDES_POLARITY_CHANGE {
+		t.Errorf("unexpected converter %v: %v", sc, err)
+	}
+	p := &Port{}
+	if err := p.Read(r); err != nil || p.MacId != 24 || p.SdsIdx != 6 || !p.PhyMdiPinSwap || p.PhyMdiPairSwap != 0x9 {
+		t.Errorf("unexpected port %v: %v", p, err)
+	}
+	if err := (&Leds{}).Read(r); err != io.EOF {
+		t.Errorf("expected io.EOF at the end of the input, got %v", err)
+	}
+	var pe *ParseError
+	if err := (&Port{}).Read(bufio.NewReader(bytes.NewReader([]byte{24, 0}))); !errors.As(err, &pe) {
+		t.Errorf("expected a parse error on a truncated port, got %v", err)
+	}
+}
+
+func TestPlausibilityByteOrder(t *testing.T) {
+	// RTL931x have a big endian CPU, but the SDK may run on a little endian
+	// host: the byte order of the chip does not favor a layout.
+	sw := validSwitch()
+	sw.ChipId = RTL9313_CHIP_ID
+	if be, le := plausibility(sw, SDK3_BE), plausibility(sw, SDK3_LE); be != le {
+		t.Errorf("scores differ: %d as %s, %d as %s", be, SDK3_BE, le, SDK3_LE)
+	}
+}
+
+func TestDetectLayoutGarbage(t *testing.T) {
+	if _, err := DetectLayout(make([]byte, 16)); err == nil {
+		t.Errorf("expected an error on a truncated descriptor")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	data := encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})
+
+	// Ports start at 20, each one is 16 bytes long.
+	_, err := (&Switch{}).Decode(bytes.NewReader(data[:100]), SDK3_BE, 0x1000)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if pe.Offset != 0x1064 || pe.Path != "ports[5].mac_id" || !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected parse error: %v", err)
+	}
+	_, err = (&Switch{}).Decode(bytes.NewReader(data[:106]), SDK3_BE, 0)
+	if !errors.As(err, &pe) || pe.Offset != 104 || pe.Path != "ports[5].sds_idx" || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("unexpected parse error: %v", err)
+	}
+
+	// The serdes table follows the ports and their 1 byte count, entries
+	// are 2 bytes long.
+	const mode = 20 + 64*16 + 1 + 1
+	data[mode] = 0xfc
+	data[mode+2] = 0xfc // after the end marker
+	warnings, err := (&Switch{}).Decode(bytes.NewReader(data), SDK3_BE, 0x1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Warning{{Offset: 0x1000 + mode, Path: "serdes[0].mode", Message: "unknown MII mode 63"}}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("expected warnings %v, got %v", want, warnings)
+	}
+}
+
+func TestDecodeErrorAllocs(t *testing.T) {
+	data := encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})
+	for _, n := range []int{0, 100, len(data) - 1} {
+		sw := &Switch{}
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := sw.DecodeBytes(data[:n], SDK3_BE, 0); err == nil {
+				t.Fatalf("%d bytes: expected an error", n)
+			}
+		})
+		// The ParseError and its path.
+		if allocs > 2 {
+			t.Errorf("%d bytes: %v allocations on failure, want at most 2", n, allocs)
+		}
+	}
+}
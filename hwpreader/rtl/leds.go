@@ -4,9 +4,7 @@ package rtl
 
 import (
 	"bufio"
-	"encoding/binary"
 	"fmt"
-	"log"
 )
 
 type LedIfSel uint32
@@ -32,26 +30,59 @@ func (l LedIfSel) String() string {
 	return fmt.Sprintf("UNKNOWN (%d)", l)
 }
 
+// LedActive is the level lighting the LEDs of the switch.
+type LedActive uint32
+
+const (
+	LED_ACTIVE_LOW LedActive = iota
+	LED_ACTIVE_HIGH
+)
+
+func (a LedActive) String() string {
+	switch a {
+	case LED_ACTIVE_LOW:
+		return "LOW"
+	case LED_ACTIVE_HIGH:
+		return "HIGH"
+	}
+	return fmt.Sprintf("UNKNOWN (%d)", a)
+}
+
 type Leds struct {
+	// Active is the led_active field of the profile sources, HasActive
+	// being set when they give it. The binary descriptors do not carry it:
+	// their LEDs are taken as active low.
+	Active    LedActive
+	HasActive bool
 	LedIfSel
 	LedSet [RTK_MAX_LED_MOD]struct {
-		Led [RTK_MAX_LED_PER_PORT]uint32
+		Led [RTK_MAX_LED_PER_PORT]LedWord
 	}
 }
 
-func (l *Leds) Read(r *bufio.Reader) error {
-	if err := binary.Read(r, binary.BigEndian, &l.LedIfSel); err != nil {
-		return err
+// Names of the LED fields of a LED set, not to format them for each read.
+var ledFields [RTK_MAX_LED_PER_PORT]string
+
+func init() {
+	for j := range ledFields {
+		ledFields[j] = fmt.Sprintf("led[%d]", j)
+	}
+}
+
+func (l *Leds) decode(d *decoder) {
+	l.LedIfSel = LedIfSel(d.u32("led_if_sel"))
+	if l.LedIfSel > LED_IF_SEL_BI_COLOR_SCAN {
+		d.warn("led_if_sel", "unknown LED interface %d", uint32(l.LedIfSel))
 	}
 	for i := range RTK_MAX_LED_MOD {
+		d.entry, d.index = "leds.led_definition_set", i
 		for j := range RTK_MAX_LED_PER_PORT {
-			var val uint32
-			if err := binary.Read(r, binary.BigEndian, &val); err != nil {
-				return err
-			}
-			log.Printf("[%d][%d]=0x%x", i, j, val)
-			l.LedSet[i].Led[j] = val
+			l.LedSet[i].Led[j] = LedWord(d.u32(ledFields[j]))
 		}
 	}
-	return nil
+}
+
+// Read decodes the LED definitions laid out as SDK3_BE from r.
+func (l *Leds) Read(r *bufio.Reader) error {
+	return readEntry(r, ledsSize, l.decode)
 }
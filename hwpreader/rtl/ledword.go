@@ -0,0 +1,183 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LedWord is a LED definition (led_definition_set[i].led[j]). On RTL930x and
+// RTL931x it is the 16 bit selector the SDK writes for the LED to the
+// LED_SET<i>_<j> fields of the LED_SET_CTRL registers, as the led_set<i>
+// cells of the rtl9300-leds node are in the rtl930x driver of the OpenWrt
+// realtek target. The LED follows the link and activity of its port when the
+// port runs at one of the selected speeds. The bits are the RTL93XX_LED_SET_*
+// values of target/linux/realtek/dts/macros.dtsi in OpenWrt:
+//
+//	bit  0: 10G     bit  6: 500M    bit  9: link
+//	bit  1: 5G      bit  7: 100M    bit 10: link flash
+//	bit  3: 2.5G    bit  8: 10M     bit 11: activity
+//	bit  5: 1G
+//
+// 0xffff (RTL93XX_LED_SET_NONE) leaves the LED unused. The polarity of the
+// LEDs is not part of the word: it is global to the switch, given by the
+// led_active field of the profile and the active-low property in device
+// trees. The word has no blink rate either.
+type LedWord uint32
+
+// LedCond is the set of speeds and conditions of a LED definition.
+type LedCond uint16
+
+const (
+	LED_10G        LedCond = 1 << 0
+	LED_5G         LedCond = 1 << 1
+	LED_2_5G       LedCond = 1 << 3
+	LED_1G         LedCond = 1 << 5
+	LED_500M       LedCond = 1 << 6
+	LED_100M       LedCond = 1 << 7
+	LED_10M        LedCond = 1 << 8
+	LED_LINK       LedCond = 1 << 9
+	LED_LINK_FLASH LedCond = 1 << 10
+	LED_ACT        LedCond = 1 << 11
+
+	// LED_ALL_SPEEDS selects every speed.
+	LED_ALL_SPEEDS = LED_10G | LED_5G | LED_2_5G | LED_1G | LED_500M | LED_100M | LED_10M
+	ledModes       = LED_LINK | LED_LINK_FLASH | LED_ACT
+	ledCondMask    = LED_ALL_SPEEDS | ledModes
+)
+
+// LED_UNUSED is the definition of a LED left unused.
+const LED_UNUSED LedWord = 0xffff
+
+// Speed names, from the lowest speed.
+var ledSpeeds = []struct {
+	cond LedCond
+	name string
+}{
+	{LED_10M, "10M"},
+	{LED_100M, "100M"},
+	{LED_500M, "500M"},
+	{LED_1G, "1G"},
+	{LED_2_5G, "2.5G"},
+	{LED_5G, "5G"},
+	{LED_10G, "10G"},
+}
+
+// Names of the conditions, in the order they are described.
+var ledModeNames = []struct {
+	cond LedCond
+	name string
+}{
+	{LED_LINK, "link"},
+	{LED_LINK_FLASH, "link-flash"},
+	{LED_ACT, "act"},
+}
+
+// Conditions returns the speeds and conditions of the LED.
+func (w LedWord) Conditions() LedCond {
+	return LedCond(w) & ledCondMask
+}
+
+// Speeds returns the speeds at which the LED follows its port, from the
+// lowest.
+func (w LedWord) Speeds() []string {
+	var speeds []string
+	for _, s := range ledSpeeds {
+		if w.Conditions()&s.cond != 0 {
+			speeds = append(speeds, s.name)
+		}
+	}
+	return speeds
+}
+
+// Off returns true if the LED is never lit: unused, or without speed or
+// condition.
+func (w LedWord) Off() bool {
+	c := w.Conditions()
+	return w == LED_UNUSED || c&LED_ALL_SPEEDS == 0 || c&ledModes == 0
+}
+
+// String describes the LED definition in the syntax understood by
+// ParseLedWord, e.g. "link act speed(1G,10G)".
+func (w LedWord) String() string {
+	switch w {
+	case 0:
+		return "off"
+	case LED_UNUSED:
+		return "unused"
+	}
+	var parts []string
+	for _, m := range ledModeNames {
+		if w.Conditions()&m.cond != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	if speeds := w.Speeds(); len(speeds) > 0 {
+		parts = append(parts, "speed("+strings.Join(speeds, ",")+")")
+	}
+	if unknown := w &^ LedWord(ledCondMask); unknown != 0 {
+		parts = append(parts, fmt.Sprintf("unknown(0x%x)", uint32(unknown)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseLedWord encodes a LED definition from its description. The description
+// is a space separated list of terms: link, link-flash, act and
+// speed(speeds...). Speeds are 10M, 100M, 500M, 1G, 2.5G, 5G, 10G or all.
+// "off" describes a LED that is never lit and "unused" a LED left unused.
+func ParseLedWord(desc string) (LedWord, error) {
+	var w LedWord
+	for _, term := range strings.Fields(desc) {
+		name, args, hasArgs := strings.Cut(term, "(")
+		if hasArgs {
+			if !strings.HasSuffix(args, ")") {
+				return 0, fmt.Errorf("missing ')' in %q", term)
+			}
+			args = strings.TrimSuffix(args, ")")
+		}
+		switch name {
+		case "off":
+		case "unused":
+			if len(strings.Fields(desc)) > 1 {
+				return 0, fmt.Errorf("unused LED with other terms in %q", desc)
+			}
+			return LED_UNUSED, nil
+		case "speed":
+			if !hasArgs {
+				return 0, fmt.Errorf("missing speeds in %q", term)
+			}
+			for _, speed := range strings.Split(args, ",") {
+				cond, err := ledSpeed(speed)
+				if err != nil {
+					return 0, err
+				}
+				w |= LedWord(cond)
+			}
+		default:
+			found := false
+			for _, m := range ledModeNames {
+				if m.name == term {
+					w |= LedWord(m.cond)
+					found = true
+				}
+			}
+			if !found {
+				return 0, fmt.Errorf("unknown LED term %q", term)
+			}
+		}
+	}
+	return w, nil
+}
+
+func ledSpeed(speed string) (LedCond, error) {
+	if speed == "all" {
+		return LED_ALL_SPEEDS, nil
+	}
+	for _, s := range ledSpeeds {
+		if strings.EqualFold(s.name, speed) {
+			return s.cond, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown speed %q", speed)
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import "testing"
+
+func TestLedWord(t *testing.T) {
+	tests := []struct {
+		word LedWord
+		desc string
+		off  bool
+	}{
+		{0, "off", true},
+		{LED_UNUSED, "unused", true},
+		{0x0a20, "link act speed(1G)", false},
+		{0x0b80, "link act speed(10M,100M)", false},
+		{0x0a0b, "link act speed(2.5G,5G,10G)", false},
+		{LedWord(LED_LINK_FLASH | LED_500M), "link-flash speed(500M)", false},
+		{LedWord(LED_LINK), "link", true},
+		{LedWord(LED_10G), "speed(10G)", true},
+		{LedWord(LED_ACT|LED_10G) | 1<<4 | 1<<20, "act speed(10G) unknown(0x100010)", false},
+	}
+	for _, test := range tests {
+		if got := test.word.String(); got != test.desc {
+			t.Errorf("0x%x: got %q, want %q", uint32(test.word), got, test.desc)
+		}
+		if got := test.word.Off(); got != test.off {
+			t.Errorf("0x%x: got off %v, want %v", uint32(test.word), got, test.off)
+		}
+		if test.word != LED_UNUSED && test.word&^LedWord(ledCondMask) != 0 {
+			continue
+		}
+		w, err := ParseLedWord(test.desc)
+		if err != nil {
+			t.Errorf("%q: %v", test.desc, err)
+		} else if w != test.word {
+			t.Errorf("%q: got 0x%x, want 0x%x", test.desc, uint32(w), uint32(test.word))
+		}
+	}
+}
+
+func TestParseLedWord(t *testing.T) {
+	w, err := ParseLedWord("link act speed(all)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := LedWord(0xbeb); w != want {
+		t.Errorf("got 0x%x, want 0x%x", uint32(w), uint32(want))
+	}
+	for _, desc := range []string{"speed(1G", "speed(3G)", "speed()", "speed", "blink(32ms)", "unused link", "blue"} {
+		if _, err := ParseLedWord(desc); err == nil {
+			t.Errorf("%q: expected an error", desc)
+		}
+	}
+}
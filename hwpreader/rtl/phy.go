@@ -2,7 +2,10 @@
 
 package rtl
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type PhyChipId uint32
 
@@ -153,6 +156,66 @@ type Phy struct {
 	Pad1   uint8
 }
 
+// decode reads a PHY entry. Unknown chips are kept as is, with a warning.
+func (p *Phy) decode(d *decoder) {
+	p.Chip = PhyChipId(d.u32("chip"))
+	if p.Chip >= RTK_PHYTYPE_UNKNOWN {
+		d.warn("chip", "unknown PHY chip %d", uint32(p.Chip))
+	}
+	p.PhyMax = d.u8("phy_max")
+	p.MacId = d.u8("mac_id")
+	p.Pad0 = d.u8("pad")
+	p.Pad1 = d.u8("pad")
+}
+
 func (p *Phy) String() string {
 	return fmt.Sprintf("Phy{chip: %s, mac_id: %2d, phy_max: %2d}", p.Chip, p.MacId, p.PhyMax)
 }
+
+// PhySerdes returns for each PHY of sw the indexes of the serdes feeding it,
+// those designated by the sds_idx of its ports. The PHYs whose ports give
+// none, as the SDK descriptors do for their PHY ports, are fed by the serdes
+// no port designates: by increasing MAC id, each PHY takes in the order of
+// the serdes table as many of them as its ports need. Disabled serdes and
+// serdes of unknown capacity feed no PHY this way.
+func (sw *Switch) PhySerdes() [][]int {
+	serdes := make([][]int, len(sw.Phys))
+	used := make([]bool, len(sw.Serdes))
+	for _, p := range sw.Ports {
+		idx, _ := sw.PortSerdes(p)
+		for _, k := range idx {
+			used[k] = true
+			if p.Attr&HWP_CPU != 0 || int(p.PhyIdx) >= len(sw.Phys) {
+				continue
+			}
+			known := false
+			for _, j := range serdes[p.PhyIdx] {
+				known = known || j == k
+			}
+			if !known {
+				serdes[p.PhyIdx] = append(serdes[p.PhyIdx], k)
+			}
+		}
+	}
+	var free []int
+	for k, sd := range sw.Serdes {
+		if c, ok := sd.Mode.Capacity(); ok && c.Ports > 0 && !used[k] {
+			free = append(free, k)
+		}
+	}
+	var phys []int
+	for i := range sw.Phys {
+		if len(serdes[i]) == 0 {
+			phys = append(phys, i)
+		}
+	}
+	sort.SliceStable(phys, func(a, b int) bool { return sw.Phys[phys[a]].MacId < sw.Phys[phys[b]].MacId })
+	for _, i := range phys {
+		for n := 0; n < int(sw.Phys[i].PhyMax) && len(free) > 0; free = free[1:] {
+			c, _ := sw.Serdes[free[0]].Mode.Capacity()
+			serdes[i] = append(serdes[i], free[0])
+			n += c.Ports
+		}
+	}
+	return serdes
+}
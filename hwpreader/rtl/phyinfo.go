@@ -0,0 +1,108 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+// PhyInfo describes the capabilities of a PHY chip.
+type PhyInfo struct {
+	Chip PhyChipId
+	// Ports is the number of ports of the package, each with its own MDIO
+	// address.
+	Ports int
+	// Speeds are the ethernet types the PHY can link at.
+	Speeds []EthType
+	// HostModes are the serdes modes the PHY accepts on its host side.
+	HostModes []SerdesMode
+	// Clause45 is set for PHYs only reachable through clause 45 MDIO
+	// accesses.
+	Clause45 bool
+}
+
+// SupportsSpeed returns true if the PHY can link at the ethernet type e.
+func (pi *PhyInfo) SupportsSpeed(e EthType) bool {
+	for _, speed := range pi.Speeds {
+		if speed == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsHost returns true if the PHY can be fed by a serdes in mode m.
+func (pi *PhyInfo) SupportsHost(m SerdesMode) bool {
+	for _, mode := range pi.HostModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	feSpeeds  = []EthType{HWP_FE}
+	geSpeeds  = []EthType{HWP_FE, HWP_GE}
+	g25Speeds = []EthType{HWP_FE, HWP_GE, HWP_2_5GE}
+	g5Speeds  = []EthType{HWP_FE, HWP_GE, HWP_2_5GE, HWP_5GE}
+	xgSpeeds  = []EthType{HWP_FE, HWP_GE, HWP_2_5GE, HWP_5GE, HWP_XGE}
+)
+
+// phyInfos is the database of the known PHY chips. Serdes pseudo PHYs and
+// customer PHYs have no entry.
+var phyInfos = map[PhyChipId]*PhyInfo{}
+
+func init() {
+	for _, p := range []PhyInfo{
+		{RTK_PHYTYPE_RTL8208D, 8, feSpeeds, []SerdesMode{RTK_MII_XSMII, RTK_MII_SSSMII}, false},
+		{RTK_PHYTYPE_RTL8208G, 8, feSpeeds, []SerdesMode{RTK_MII_XSMII, RTK_MII_SSSMII}, false},
+		{RTK_PHYTYPE_RTL8208L, 8, feSpeeds, []SerdesMode{RTK_MII_XSMII, RTK_MII_SSSMII}, false},
+		{RTK_PHYTYPE_RTL8208L_INT, 8, feSpeeds, []SerdesMode{RTK_MII_XSMII, RTK_MII_SSSMII}, false},
+		{RTK_PHYTYPE_RTL8212B, 2, geSpeeds, []SerdesMode{RTK_MII_SGMII}, false},
+		{RTK_PHYTYPE_RTL8214FB, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8214B, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8214FC, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8214C, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8214QF, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8214QF_NC5, 4, geSpeeds, []SerdesMode{RTK_MII_QSGMII}, false},
+		{RTK_PHYTYPE_RTL8218B, 8, geSpeeds, []SerdesMode{RTK_MII_QSGMII, RTK_MII_RSGMII, RTK_MII_RSGMII_PLUS}, false},
+		{RTK_PHYTYPE_RTL8218FB, 8, geSpeeds, []SerdesMode{RTK_MII_QSGMII, RTK_MII_RSGMII, RTK_MII_RSGMII_PLUS}, false},
+		{RTK_PHYTYPE_RTL8218D, 8, geSpeeds, []SerdesMode{RTK_MII_QSGMII, RTK_MII_XSGMII}, false},
+		{RTK_PHYTYPE_RTL8218D_NMP, 8, geSpeeds, []SerdesMode{RTK_MII_QSGMII, RTK_MII_XSGMII}, false},
+		{RTK_PHYTYPE_RTL8218E, 8, geSpeeds, []SerdesMode{RTK_MII_QSGMII, RTK_MII_XSGMII, RTK_MII_USXGMII_10GQXGMII}, false},
+		{RTK_PHYTYPE_RTL8295R, 1, []EthType{HWP_GE, HWP_XGE}, []SerdesMode{RTK_MII_10GR, RTK_MII_XAUI, RTK_MII_RXAUI}, true},
+		{RTK_PHYTYPE_RTL8295R_C22, 1, []EthType{HWP_GE, HWP_XGE}, []SerdesMode{RTK_MII_10GR, RTK_MII_XAUI, RTK_MII_RXAUI}, false},
+		{RTK_PHYTYPE_RTL8224QF, 4, g25Speeds, []SerdesMode{RTK_MII_USXGMII_10GQXGMII}, false},
+		{RTK_PHYTYPE_RTL8226, 1, g25Speeds, []SerdesMode{RTK_MII_HISGMII, RTK_MII_2500Base_X, RTK_MII_SGMII}, true},
+		{RTK_PHYTYPE_RTL8226B, 1, g25Speeds, []SerdesMode{RTK_MII_HISGMII, RTK_MII_2500Base_X, RTK_MII_SGMII}, true},
+		{RTK_PHYTYPE_RTL8224, 4, g25Speeds, []SerdesMode{RTK_MII_USXGMII_10GQXGMII}, true},
+		{RTK_PHYTYPE_RTL8251, 1, g5Speeds, []SerdesMode{RTK_MII_USXGMII_5GSXGMII, RTK_MII_5GBASEX, RTK_MII_HISGMII_5G}, true},
+		{RTK_PHYTYPE_RTL8251I, 1, g5Speeds, []SerdesMode{RTK_MII_USXGMII_5GSXGMII, RTK_MII_5GBASEX, RTK_MII_HISGMII_5G}, true},
+		{RTK_PHYTYPE_RTL8251L, 1, g5Speeds, []SerdesMode{RTK_MII_USXGMII_5GSXGMII, RTK_MII_5GBASEX, RTK_MII_HISGMII_5G}, true},
+		{RTK_PHYTYPE_RTL8254, 4, g5Speeds, []SerdesMode{RTK_MII_USXGMII_10GQXGMII}, true},
+		{RTK_PHYTYPE_RTL8254I, 4, g5Speeds, []SerdesMode{RTK_MII_USXGMII_10GQXGMII}, true},
+		{RTK_PHYTYPE_RTL8254L, 4, g5Speeds, []SerdesMode{RTK_MII_USXGMII_10GQXGMII}, true},
+		{RTK_PHYTYPE_RTL8261, 1, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_RTL8261I, 1, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_RTL8261B, 1, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_RTL8264, 4, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_RTL8264I, 4, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_RTL8264B, 4, xgSpeeds, []SerdesMode{RTK_MII_USXGMII_10GSXGMII, RTK_MII_10GR}, true},
+		{RTK_PHYTYPE_EXP_RTL8211FS, 1, geSpeeds, []SerdesMode{RTK_MII_SGMII, RTK_MII_1000BX_FIBER}, false},
+	} {
+		phyInfos[p.Chip] = &p
+	}
+}
+
+// IsConverter returns true if the chip is a serdes converter, described by the
+// converter table rather than the PHY table.
+func (pci PhyChipId) IsConverter() bool {
+	switch pci {
+	case RTK_PHYTYPE_RTL8295R, RTK_PHYTYPE_RTL8295R_C22, RTK_PHYTYPE_RTL8214QF, RTK_PHYTYPE_RTL8214QF_NC5:
+		return true
+	}
+	return false
+}
+
+// Info returns the capabilities of the PHY chip, false if it is unknown.
+func (pci PhyChipId) Info() (*PhyInfo, bool) {
+	info, ok := phyInfos[pci]
+	return info, ok
+}
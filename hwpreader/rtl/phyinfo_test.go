@@ -0,0 +1,44 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"testing"
+)
+
+func TestPhyInfo(t *testing.T) {
+	tests := []struct {
+		chip     PhyChipId
+		ports    int
+		clause45 bool
+	}{
+		{RTK_PHYTYPE_RTL8218D, 8, false},
+		{RTK_PHYTYPE_RTL8214FC, 4, false},
+		{RTK_PHYTYPE_RTL8226, 1, true},
+		{RTK_PHYTYPE_RTL8224, 4, true},
+		{RTK_PHYTYPE_RTL8295R_C22, 1, false},
+	}
+	for _, tt := range tests {
+		info, ok := tt.chip.Info()
+		if !ok || info.Chip != tt.chip || info.Ports != tt.ports || info.Clause45 != tt.clause45 {
+			t.Errorf("%s: got %+v, %v", tt.chip, info, ok)
+		}
+	}
+	for _, chip := range []PhyChipId{RTK_PHYTYPE_NONE, RTK_PHYTYPE_SERDES, RTK_PHYTYPE_CUST1, RTK_PHYTYPE_UNKNOWN} {
+		if info, ok := chip.Info(); info != nil || ok {
+			t.Errorf("%s: unexpected info %+v", chip, info)
+		}
+	}
+
+	if !RTK_PHYTYPE_RTL8295R.IsConverter() || !RTK_PHYTYPE_RTL8214QF.IsConverter() || RTK_PHYTYPE_RTL8214FC.IsConverter() {
+		t.Errorf("unexpected serdes converter chips")
+	}
+
+	info, _ := RTK_PHYTYPE_RTL8218D.Info()
+	if !info.SupportsHost(RTK_MII_QSGMII) || info.SupportsHost(RTK_MII_10GR) {
+		t.Errorf("unexpected RTL8218D host modes: %v", info.HostModes)
+	}
+	if !info.SupportsSpeed(HWP_GE) || info.SupportsSpeed(HWP_2_5GE) {
+		t.Errorf("unexpected RTL8218D speeds: %v", info.Speeds)
+	}
+}
@@ -4,75 +4,235 @@ package rtl
 
 import (
 	"bufio"
-	"encoding/binary"
 	"fmt"
+	"math/bits"
+	"strings"
 )
 
-type Port struct {
-	MacId          uint8  // Physical MAC ID
-	PhyIdx         uint8  // phy index number or HWP_NONE
-	Smi            uint8  // which set of SMI interface
-	PhyAddr        uint8  // phy address
-	SdsIdx         uint32 // serdes index number, or HWP_NONE, or index bitmap. To specify a index bitmap: e.g. (SBM(n)|SBM(m))
-	Attr           uint8  // port attribute
-	Eth            uint8  // port ethernet type
-	Medi           uint8  // port medium
-	ScIdx          uint8  // index to serdes converter. this file is meanful only when HWP_SC bit is set in .attr
-	LedC           uint8  // copper port led definition selection
-	LedF           uint8  // fibber port led definition selection
-	LedLayout      uint8  // choose led layout of the combo port
-	PhyMdiPinSwap  bool   // PHY's MDI pins which connects to ICM. 1: Swap the pins(pair ABCD to DCBA); 0: swap is asigned by strap pin.
-	PhyMdiPairSwap uint8  // PHY's MDI pins which connects to ICM. A bitmap, bit[0] for swap pair A polarity; bit[1] for swap pair B polarity; bit[2] for swap pair C polarity; bit[3] for swap pair D polarity;
+// PortAttr is the bitmap of attributes of a port.
+type PortAttr uint8
+
+const (
+	HWP_ETHER   PortAttr = 0x01 // ethernet port
+	HWP_UPLINK  PortAttr = 0x02 // uplink port
+	HWP_CASCADE PortAttr = 0x04 // cascade port to another unit
+	HWP_CPU     PortAttr = 0x08 // CPU port
+	HWP_SC      PortAttr = 0x10 // port behind a serdes converter
+
+	portAttrMask = HWP_ETHER | HWP_UPLINK | HWP_CASCADE | HWP_CPU | HWP_SC
+)
+
+var portAttrNames = []struct {
+	attr PortAttr
+	name string
+}{
+	{HWP_ETHER, "HWP_ETHER"},
+	{HWP_UPLINK, "HWP_UPLINK"},
+	{HWP_CASCADE, "HWP_CASCADE"},
+	{HWP_CPU, "HWP_CPU"},
+	{HWP_SC, "HWP_SC"},
 }
 
-func (p *Port) Read(r *bufio.Reader) error {
-	var err error
-	if p.MacId, err = r.ReadByte(); err != nil {
-		return err
+func (a PortAttr) String() string {
+	if a == 0 {
+		return "0"
 	}
-	if p.PhyIdx, err = r.ReadByte(); err != nil {
-		return err
+	var names []string
+	for _, n := range portAttrNames {
+		if a&n.attr != 0 {
+			names = append(names, n.name)
+		}
 	}
-	if p.Smi, err = r.ReadByte(); err != nil {
-		return err
+	if unknown := a &^ portAttrMask; unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint8(unknown)))
 	}
-	if p.PhyAddr, err = r.ReadByte(); err != nil {
-		return err
+	return strings.Join(names, "|")
+}
+
+// EthType is the ethernet speed class of a port.
+type EthType uint8
+
+const (
+	HWP_FE EthType = iota
+	HWP_GE
+	HWP_2_5GE
+	HWP_5GE
+	HWP_XGE
+	HWP_ETH_END
+)
+
+func (e EthType) String() string {
+	switch e {
+	case HWP_FE:
+		return "HWP_FE"
+	case HWP_GE:
+		return "HWP_GE"
+	case HWP_2_5GE:
+		return "HWP_2_5GE"
+	case HWP_5GE:
+		return "HWP_5GE"
+	case HWP_XGE:
+		return "HWP_XGE"
+	case HWP_NONE:
+		return "HWP_NONE"
 	}
-	if err := binary.Read(r, binary.BigEndian, &p.SdsIdx); err != nil {
-		return err
+	return fmt.Sprintf("HWP_ETH_UNKNOWN (%d)", uint8(e))
+}
+
+// Medium is the physical medium of a port.
+type Medium uint8
+
+const (
+	HWP_COPPER Medium = iota
+	HWP_FIBER
+	HWP_COMBO
+	HWP_SERDES
+	HWP_MEDI_END
+)
+
+func (m Medium) String() string {
+	switch m {
+	case HWP_COPPER:
+		return "HWP_COPPER"
+	case HWP_FIBER:
+		return "HWP_FIBER"
+	case HWP_COMBO:
+		return "HWP_COMBO"
+	case HWP_SERDES:
+		return "HWP_SERDES"
+	case HWP_NONE:
+		return "HWP_NONE"
 	}
-	if p.Attr, err = r.ReadByte(); err != nil {
-		return err
+	return fmt.Sprintf("HWP_MEDI_UNKNOWN (%d)", uint8(m))
+}
+
+// LedSel selects one of the LED definition sets, or HWP_NONE.
+type LedSel uint8
+
+func (l LedSel) String() string {
+	if l == HWP_NONE {
+		return "HWP_NONE"
 	}
-	if p.Eth, err = r.ReadByte(); err != nil {
-		return err
+	return fmt.Sprint(uint8(l))
+}
+
+// LedLayout is the LED layout of a combo port.
+type LedLayout uint8
+
+const (
+	SINGLE_SET LedLayout = iota // copper and fiber share the port LEDs
+	DOUBLE_SET                  // copper and fiber have their own LEDs
+	LED_LAYOUT_END
+)
+
+func (l LedLayout) String() string {
+	switch l {
+	case SINGLE_SET:
+		return "SINGLE_SET"
+	case DOUBLE_SET:
+		return "DOUBLE_SET"
+	case HWP_NONE:
+		return "HWP_NONE"
 	}
-	if p.Medi, err = r.ReadByte(); err != nil {
-		return err
+	return fmt.Sprintf("LED_LAYOUT_UNKNOWN (%d)", uint8(l))
+}
+
+// Bitfields of the MDI swap byte of the port entries.
+const (
+	pinSwapField  bitfield = 0x8
+	pairSwapField bitfield = 0xf
+)
+
+type Port struct {
+	MacId          uint8     // Physical MAC ID
+	PhyIdx         uint8     // phy index number or HWP_NONE
+	Smi            uint8     // which set of SMI interface
+	PhyAddr        uint8     // phy address
+	SdsIdx         uint32    // serdes index number, or HWP_NONE, or index bitmap. To specify a index bitmap: e.g. (SBM(n)|SBM(m))
+	Attr           PortAttr  // port attribute
+	Eth            EthType   // port ethernet type
+	Medi           Medium    // port medium
+	ScIdx          uint8     // index to serdes converter. this file is meanful only when HWP_SC bit is set in .attr
+	LedC           LedSel    // copper port led definition selection
+	LedF           LedSel    // fibber port led definition selection
+	LedLayout      LedLayout // choose led layout of the combo port
+	PhyMdiPinSwap  bool      // PHY's MDI pins which connects to ICM. 1: Swap the pins(pair ABCD to DCBA); 0: swap is asigned by strap pin.
+	PhyMdiPairSwap uint8     // PHY's MDI pins which connects to ICM. A bitmap, bit[0] for swap pair A polarity; bit[1] for swap pair B polarity; bit[2] for swap pair C polarity; bit[3] for swap pair D polarity;
+}
+
+func (p *Port) decode(d *decoder) {
+	p.MacId = d.u8("mac_id")
+	p.PhyIdx = d.u8("phy_idx")
+	p.Smi = d.u8("smi")
+	p.PhyAddr = d.u8("phy_addr")
+	p.SdsIdx = d.u32("sds_idx")
+	p.Attr = PortAttr(d.u8("attr"))
+	p.Eth = EthType(d.u8("eth"))
+	if p.Eth >= HWP_ETH_END && p.Eth != HWP_NONE {
+		d.warn("eth", "unknown ethernet type %d", p.Eth)
 	}
-	if p.ScIdx, err = r.ReadByte(); err != nil {
-		return err
+	p.Medi = Medium(d.u8("medi"))
+	if p.Medi >= HWP_MEDI_END && p.Medi != HWP_NONE {
+		d.warn("medi", "unknown medium %d", p.Medi)
 	}
-	if p.LedC, err = r.ReadByte(); err != nil {
-		return err
+	p.ScIdx = d.u8("sc_idx")
+	p.LedC = LedSel(d.u8("led_c"))
+	p.LedF = LedSel(d.u8("led_f"))
+	p.LedLayout = LedLayout(d.u8("led_layout"))
+	swap := d.u8("phy_mdi_pin_swap")
+	p.PhyMdiPinSwap = d.unpack(swap, pinSwapField, "phy_mdi_pin_swap") != 0
+	p.PhyMdiPairSwap = d.unpack(swap, pairSwapField, "phy_mdi_pair_swap")
+}
+
+// Read decodes a port entry laid out as SDK3_BE from r.
+func (p *Port) Read(r *bufio.Reader) error {
+	return readEntry(r, portSize, p.decode)
+}
+
+// PortSerdes returns the indexes in sw.Serdes of the serdes designated by
+// the sds_idx of a port, none for HWP_NONE. A port fed by several serdes gives
+// them as a bitmap, SBM(n)|SBM(m): the values within the serdes table are
+// indexes, the others with several bits set are bitmaps. An error reports a
+// serdes out of the table.
+func (sw *Switch) PortSerdes(p *Port) ([]int, error) {
+	switch {
+	case p.SdsIdx == HWP_NONE:
+		return nil, nil
+	case p.SdsIdx < uint32(len(sw.Serdes)):
+		return []int{int(p.SdsIdx)}, nil
+	case bits.OnesCount32(p.SdsIdx) < 2:
+		return nil, fmt.Errorf("serdes index %d is out of range (%d serdes)", p.SdsIdx, len(sw.Serdes))
+	case bits.Len32(p.SdsIdx) > len(sw.Serdes):
+		return nil, fmt.Errorf("serdes bitmap 0x%x is out of range (%d serdes)", p.SdsIdx, len(sw.Serdes))
+	}
+	var serdes []int
+	for m := p.SdsIdx; m != 0; m &= m - 1 {
+		serdes = append(serdes, bits.TrailingZeros32(m))
 	}
-	if p.LedF, err = r.ReadByte(); err != nil {
-		return err
+	return serdes, nil
+}
+
+// PortLanes returns the indexes of the serdes carrying the traffic of a port:
+// those of its sds_idx or, for a port behind a PHY without one, one of the
+// serdes feeding the PHY as resolved by PhySerdes, the ports of the PHY being
+// spread over them by MAC id. An error reports a serdes out of the table.
+func (sw *Switch) PortLanes(p *Port) ([]int, error) {
+	idx, err := sw.PortSerdes(p)
+	if err != nil || len(idx) > 0 || p.Attr&HWP_CPU != 0 || int(p.PhyIdx) >= len(sw.Phys) {
+		return idx, err
 	}
-	if p.LedLayout, err = r.ReadByte(); err != nil {
-		return err
+	lanes := sw.PhySerdes()[p.PhyIdx]
+	if len(lanes) == 0 {
+		return nil, nil
 	}
-	b, err := r.ReadByte()
-	if err != nil {
-		return err
+	k := 0
+	if c, _ := sw.Serdes[lanes[0]].Mode.Capacity(); p.MacId > sw.Phys[p.PhyIdx].MacId {
+		k = min(int(p.MacId-sw.Phys[p.PhyIdx].MacId)/c.Ports, len(lanes)-1)
 	}
-	p.PhyMdiPinSwap = (b & 0x8) != 0
-	p.PhyMdiPairSwap = uint8(b & 0xf)
-	return nil
+	return []int{lanes[k]}, nil
 }
 
 func (p *Port) String() string {
-	return fmt.Sprintf("Port{mac_id: %2d, phy_idx: %v, smi: %v, phy_addr: %v, sds_idx: %v, attr: %x, eth: %v, medi: %v, sc_idx: %v, led_c: %v, led_f: %v, led_layout: %v, phy_mdi_pin_swap: %v, phy_mdi_pair_swap: %v}",
+	return fmt.Sprintf("Port{mac_id: %2d, phy_idx: %v, smi: %v, phy_addr: %v, sds_idx: %v, attr: %v, eth: %v, medi: %v, sc_idx: %v, led_c: %v, led_f: %v, led_layout: %v, phy_mdi_pin_swap: %v, phy_mdi_pair_swap: %v}",
 		p.MacId, p.PhyIdx, p.Smi, p.PhyAddr, p.SdsIdx, p.Attr, p.Eth, p.Medi, p.ScIdx, p.LedC, p.LedF, p.LedLayout, p.PhyMdiPinSwap, p.PhyMdiPairSwap)
 }
@@ -0,0 +1,229 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Maximum length of a profile name, including the terminating NUL.
+const maxProfileNameLen = 128
+
+// ProfileSize is the size of a hardware profile on 32 bits targets.
+const ProfileSize = 5*4 + (RTK_MAX_NUM_OF_UNIT_LOCAL+1)*4
+
+// Memory gives access to the image a hardware profile is decoded from. Its
+// pointers are virtual addresses: the offset of ReadAt is an address.
+type Memory interface {
+	io.ReaderAt
+}
+
+// Image is the Memory of a raw image loaded at Base: the content of the
+// address addr is at offset addr - Base in the image.
+type Image struct {
+	R    io.ReaderAt
+	Base uint32
+}
+
+func (im *Image) ReadAt(p []byte, addr int64) (int, error) {
+	if addr < int64(im.Base) {
+		return 0, fmt.Errorf("address 0x%x below image base 0x%x", addr, im.Base)
+	}
+	return im.R.ReadAt(p, addr-int64(im.Base))
+}
+
+// Identifier identifies a hardware profile (hwp_identifier_t).
+type Identifier struct {
+	Name string
+	Id   uint32
+}
+
+// Soc describes how the SoC drives the units (hwp_socDescp_t).
+type Soc struct {
+	// SwDescpIndex is the unit embedding the CPU.
+	SwDescpIndex      uint32
+	SlaveInterruptPin uint32
+}
+
+// HwProfile is a complete hardware profile (hwp_hwProfile_t): a board made of
+// one or several switch units. Stacked or cascaded designs use more than one
+// unit.
+type HwProfile struct {
+	Identifier Identifier
+	Soc        Soc
+	Units      []*Switch
+}
+
+// ReadProfile decodes the hardware profile found at the address addr of mem,
+// its switch descriptors being laid out as l. The offsets of the parse errors
+// and warnings are addresses, the paths of the warnings are prefixed by the
+// unit, e.g. units[1].serdes[3].mode. The profile itself is the one of a 32
+// bits target:
+//
+//	identifier.name:        4 bytes pointer to a NUL terminated string
+//	identifier.id:          4 bytes
+//	soc.swDescp_index:      4 bytes
+//	soc.slaveInterruptPin:  4 bytes
+//	sw_count:               4 bytes
+//	swDescp:                RTK_MAX_NUM_OF_UNIT_LOCAL + 1 pointers, NULL terminated
+func ReadProfile(mem Memory, addr uint32, l *Layout) (*HwProfile, []Warning, error) {
+	var raw struct {
+		Name              uint32
+		Id                uint32
+		SwDescpIndex      uint32
+		SlaveInterruptPin uint32
+		SwCount           uint32
+		SwDescp           [RTK_MAX_NUM_OF_UNIT_LOCAL + 1]uint32
+	}
+	r := io.NewSectionReader(mem, int64(addr), int64(binary.Size(raw)))
+	if err := binary.Read(r, l.Order, &raw); err != nil {
+		return nil, nil, &ParseError{Offset: int64(addr), Path: "profile", Err: err}
+	}
+
+	hp := &HwProfile{
+		Identifier: Identifier{Id: raw.Id},
+		Soc:        Soc{SwDescpIndex: raw.SwDescpIndex, SlaveInterruptPin: raw.SlaveInterruptPin},
+	}
+	if raw.Name != 0 {
+		name, err := readString(mem, raw.Name)
+		if err != nil {
+			return nil, nil, &ParseError{Offset: int64(raw.Name), Path: "identifier.name", Err: err}
+		}
+		hp.Identifier.Name = name
+	}
+	var warnings []Warning
+	for i, ptr := range raw.SwDescp {
+		if ptr == 0 {
+			break
+		}
+		prefix := fmt.Sprintf("units[%d].", i)
+		sw := &Switch{}
+		ws, err := sw.DecodeAt(mem, int64(ptr), l)
+		if pe, ok := err.(*ParseError); ok {
+			pe.Path = prefix + pe.Path
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, w := range ws {
+			w.Path = prefix + w.Path
+			warnings = append(warnings, w)
+		}
+		hp.Units = append(hp.Units, sw)
+	}
+	if int(raw.SwCount) != len(hp.Units) {
+		return nil, nil, fmt.Errorf("profile at 0x%x: sw_count is %d but %d units are defined", addr, raw.SwCount, len(hp.Units))
+	}
+	return hp, warnings, nil
+}
+
+// DetectProfile decodes the hardware profile found at the address addr of mem
+// with every known layout and returns the most plausible result, with the
+// warnings of its decoding.
+func DetectProfile(mem Memory, addr uint32) (*HwProfile, *Layout, []Warning, error) {
+	var best *HwProfile
+	var bestLayout *Layout
+	var bestWarnings []Warning
+	bestScore := 0
+	for _, l := range Layouts {
+		hp, warnings, err := ReadProfile(mem, addr, l)
+		if err != nil || len(hp.Units) == 0 {
+			continue
+		}
+		score := 0
+		for _, sw := range hp.Units {
+			score += plausibility(sw, l)
+		}
+		if score /= len(hp.Units); score > bestScore {
+			best, bestLayout, bestWarnings, bestScore = hp, l, warnings, score
+		}
+	}
+	if best == nil {
+		return nil, nil, nil, fmt.Errorf("no layout matches the profile at 0x%x", addr)
+	}
+	return best, bestLayout, bestWarnings, nil
+}
+
+// readString reads the NUL terminated string at addr.
+func readString(mem Memory, addr uint32) (string, error) {
+	buf := make([]byte, maxProfileNameLen)
+	n, err := mem.ReadAt(buf, int64(addr))
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	end := bytes.IndexByte(buf[:n], 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	return string(buf[:end]), nil
+}
+
+// Cascade is a link between cascade ports (HWP_CASCADE) of two units.
+type Cascade struct {
+	Unit     int
+	Port     *Port
+	PeerUnit int
+	Peer     *Port
+}
+
+func (c Cascade) String() string {
+	return fmt.Sprintf("unit %d port %d <-> unit %d port %d", c.Unit, c.Port.MacId, c.PeerUnit, c.Peer.MacId)
+}
+
+// Cascades resolves the links between the units. As in the SDK, the units are
+// chained: the cascade ports of a unit that are not linked to the previous
+// unit are linked, in order, to the first cascade ports of the next unit.
+func (hp *HwProfile) Cascades() ([]Cascade, error) {
+	ports := make([][]*Port, len(hp.Units))
+	for u, sw := range hp.Units {
+		for _, p := range sw.Ports {
+			if p.Attr&HWP_CASCADE != 0 {
+				ports[u] = append(ports[u], p)
+			}
+		}
+	}
+
+	var links []Cascade
+	for u := 0; u+1 < len(ports); u++ {
+		for len(ports[u]) > 0 && len(ports[u+1]) > 0 {
+			links = append(links, Cascade{Unit: u, Port: ports[u][0], PeerUnit: u + 1, Peer: ports[u+1][0]})
+			ports[u], ports[u+1] = ports[u][1:], ports[u+1][1:]
+		}
+		if len(ports[u]) > 0 {
+			return links, fmt.Errorf("unit %d: cascade port %d has no peer", u, ports[u][0].MacId)
+		}
+	}
+	if last := len(ports) - 1; last >= 0 && len(ports[last]) > 0 {
+		return links, fmt.Errorf("unit %d: cascade port %d has no peer", last, ports[last][0].MacId)
+	}
+	return links, nil
+}
+
+// Validate checks every unit of the profile and the links between them. The
+// paths of the findings are prefixed by the unit, e.g. units[1].ports[3].
+func (hp *HwProfile) Validate() []Finding {
+	var findings []Finding
+	if len(hp.Units) == 0 {
+		return []Finding{{Severity: ERROR, Path: "units", Message: "no unit defined"}}
+	}
+	if int(hp.Soc.SwDescpIndex) >= len(hp.Units) {
+		findings = append(findings, Finding{
+			Severity: ERROR,
+			Path:     "soc.swDescp_index",
+			Message:  fmt.Sprintf("unit %d not defined (%d units)", hp.Soc.SwDescpIndex, len(hp.Units)),
+		})
+	}
+	for u, sw := range hp.Units {
+		for _, f := range sw.validate(u != int(hp.Soc.SwDescpIndex)) {
+			f.Path = fmt.Sprintf("units[%d].%s", u, f.Path)
+			findings = append(findings, f)
+		}
+	}
+	if _, err := hp.Cascades(); err != nil {
+		findings = append(findings, Finding{Severity: ERROR, Path: "units", Message: err.Error()})
+	}
+	return findings
+}
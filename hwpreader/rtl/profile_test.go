@@ -0,0 +1,134 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func cascadePort(mac uint8) *Port {
+	return &Port{
+		MacId: mac, PhyIdx: HWP_NONE, Smi: HWP_NONE, PhyAddr: HWP_NONE, SdsIdx: 0,
+		Attr: HWP_CASCADE, Eth: HWP_XGE, Medi: HWP_SERDES, LedC: HWP_NONE, LedF: HWP_NONE, LedLayout: SINGLE_SET,
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	const base = 0x80000000
+	units := [][]byte{
+		encodeSwitch(SDK3_BE, RTL9311_CHIP_ID, cascadePort(52), cascadePort(53)),
+		encodeSwitch(SDK3_BE, RTL9311_CHIP_ID, cascadePort(48), cascadePort(49)),
+	}
+	// Layout: profile at 0x100, name at 0x200, units from 0x400.
+	img := make([]byte, 0x400)
+	copy(img[0x200:], "stacked_board\x00")
+	ptrs := [RTK_MAX_NUM_OF_UNIT_LOCAL + 1]uint32{}
+	for i, u := range units {
+		ptrs[i] = base + uint32(len(img))
+		img = append(img, u...)
+	}
+	var hdr bytes.Buffer
+	binary.Write(&hdr, binary.BigEndian, []uint32{base + 0x200, 0x42, 0, HWP_NONE, uint32(len(units))})
+	binary.Write(&hdr, binary.BigEndian, ptrs)
+	copy(img[0x100:], hdr.Bytes())
+
+	hp, warnings, err := ReadProfile(&Image{R: bytes.NewReader(img), Base: base}, base+0x100, SDK3_BE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if want := (Identifier{Name: "stacked_board", Id: 0x42}); hp.Identifier != want {
+		t.Errorf("identifier: got %+v, want %+v", hp.Identifier, want)
+	}
+	if len(hp.Units) != 2 {
+		t.Fatalf("got %d units, want 2", len(hp.Units))
+	}
+	for i, sw := range hp.Units {
+		if sw.ChipId != RTL9311_CHIP_ID || len(sw.Ports) != 2 {
+			t.Errorf("unit %d: unexpected descriptor %v with %d ports", i, sw.ChipId, len(sw.Ports))
+		}
+	}
+
+	cascades, err := hp.Cascades()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range cascades {
+		got = append(got, c.String())
+	}
+	want := []string{"unit 0 port 52 <-> unit 1 port 48", "unit 0 port 53 <-> unit 1 port 49"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cascades: got %q, want %q", got, want)
+	}
+
+	// Unknown PHY chip in the first entry of the PHY table of unit 1.
+	phy := 0x400 + len(units[0]) + SDK3_BE.Size() - 4 - RTK_MAX_LED_MOD*RTK_MAX_LED_PER_PORT*4 - SDK3_BE.Phys*8
+	img[phy+3] = 0x80
+	_, warnings, err = ReadProfile(&Image{R: bytes.NewReader(img), Base: base}, base+0x100, SDK3_BE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{fmt.Sprintf("offset 0x%x: units[1].phys[0].chip: unknown PHY chip 128", base+phy)}
+	if len(warnings) != 1 || warnings[0].String() != want[0] {
+		t.Errorf("warnings: got %v, want %q", warnings, want)
+	}
+
+	// Truncate unit 1.
+	_, _, err = ReadProfile(&Image{R: bytes.NewReader(img[:0x400+len(units[0])+30]), Base: base}, base+0x100, SDK3_BE)
+	if pe, ok := err.(*ParseError); !ok || pe.Path != "units[1].ports[0].medi" || pe.Offset != int64(base+0x400+len(units[0])+30) {
+		t.Errorf("expected a parse error in the ports of unit 1, got %v", err)
+	}
+
+	// Corrupt sw_count.
+	img[0x100+16+3] = 3
+	if _, _, err := ReadProfile(&Image{R: bytes.NewReader(img), Base: base}, base+0x100, SDK3_BE); err == nil {
+		t.Errorf("expected an error on sw_count mismatch")
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	// The slave has the MAC IDs of a RTL931x for its cascade ports.
+	slave := validSwitch()
+	slave.ChipId = RTL9311_CHIP_ID
+	var ports []*Port
+	for _, p := range slave.Ports {
+		if p.Attr&HWP_CPU == 0 {
+			ports = append(ports, p)
+		}
+	}
+	slave.Ports = ports
+	hp := &HwProfile{Units: []*Switch{validSwitch(), slave}}
+	if findings := hp.Validate(); len(findings) != 0 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+
+	slave.Ports = append(slave.Ports, cascadePort(40))
+	findings := hp.Validate()
+	if len(findings) != 1 || findings[0].Path != "units" || findings[0].Severity != ERROR {
+		t.Errorf("expected an unpaired cascade port error, got %v", findings)
+	}
+
+	// The budget of each unit is checked.
+	slave.Ports = slave.Ports[:len(slave.Ports)-1]
+	slave.Ports[2].Eth = HWP_5GE
+	slave.Serdes[1].Mode = RTK_MII_HISGMII
+	findings = hp.Validate()
+	want := Finding{ERROR, "units[1].ports[2].eth", "HWP_5GE exceeds the 2.5G per port of serdes[1] in mode RTK_MII_HISGMII"}
+	if len(findings) != 1 || findings[0] != want {
+		t.Errorf("expected %v, got %v", want, findings)
+	}
+	slave.Ports[2].Eth = HWP_XGE
+	slave.Serdes[1].Mode = RTK_MII_10GR
+
+	hp.Soc.SwDescpIndex = 2
+	if !HasErrors(hp.Validate()) {
+		t.Errorf("expected an error on soc.swDescp_index")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Candidate is a switch descriptor found by Scan.
+type Candidate struct {
+	// Offset is the offset of the descriptor in the scanned image.
+	Offset int64
+	Layout *Layout
+	Switch *Switch
+	// Score is the plausibility of the descriptor with its layout.
+	Score int
+}
+
+// Descriptors start with a 32 bits chip ID and are aligned accordingly.
+const scanAlign = 4
+
+// Scan looks for switch descriptors in data, a raw image such as a flash dump,
+// at every aligned offset and with every known layout. The offsets are split
+// among workers goroutines, one per CPU if workers is not positive. The
+// candidates are returned in offset order, with their most plausible layout.
+func Scan(data []byte, workers int) []Candidate {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	slots := (len(data) + scanAlign - 1) / scanAlign
+	chunk := (slots + workers - 1) / workers * scanAlign
+	results := make([][]Candidate, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		start, stop := w*chunk, min((w+1)*chunk, len(data))
+		if start >= stop {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[w] = scanRange(data, start, stop)
+		}()
+	}
+	wg.Wait()
+
+	var candidates []Candidate
+	for _, r := range results {
+		candidates = append(candidates, r...)
+	}
+	return candidates
+}
+
+// scanRange looks for the descriptors starting in data[start:stop]. Offsets
+// are rejected on their header without allocating: only the candidates are
+// fully decoded.
+func scanRange(data []byte, start, stop int) []Candidate {
+	var found []Candidate
+	for off := start; off < stop; off += scanAlign {
+		var best Candidate
+		for _, l := range Layouts {
+			if !plausibleHeader(data[off:], l) {
+				continue
+			}
+			sw := &Switch{}
+			sw.decode(newDecoder(data[off:off+l.Size()], l, int64(off)), l)
+			if len(sw.Ports) == 0 {
+				continue
+			}
+			if score := plausibility(sw, l); score > best.Score {
+				best = Candidate{Offset: int64(off), Layout: l, Switch: sw, Score: score}
+			}
+		}
+		if best.Layout != nil {
+			found = append(found, best)
+		}
+	}
+	return found
+}
+
+// plausibleHeader tells from its header only if data may start with a
+// descriptor laid out as l: the chip must be of a known family, the switch
+// core access method known and the supported flags booleans.
+func plausibleHeader(data []byte, l *Layout) bool {
+	if len(data) < l.Size() {
+		return false
+	}
+	if _, ok := families[RtlChipId(l.Order.Uint32(data)).Family()]; !ok {
+		return false
+	}
+	acc := 4 + 1 + l.SwcorePad
+	nic := acc + 4 + 1
+	return data[4] <= 1 && data[nic] <= 1 &&
+		SwitchRegAccMethod(l.Order.Uint32(data[acc:])) < HWP_SW_ACC_END
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// scanImage returns an image of size bytes embedding a SDK3_BE descriptor at
+// 0x1000 and a SDK3_LE one at 0x8004.
+func scanImage(size int) []byte {
+	port := &Port{MacId: 0, PhyIdx: HWP_NONE, Smi: HWP_NONE, PhyAddr: HWP_NONE, SdsIdx: 0, Attr: HWP_ETHER, Eth: HWP_XGE, Medi: HWP_FIBER, LedC: HWP_NONE, LedF: 0}
+	img := make([]byte, size)
+	copy(img[0x1000:], encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, port))
+	copy(img[0x8004:], encodeSwitch(SDK3_LE, RTL9313_CHIP_ID, port))
+	return img
+}
+
+func TestScan(t *testing.T) {
+	img := scanImage(64 << 10)
+	want := []struct {
+		off    int64
+		layout *Layout
+		chip   RtlChipId
+	}{
+		{0x1000, SDK3_BE, RTL9302B_CHIP_ID},
+		{0x8004, SDK3_LE, RTL9313_CHIP_ID},
+	}
+	var first []Candidate
+	for _, workers := range []int{1, 3, 0} {
+		candidates := Scan(img, workers)
+		if len(candidates) != len(want) {
+			t.Fatalf("%d workers: found %d candidates, want %d", workers, len(candidates), len(want))
+		}
+		for i, c := range candidates {
+			if c.Offset != want[i].off || c.Layout != want[i].layout || c.Switch.ChipId != want[i].chip {
+				t.Errorf("%d workers: candidate %d is %s at 0x%x with %s", workers, i, c.Switch.ChipId, c.Offset, c.Layout)
+			}
+		}
+		if first == nil {
+			first = candidates
+		} else if !reflect.DeepEqual(candidates, first) {
+			t.Errorf("%d workers: candidates differ from a single worker", workers)
+		}
+	}
+}
+
+func TestScanRejectAllocs(t *testing.T) {
+	img := make([]byte, 16<<10)
+	for i := range img {
+		img[i] = byte(i * 7)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		scanRange(img, 0, len(img))
+	})
+	if allocs != 0 {
+		t.Errorf("rejecting offsets allocates %v times", allocs)
+	}
+}
+
+func TestDecodeWrappers(t *testing.T) {
+	data := encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})
+	img := append(make([]byte, 0x100), data...)
+
+	a, b, c := &Switch{}, &Switch{}, &Switch{}
+	if _, err := a.DecodeBytes(data, SDK3_BE, 0x100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Decode(bytes.NewReader(data), SDK3_BE, 0x100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.DecodeAt(bytes.NewReader(img), 0x100, SDK3_BE); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) || !reflect.DeepEqual(a, c) {
+		t.Errorf("decoders disagree:\n%v\n%v\n%v", a, b, c)
+	}
+
+	_, err := c.DecodeAt(bytes.NewReader(img[:0x100+100]), 0x100, SDK3_BE)
+	if pe, ok := err.(*ParseError); !ok || pe.Offset != 0x164 || pe.Path != "ports[5].mac_id" {
+		t.Errorf("unexpected parse error: %v", err)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := encodeSwitch(SDK3_BE, RTL9302B_CHIP_ID, &Port{MacId: 0, Eth: HWP_XGE, Medi: HWP_FIBER})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for range b.N {
+			(&Switch{}).DecodeBytes(data, SDK3_BE, 0)
+		}
+	})
+	b.Run("reader", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for range b.N {
+			(&Switch{}).Decode(bytes.NewReader(data), SDK3_BE, 0)
+		}
+	})
+}
+
+func BenchmarkScan(b *testing.B) {
+	img := scanImage(4 << 20)
+	for _, bc := range []struct {
+		name    string
+		workers int
+	}{{"serial", 1}, {"parallel", 0}} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(img)))
+			for range b.N {
+				Scan(img, bc.workers)
+			}
+		})
+	}
+}
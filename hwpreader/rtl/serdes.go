@@ -4,9 +4,8 @@ package rtl
 
 import (
 	"bufio"
-	"encoding/binary"
 	"fmt"
-	"log"
+	"strings"
 )
 
 const (
@@ -17,6 +16,15 @@ const (
 	CONVERTER_RX_POLARITY_MASK = 0x8
 )
 
+// Bitfields of the serdes and converter entries.
+const (
+	serdesModeField  bitfield = 0xff << SERDES_MODE_OFFSET & 0xff
+	serdesRxField    bitfield = SERDES_RX_POLARITY_MASK
+	serdesTxField    bitfield = SERDES_TX_POLARITY_MASK
+	converterRxField bitfield = CONVERTER_RX_POLARITY_MASK
+	converterTxField bitfield = CONVERTER_TX_POLARITY_MASK
+)
+
 type SerdesMode uint8
 
 const (
@@ -186,39 +194,35 @@ type Serdes struct {
 	TxPolarity SerdesPolarity
 }
 
-func (sd *Serdes) Read(r *bufio.Reader) error {
-	b, err := r.ReadByte()
-	if err != nil {
-		return err
-	}
-	sd.Id = uint8(b)
-	b, err = r.ReadByte()
-	if err != nil {
-		return err
-	}
-	mode := uint8(b) >> SERDES_MODE_OFFSET
-	if mode < uint8(RTK_MII_NONE) || mode > uint8(RTK_MII_END) {
-		//return fmt.Errorf("invalid MII mode: %v", mode)
-		log.Printf("unknown MII mode %x", mode)
-	}
-	sd.Mode = SerdesMode(mode)
-	if (b & SERDES_RX_POLARITY_MASK) != 0 {
-		sd.RxPolarity = SERDES_POLARITY_CHANGE
-	} else {
-		sd.RxPolarity = SERDES_POLARITY_NORMAL
+// decode reads a serdes entry. Unknown modes are kept as is, with a warning.
+func (sd *Serdes) decode(d *decoder) {
+	sd.Id = d.u8("sds_id")
+	b := d.u8("mode")
+	sd.Mode = SerdesMode(d.unpack(b, serdesModeField, "mode"))
+	if sd.Mode >= RTK_MII_END {
+		d.warn("mode", "unknown MII mode %d", sd.Mode)
 	}
-	if (b & SERDES_TX_POLARITY_MASK) != 0 {
-		sd.TxPolarity = SERDES_POLARITY_CHANGE
-	} else {
-		sd.TxPolarity = SERDES_POLARITY_NORMAL
-	}
-	return nil
+	sd.RxPolarity = SerdesPolarity(d.unpack(b, serdesRxField, "rx_polarity"))
+	sd.TxPolarity = SerdesPolarity(d.unpack(b, serdesTxField, "tx_polarity"))
+}
+
+// Read decodes a serdes entry laid out as SDK3_BE from r.
+func (sd *Serdes) Read(r *bufio.Reader) error {
+	return readEntry(r, serdesSize, sd.decode)
 }
 
 func (sd *Serdes) String() string {
 	return fmt.Sprintf("Serdes{sds_id: %d, mode: %s, rx_polarity: %s, tx_polarity: %s}", sd.Id, sd.Mode, sd.RxPolarity, sd.TxPolarity)
 }
 
+// SerdesConverter is a chip bridging a serdes of the switch to fiber ports,
+// e.g. a RTL8295R or RTL8214QF. It is managed through the SMI bus Smi, its
+// ports answering at consecutive addresses from PhyAddr.
+//
+// Chip is kept as the raw value of the profile. Unlike the chip of the PHY
+// table, the field is a plain uint32 and not a PhyChipId, and its encoding is
+// not documented. Model takes it to be the part number of the chip, such as
+// 0x8295 for a RTL8295R: the checks relying on that guess only warn.
 type SerdesConverter struct {
 	Chip       uint32
 	Smi        uint8
@@ -228,40 +232,49 @@ type SerdesConverter struct {
 	Pad0       uint8
 }
 
-func (sc *SerdesConverter) Read(r *bufio.Reader) error {
-	if err := binary.Read(r, binary.BigEndian, &sc.Chip); err != nil {
-		return err
-	}
-	b, err := r.ReadByte()
-	if err != nil {
-		return err
-	}
-	sc.Smi = uint8(b)
-	b, err = r.ReadByte()
-	if err != nil {
-		return err
-	}
-	sc.PhyAddr = uint8(b)
-	b, err = r.ReadByte()
-	if err != nil {
-		return err
-	}
-	if (b & CONVERTER_RX_POLARITY_MASK) != 0 {
-		sc.RxPolarity = SERDES_POLARITY_CHANGE
-	} else {
-		sc.RxPolarity = SERDES_POLARITY_NORMAL
-	}
-	if (b & CONVERTER_TX_POLARITY_MASK) != 0 {
-		sc.TxPolarity = SERDES_POLARITY_CHANGE
-	} else {
-		sc.TxPolarity = SERDES_POLARITY_NORMAL
+// converterModels maps the model numbers of the converter chips to their PHY
+// type.
+var converterModels = map[uint32]PhyChipId{
+	0x8214: RTK_PHYTYPE_RTL8214QF,
+	0x8295: RTK_PHYTYPE_RTL8295R,
+}
+
+// Model returns the PHY type of the converter chip, false if its model number
+// is unknown.
+func (sc *SerdesConverter) Model() (PhyChipId, bool) {
+	m, ok := converterModels[sc.Chip]
+	return m, ok
+}
+
+// ChipName returns the name of the converter chip, e.g. RTL8295R, or its
+// model number if it is unknown.
+func (sc *SerdesConverter) ChipName() string {
+	if m, ok := sc.Model(); ok {
+		return strings.TrimPrefix(m.String(), "RTK_PHYTYPE_")
 	}
-	if sc.Pad0, err = r.ReadByte(); err != nil {
-		return err
+	return fmt.Sprintf("0x%x", sc.Chip)
+}
+
+// decode reads a converter entry. Unknown chips are kept as is, with a
+// warning.
+func (sc *SerdesConverter) decode(d *decoder) {
+	sc.Chip = d.u32("chip")
+	if _, ok := sc.Model(); !ok {
+		d.warn("chip", "unknown converter chip 0x%x", sc.Chip)
 	}
-	return nil
+	sc.Smi = d.u8("smi")
+	sc.PhyAddr = d.u8("phy_addr")
+	b := d.u8("polarity")
+	sc.RxPolarity = SerdesPolarity(d.unpack(b, converterRxField, "rx_polarity"))
+	sc.TxPolarity = SerdesPolarity(d.unpack(b, converterTxField, "tx_polarity"))
+	sc.Pad0 = d.u8("pad")
+}
+
+// Read decodes a converter entry laid out as SDK3_BE from r.
+func (sc *SerdesConverter) Read(r *bufio.Reader) error {
+	return readEntry(r, converterSize, sc.decode)
 }
 
 func (sc *SerdesConverter) String() string {
-	return fmt.Sprintf("SerdesConverter{chip: 0x%x, mode: %d, phy_add: %d, rx_polarity: %s, tx_polarity: %s}", sc.Chip, sc.Smi, sc.PhyAddr, sc.RxPolarity, sc.TxPolarity)
+	return fmt.Sprintf("SerdesConverter{chip: %s, smi: %d, phy_addr: %d, rx_polarity: %s, tx_polarity: %s}", sc.ChipName(), sc.Smi, sc.PhyAddr, sc.RxPolarity, sc.TxPolarity)
 }
@@ -4,9 +4,8 @@ package rtl
 
 import (
 	"bufio"
-	"encoding/binary"
-	"errors"
 	"html/template"
+	"io"
 	"log"
 )
 
@@ -55,134 +54,201 @@ type Switch struct {
 	Leds                    *Leds
 }
 
+// UnmarshalBinary decodes a descriptor with the SDK3_BE layout.
 func (sw *Switch) UnmarshalBinary(r *bufio.Reader) error {
-	var val uint8
-	// chip_id is 4 bytes long
-	if err := binary.Read(r, binary.BigEndian, &sw.ChipId); err != nil {
-		return err
-	}
-	// swcore_supported is 1 byte long
-	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
+	_, err := sw.Decode(r, SDK3_BE, 0)
+	return err
+}
+
+// readEntry reads a table entry of size bytes laid out as SDK3_BE from r and
+// decodes it. The warnings are logged. It returns io.EOF when r is exhausted
+// before the entry.
+func readEntry(r *bufio.Reader, size int, decode func(d *decoder)) error {
+	data := make([]byte, size)
+	n, err := io.ReadFull(r, data)
+	if err == io.EOF {
 		return err
 	}
-	sw.SwitchCoreSupported = (val != 0)
-	// 3 bytes alignment padding
-	if err := eatPadding(r, 3); err != nil {
-		return err
+	d := newDecoder(data[:n], SDK3_BE, 0)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		d.readErr = err
 	}
-	if err := binary.Read(r, binary.BigEndian, &sw.SwitchCoreAccessMethod); err != nil {
-		return err
+	decode(d)
+	for _, w := range d.warnings {
+		log.Print(w)
 	}
-	if err := binary.Read(r, binary.BigEndian, &sw.SwitchCoreSpiChipSelect); err != nil {
-		return err
+	return d.err()
+}
+
+// Decode reads a descriptor laid out as l, base being its absolute offset in
+// the image it is read from. It consumes the size of the layout from r and
+// decodes it with DecodeBytes.
+func (sw *Switch) Decode(r io.Reader, l *Layout, base int64) ([]Warning, error) {
+	data := make([]byte, l.Size())
+	n, err := io.ReadFull(r, data)
+	d := newDecoder(data[:n], l, base)
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		d.readErr = err
 	}
-	val, err := readUint8WithPadding(r, 2)
-	if err != nil {
-		return err
+	sw.decode(d, l)
+	return d.warnings, d.err()
+}
+
+// DecodeAt reads the descriptor laid out as l found at off in r. The offsets
+// of the parse errors and warnings are offsets of r.
+func (sw *Switch) DecodeAt(r io.ReaderAt, off int64, l *Layout) ([]Warning, error) {
+	data := make([]byte, l.Size())
+	n, err := r.ReadAt(data, off)
+	d := newDecoder(data[:n], l, off)
+	if n < len(data) && err != io.EOF {
+		d.readErr = err
 	}
-	sw.NicSupported = (val != 0)
+	sw.decode(d, l)
+	return d.warnings, d.err()
+}
+
+// DecodeBytes decodes the descriptor laid out as l at the start of data, base
+// being its absolute offset in the image it is taken from. The fields are
+// read in place: the tables are allocated once, the paths of the parse errors
+// and warnings are only formatted when needed. Tables are read up to their
+// end marker, the entry counts are ignored. Decoding failures are returned as
+// a *ParseError, suspicious values of the decoded entries as warnings. The
+// ParseError is all a truncated descriptor allocates.
+func (sw *Switch) DecodeBytes(data []byte, l *Layout, base int64) ([]Warning, error) {
+	d := decoder{data: data, order: l.Order, bits: l.Bits, base: base, index: -1}
+	sw.decode(&d, l)
+	return d.warnings, d.err()
+}
+
+// decode decodes the descriptor from d. A window shorter than the layout
+// fails to decode: unless the fields are recorded, its entries are only read
+// to locate the failure, the tables being left empty.
+func (sw *Switch) decode(d *decoder, l *Layout) {
+	d.dry = !d.record && len(d.data) < l.Size()
+	sw.ChipId = RtlChipId(d.u32("chip_id"))
+	sw.SwitchCoreSupported = d.u8("swcore_supported") != 0
+	d.skip("swcore_access_method", l.SwcorePad)
+	sw.SwitchCoreAccessMethod = SwitchRegAccMethod(d.u32("swcore_access_method"))
+	sw.SwitchCoreSpiChipSelect = d.u8("swcore_spi_chip_select")
+	sw.NicSupported = d.u8("nic_supported") != 0
+	d.skip("ports.count", l.NicPad)
 
 	// Port count is ignored.
-	if _, err := readUint8WithPadding(r, 3); err != nil {
-		return err
+	d.u8("ports.count")
+	d.skip("ports", l.PortCountPad)
+	var ports []Port
+	if !d.dry {
+		ports = make([]Port, l.Ports)
 	}
-	// Read ports.
+	sw.Ports = nil
 	done := false
-	for range RTK_MAX_PORT_PER_UNIT {
-		port := &Port{}
-		if err := port.Read(r); err != nil {
-			return err
-		}
-		if port.MacId == 0xff {
-			done = true
-		}
-		if !done {
-			sw.Ports = append(sw.Ports, port)
+	for i := range l.Ports {
+		var e Port
+		n := d.begin("ports", i)
+		e.decode(d)
+		if done = d.end(n, done || e.MacId == HWP_END); !done && !d.failed && !d.dry {
+			ports[i] = e
+			sw.Ports = append(sw.Ports, &ports[i])
 		}
 	}
+	d.endMarker("ports", done)
 
 	// Serdes count is ignored.
-	if _, err := r.ReadByte(); err != nil {
-		return err
+	d.u8("serdes.count")
+	d.skip("serdes", l.SdsCountPad)
+	var serdes []Serdes
+	if !d.dry {
+		serdes = make([]Serdes, l.Serdes)
 	}
+	sw.Serdes = nil
 	done = false
-	for range RTK_MAX_SDS_PER_UNIT {
-		sds := &Serdes{}
-		if err := sds.Read(r); err != nil {
-			return err
-		}
-		if sds.Id == 0xff {
-			done = true
-		}
-		if !done {
-			sw.Serdes = append(sw.Serdes, sds)
+	for i := range l.Serdes {
+		var e Serdes
+		n := d.begin("serdes", i)
+		e.decode(d)
+		if done = d.end(n, done || e.Id == HWP_END); !done && !d.failed && !d.dry {
+			serdes[i] = e
+			sw.Serdes = append(sw.Serdes, &serdes[i])
 		}
 	}
+	d.endMarker("serdes", done)
 
 	// Serdes converter count is ignored.
-	if _, err := readUint8WithPadding(r, 3); err != nil {
-		return err
+	d.u8("converters.count")
+	d.skip("converters", l.ScCountPad)
+	var converters []SerdesConverter
+	if !d.dry {
+		converters = make([]SerdesConverter, l.Converters)
 	}
-	// Read ports.
+	sw.Converters = nil
 	done = false
-	for range RTK_MAX_SC_PER_UNIT {
-		sc := &SerdesConverter{}
-		if err := sc.Read(r); err != nil {
-			return err
-		}
-		if sc.Chip == 0xff {
-			done = true
-		}
-		if !done {
-			sw.Converters = append(sw.Converters, sc)
+	for i := range l.Converters {
+		var e SerdesConverter
+		n := d.begin("converters", i)
+		e.decode(d)
+		if done = d.end(n, done || e.Chip == HWP_END); !done && !d.failed && !d.dry {
+			converters[i] = e
+			sw.Converters = append(sw.Converters, &converters[i])
 		}
 	}
+	d.endMarker("converters", done)
 
-	if _, err := readUint8WithPadding(r, 6); err != nil {
-		return err
+	// PHY count is ignored.
+	d.u8("phys.count")
+	d.skip("phys", l.PhyCountPad)
+	var phys []Phy
+	if !d.dry {
+		phys = make([]Phy, l.Phys)
 	}
-	// Read ports.
+	sw.Phys = nil
 	done = false
-	for range RTK_MAX_PHY_PER_UNIT {
-		phy := &Phy{}
-		if err := binary.Read(r, binary.BigEndian, phy); err != nil {
-			return err
-		}
-		if phy.Chip == 0xff {
-			done = true
-		}
-		if !done {
-			sw.Phys = append(sw.Phys, phy)
+	for i := range l.Phys {
+		var e Phy
+		n := d.begin("phys", i)
+		e.decode(d)
+		if done = d.end(n, done || e.Chip == HWP_END); !done && !d.failed && !d.dry {
+			phys[i] = e
+			sw.Phys = append(sw.Phys, &phys[i])
 		}
 	}
+	d.endMarker("phys", done)
 
-	sw.Leds = &Leds{}
-	if err := sw.Leds.Read(r); err != nil {
-		return err
+	var leds Leds
+	d.entry = "leds"
+	leds.decode(d)
+	sw.Leds = nil
+	if !d.dry {
+		sw.Leds = &Leds{}
+		*sw.Leds = leds
 	}
+	d.entry, d.index = "", -1
+}
 
-	return nil
+// begin starts the decoding of the i-th entry of a table and returns the
+// number of warnings recorded so far.
+func (d *decoder) begin(table string, i int) int {
+	d.entry, d.index = table, i
+	return len(d.warnings)
 }
 
-func readUint8WithPadding(r *bufio.Reader, pcount int) (uint8, error) {
-	b, err := r.ReadByte()
-	if err != nil {
-		return 0, err
+// end completes the decoding of a table entry, done telling if the end marker
+// of the table was met. The warnings about the entries following the end
+// marker are dropped, n being the number of warnings before the entry.
+func (d *decoder) end(n int, done bool) bool {
+	d.entry, d.index = "", -1
+	if done {
+		d.warnings = d.warnings[:n]
 	}
-	if err := eatPadding(r, pcount); err != nil {
-		return 0, err
-	}
-	return uint8(b), nil
+	d.quiet = done
+	return done
 }
 
-func eatPadding(r *bufio.Reader, count int) error {
-	bytes := make([]byte, count)
-	if n, err := r.Read(bytes); err != nil {
-		return err
-	} else if n != count {
-		return errors.New("padding error")
+// endMarker warns about a table filled up without end marker.
+func (d *decoder) endMarker(table string, done bool) {
+	d.quiet = false
+	if !done && !d.failed && !d.dry {
+		d.warnings = append(d.warnings, Warning{Offset: d.base + int64(d.off), Path: table, Message: "table has no end marker"})
 	}
-	return nil
 }
 
 const switchTmpl = `Switch{
@@ -209,15 +275,17 @@ const switchTmpl = `Switch{
   ]
 
   .leds: {
+    .led_active: {{printf "%s" .Leds.Active}},{{if not .Leds.HasActive}} /* not in the descriptor, taken as LOW */{{end}}
     .led_if_sel: {{printf "%s" .Leds.LedIfSel}},
     .led_definition_set: [
-{{range $i, $set := .Leds.LedSet}}{{range $j, $led := $set.Led}}{{printf "       .led_definition_set[%d].led[%d] = 0x%04x\n" $i $j $led}}{{end}}{{end}}
+{{range $i, $set := .Leds.LedSet}}{{range $j, $led := $set.Led}}{{printf "       .led_definition_set[%d].led[%d] = 0x%04x /* %s */\n" $i $j (word $led) $led}}{{end}}{{end}}
     ]
   }
 }`
 
 func (sw *Switch) String() string {
-	t := template.Must(template.New("t").Parse(switchTmpl))
+	funcs := template.FuncMap{"word": func(w LedWord) uint32 { return uint32(w) }}
+	t := template.Must(template.New("t").Funcs(funcs).Parse(switchTmpl))
 	t.Execute(log.Writer(), sw)
 	return ""
 }
@@ -0,0 +1,438 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+)
+
+// Severity of a validation finding.
+type Severity int
+
+const (
+	INFO Severity = iota
+	WARNING
+	ERROR
+)
+
+func (s Severity) String() string {
+	switch s {
+	case INFO:
+		return "info"
+	case WARNING:
+		return "warning"
+	case ERROR:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Finding is an inconsistency found in a switch descriptor.
+type Finding struct {
+	Severity Severity
+	// Path designates the faulty field, e.g. ports[3].phy_idx.
+	Path    string
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Path, f.Message)
+}
+
+// validator accumulates the findings of a switch descriptor.
+type validator struct {
+	sw *Switch
+	// info holds the limits of the chip, nil if its family is unknown.
+	info *ChipInfo
+	// slave is set for the units of a profile not embedding the CPU.
+	slave    bool
+	findings []Finding
+}
+
+func (v *validator) report(sev Severity, path string, format string, args ...any) {
+	v.findings = append(v.findings, Finding{
+		Severity: sev,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate checks the consistency of the switch descriptor and returns the
+// findings ordered as the descriptor fields.
+func (sw *Switch) Validate() []Finding {
+	return sw.validate(false)
+}
+
+func (sw *Switch) validate(slave bool) []Finding {
+	v := &validator{sw: sw, slave: slave}
+	v.chip()
+	v.ports()
+	v.serdes()
+	v.phys()
+	v.converters()
+	v.budget()
+	return v.findings
+}
+
+// HasErrors returns true if at least one finding is an error.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) chip() {
+	info, ok := v.sw.ChipId.Info()
+	switch {
+	case info == nil:
+		v.report(WARNING, "chip_id", "unknown chip %s, hardware limits are not checked", v.sw.ChipId)
+	case !ok:
+		v.report(WARNING, "chip_id", "unknown chip %s, checking against the %s family limits", v.sw.ChipId, info.Family)
+	}
+	v.info = info
+}
+
+func (v *validator) ports() {
+	if len(v.sw.Ports) == 0 {
+		v.report(ERROR, "ports", "no port defined")
+		return
+	}
+
+	n, multiGig := 0, 0
+	for _, p := range v.sw.Ports {
+		if p.Attr&HWP_CPU == 0 {
+			n++
+		}
+		if p.Attr&HWP_CPU == 0 && p.Eth == HWP_2_5GE {
+			multiGig++
+		}
+	}
+	if v.info != nil && v.info.Ports != 0 && n > v.info.Ports {
+		v.report(ERROR, "ports", "%d ports defined, the %s has %d", n, v.info.Name, v.info.Ports)
+	}
+	if v.info != nil && v.info.MultiGig != 0 && multiGig > v.info.MultiGig {
+		v.report(ERROR, "ports", "%d 2.5G ports defined, the %s has %d", multiGig, v.info.Name, v.info.MultiGig)
+	}
+
+	macs := make(map[uint8]int)
+	type mdioAddr struct{ smi, addr uint8 }
+	addrs := make(map[mdioAddr]int)
+	cpu := false
+	for i, p := range v.sw.Ports {
+		path := fmt.Sprintf("ports[%d]", i)
+		if j, ok := macs[p.MacId]; ok {
+			v.report(ERROR, path+".mac_id", "MAC %d already used by ports[%d]", p.MacId, j)
+		}
+		macs[p.MacId] = i
+		if p.Attr&HWP_CPU != 0 {
+			cpu = true
+			if v.info != nil && p.MacId != v.info.CpuPort {
+				v.report(ERROR, path+".mac_id", "the CPU port of %s is MAC %d", v.info.Family, v.info.CpuPort)
+			}
+			continue
+		}
+		if v.info != nil && int(p.MacId) >= v.info.MaxPorts {
+			v.report(ERROR, path+".mac_id", "MAC %d is out of range (%d ports on %s)", p.MacId, v.info.MaxPorts, v.info.Family)
+		}
+
+		if p.PhyIdx != HWP_NONE {
+			if int(p.PhyIdx) >= len(v.sw.Phys) {
+				v.report(ERROR, path+".phy_idx", "PHY index %d is out of range (%d PHYs)", p.PhyIdx, len(v.sw.Phys))
+			}
+			a := mdioAddr{p.Smi, p.PhyAddr}
+			if j, ok := addrs[a]; ok {
+				v.report(ERROR, path+".phy_addr", "SMI %d address %d already used by ports[%d]", p.Smi, p.PhyAddr, j)
+			}
+			addrs[a] = i
+		}
+
+		if _, err := v.sw.PortSerdes(p); err != nil {
+			v.report(ERROR, path+".sds_idx", "%v", err)
+		}
+		if p.PhyIdx == HWP_NONE && p.SdsIdx == HWP_NONE {
+			v.report(WARNING, path, "port has neither a PHY nor a serdes")
+		}
+
+		for _, led := range []struct {
+			name string
+			set  LedSel
+		}{{"led_c", p.LedC}, {"led_f", p.LedF}} {
+			if led.set != HWP_NONE && led.set >= RTK_MAX_LED_MOD {
+				v.report(ERROR, path+"."+led.name, "LED set %d is out of range", led.set)
+			}
+		}
+		v.portTypes(path, p)
+	}
+	if !cpu && !v.slave {
+		v.report(WARNING, "ports", "no CPU port defined")
+	}
+}
+
+// portTypes checks the attributes, ethernet type and medium of a port.
+func (v *validator) portTypes(path string, p *Port) {
+	if unknown := p.Attr &^ portAttrMask; unknown != 0 {
+		v.report(WARNING, path+".attr", "unknown attribute bits 0x%x", uint8(unknown))
+	}
+	if p.Attr&HWP_SC != 0 {
+		if int(p.ScIdx) >= len(v.sw.Converters) {
+			v.report(ERROR, path+".sc_idx", "serdes converter index %d is out of range (%d converters)", p.ScIdx, len(v.sw.Converters))
+		}
+	} else if p.ScIdx != 0 && p.ScIdx != HWP_NONE {
+		v.report(WARNING, path+".sc_idx", "serdes converter index %d is ignored without HWP_SC", p.ScIdx)
+	}
+
+	if p.Eth >= HWP_ETH_END {
+		v.report(ERROR, path+".eth", "invalid ethernet type %v", p.Eth)
+	}
+	if p.Medi >= HWP_MEDI_END {
+		v.report(ERROR, path+".medi", "invalid medium %v", p.Medi)
+	}
+	if p.LedLayout >= LED_LAYOUT_END && p.LedLayout != HWP_NONE {
+		v.report(ERROR, path+".led_layout", "invalid LED layout %v", p.LedLayout)
+	}
+
+	switch p.Medi {
+	case HWP_COPPER:
+		if p.PhyIdx == HWP_NONE {
+			v.report(WARNING, path+".medi", "copper port without PHY")
+		}
+		if p.LedC == HWP_NONE {
+			v.report(WARNING, path+".led_c", "copper port without copper LED set")
+		}
+	case HWP_FIBER:
+		if p.SdsIdx == HWP_NONE && p.Attr&HWP_SC == 0 {
+			v.report(WARNING, path+".medi", "fiber port without serdes")
+		}
+		if p.LedF == HWP_NONE {
+			v.report(WARNING, path+".led_f", "fiber port without fiber LED set")
+		}
+	case HWP_COMBO:
+		if p.LedC == HWP_NONE || p.LedF == HWP_NONE {
+			v.report(WARNING, path, "combo port requires both copper and fiber LED sets")
+		}
+	}
+	if p.LedLayout == DOUBLE_SET && p.Medi != HWP_COMBO {
+		v.report(INFO, path+".led_layout", "DOUBLE_SET is only meaningful for combo ports")
+	}
+}
+
+func (v *validator) serdes() {
+	n := 0
+	for _, s := range v.sw.Serdes {
+		if s.Mode != RTK_MII_NONE && s.Mode != RTK_MII_DISABLE {
+			n++
+		}
+	}
+	if v.info != nil && v.info.Serdes != 0 && n > v.info.Serdes {
+		v.report(ERROR, "serdes", "%d serdes enabled, the %s has %d", n, v.info.Name, v.info.Serdes)
+	}
+
+	ids := make(map[uint8]int)
+	for i, s := range v.sw.Serdes {
+		path := fmt.Sprintf("serdes[%d]", i)
+		if j, ok := ids[s.Id]; ok {
+			v.report(ERROR, path+".sds_id", "serdes %d already defined by serdes[%d]", s.Id, j)
+		}
+		ids[s.Id] = i
+		if v.info != nil && int(s.Id) >= v.info.MaxSerdes {
+			v.report(ERROR, path+".sds_id", "serdes %d is out of range (%d serdes on %s)", s.Id, v.info.MaxSerdes, v.info.Family)
+		}
+		if s.Mode >= RTK_MII_END {
+			v.report(ERROR, path+".mode", "invalid mode %d", s.Mode)
+			continue
+		}
+		if v.info != nil && !v.info.Supports(s.Mode) {
+			v.report(WARNING, path+".mode", "mode %s is not supported by %s", s.Mode, v.sw.ChipId)
+		}
+	}
+}
+
+func (v *validator) phys() {
+	users := make([][]int, len(v.sw.Phys))
+	for i, p := range v.sw.Ports {
+		if p.Attr&HWP_CPU == 0 && p.PhyIdx != HWP_NONE && int(p.PhyIdx) < len(v.sw.Phys) {
+			users[p.PhyIdx] = append(users[p.PhyIdx], i)
+		}
+	}
+	for i, phy := range v.sw.Phys {
+		path := fmt.Sprintf("phys[%d]", i)
+		if phy.Chip >= RTK_PHYTYPE_UNKNOWN {
+			v.report(ERROR, path+".chip", "invalid PHY chip %d", phy.Chip)
+		}
+		ports := users[i]
+		if len(ports) == 0 {
+			v.report(WARNING, path, "PHY is not used by any port")
+			continue
+		}
+		if len(ports) > int(phy.PhyMax) {
+			v.report(ERROR, path+".phy_max", "%d ports use a PHY with %d ports", len(ports), phy.PhyMax)
+		} else if len(ports) < int(phy.PhyMax) {
+			v.report(INFO, path+".phy_max", "only %d of the %d PHY ports are used", len(ports), phy.PhyMax)
+		}
+		first := v.sw.Ports[ports[0]].MacId
+		for _, j := range ports {
+			first = min(first, v.sw.Ports[j].MacId)
+		}
+		if phy.MacId != first {
+			v.report(WARNING, path+".mac_id", "base MAC %d differs from the first port using it (%d)", phy.MacId, first)
+		}
+		if phy.Chip.IsConverter() {
+			v.report(WARNING, path+".chip", "%s is a serdes converter, expected in the converter table", phy.Chip)
+		}
+		v.phySerdes(path, ports)
+		if info, ok := phy.Chip.Info(); ok {
+			v.phyTopology(path, phy, info, ports)
+		}
+	}
+}
+
+// phySerdes checks the serdes feeding the ports of a PHY. PHYs with more
+// ports than a serdes mode multiplexes are fed by several serdes, all in the
+// same mode and at most RTK_MAX_SDS_PER_PHY.
+func (v *validator) phySerdes(path string, ports []int) {
+	var serdes []int
+	seen := make(map[int]bool)
+	for _, j := range ports {
+		idx, _ := v.sw.PortSerdes(v.sw.Ports[j])
+		for _, k := range idx {
+			if !seen[k] {
+				seen[k] = true
+				serdes = append(serdes, k)
+			}
+		}
+	}
+	if len(serdes) > RTK_MAX_SDS_PER_PHY {
+		v.report(ERROR, path, "%d serdes feed the PHY, at most %d are supported", len(serdes), RTK_MAX_SDS_PER_PHY)
+	}
+	for _, k := range serdes[min(1, len(serdes)):] {
+		first, mode := v.sw.Serdes[serdes[0]].Mode, v.sw.Serdes[k].Mode
+		if mode != first {
+			v.report(WARNING, fmt.Sprintf("serdes[%d].mode", k), "%s differs from the mode %s of serdes[%d] feeding the same PHY", mode, first, serdes[0])
+		}
+	}
+}
+
+// converters checks the serdes converters against the ports behind them:
+// the chip should be a known converter model, its MDIO addresses free and the
+// ports within its capabilities.
+func (v *validator) converters() {
+	type mdioAddr struct{ smi, addr uint8 }
+	addrs := make(map[mdioAddr]string)
+	for i, p := range v.sw.Ports {
+		if p.Attr&HWP_CPU == 0 && p.PhyIdx != HWP_NONE {
+			addrs[mdioAddr{p.Smi, p.PhyAddr}] = fmt.Sprintf("ports[%d]", i)
+		}
+	}
+	users := make([][]int, len(v.sw.Converters))
+	for i, p := range v.sw.Ports {
+		if p.Attr&HWP_CPU == 0 && p.Attr&HWP_SC != 0 && int(p.ScIdx) < len(v.sw.Converters) {
+			users[p.ScIdx] = append(users[p.ScIdx], i)
+		}
+	}
+	for i, sc := range v.sw.Converters {
+		path := fmt.Sprintf("converters[%d]", i)
+		model, known := sc.Model()
+		var info *PhyInfo
+		if known {
+			info, known = model.Info()
+		} else {
+			v.report(WARNING, path+".chip", "unknown converter chip 0x%x", sc.Chip)
+		}
+
+		// The converter answers at one address per port. The addresses past
+		// the first one and the checks of the ports against the model rely
+		// on the guessed model of the chip: they only warn.
+		n := 1
+		if known {
+			n = info.Ports
+		}
+		if int(sc.PhyAddr)+n > 32 {
+			severity := WARNING
+			if sc.PhyAddr >= 32 {
+				severity = ERROR
+			}
+			v.report(severity, path+".phy_addr", "addresses %d to %d are out of the MDIO range", sc.PhyAddr, int(sc.PhyAddr)+n-1)
+		}
+		for k := range n {
+			a := mdioAddr{sc.Smi, sc.PhyAddr + uint8(k)}
+			if other, ok := addrs[a]; ok {
+				severity := WARNING
+				if k == 0 {
+					severity = ERROR
+				}
+				v.report(severity, path+".phy_addr", "SMI %d address %d already used by %s", a.smi, a.addr, other)
+			}
+			addrs[a] = path
+		}
+
+		ports := users[i]
+		if len(ports) == 0 {
+			v.report(WARNING, path, "converter is not used by any port")
+			continue
+		}
+		if !known {
+			continue
+		}
+		if len(ports) > info.Ports {
+			v.report(WARNING, path, "%d ports use %s, it has %d ports", len(ports), model, info.Ports)
+		}
+		for _, j := range ports {
+			p := v.sw.Ports[j]
+			portPath := fmt.Sprintf("ports[%d]", j)
+			if p.Eth < HWP_ETH_END && !info.SupportsSpeed(p.Eth) {
+				v.report(WARNING, portPath+".eth", "%s is not supported by %s", p.Eth, model)
+			}
+			idx, _ := v.sw.PortSerdes(p)
+			for _, k := range idx {
+				if mode := v.sw.Serdes[k].Mode; !info.SupportsHost(mode) {
+					v.report(WARNING, portPath+".sds_idx", "serdes[%d] in mode %s cannot feed %s", k, mode, model)
+				}
+			}
+		}
+	}
+}
+
+// phyTopology checks the ports using a PHY against its capabilities: they
+// must sit on the same SMI bus, the port at MAC phy.MacId+k at the address of
+// the first PHY port plus k, link at a supported speed and be fed by a serdes
+// in a host mode of the PHY.
+func (v *validator) phyTopology(path string, phy *Phy, info *PhyInfo, ports []int) {
+	if int(phy.PhyMax) > info.Ports {
+		v.report(ERROR, path+".phy_max", "%s has %d ports, not %d", phy.Chip, info.Ports, phy.PhyMax)
+	}
+	// The address of the first PHY port is given by the port of lowest
+	// MAC ID.
+	ref := v.sw.Ports[ports[0]]
+	for _, j := range ports {
+		if p := v.sw.Ports[j]; p.MacId < ref.MacId {
+			ref = p
+		}
+	}
+	base := int(ref.PhyAddr) - (int(ref.MacId) - int(phy.MacId))
+	for _, j := range ports {
+		p := v.sw.Ports[j]
+		portPath := fmt.Sprintf("ports[%d]", j)
+		offset := int(p.MacId) - int(phy.MacId)
+		if offset >= int(phy.PhyMax) {
+			v.report(ERROR, portPath+".mac_id", "MAC %d is out of the %d ports of phys[%d] from MAC %d", p.MacId, phy.PhyMax, p.PhyIdx, phy.MacId)
+		}
+		if p.Smi != ref.Smi {
+			v.report(ERROR, portPath+".smi", "SMI %d differs from the SMI %d of the other ports of phys[%d]", p.Smi, ref.Smi, p.PhyIdx)
+		} else if int(p.PhyAddr) != base+offset {
+			v.report(WARNING, portPath+".phy_addr", "address %d does not match the offset %d of MAC %d in phys[%d], expected %d", p.PhyAddr, offset, p.MacId, p.PhyIdx, base+offset)
+		}
+		if p.Eth < HWP_ETH_END && !info.SupportsSpeed(p.Eth) {
+			v.report(ERROR, portPath+".eth", "%s is not supported by %s", p.Eth, phy.Chip)
+		}
+		idx, _ := v.sw.PortSerdes(p)
+		for _, k := range idx {
+			if mode := v.sw.Serdes[k].Mode; !info.SupportsHost(mode) {
+				v.report(WARNING, portPath+".sds_idx", "serdes[%d] in mode %s cannot feed %s", k, mode, phy.Chip)
+			}
+		}
+	}
+}
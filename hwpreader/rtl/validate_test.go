@@ -0,0 +1,375 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package rtl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func validSwitch() *Switch {
+	return &Switch{
+		ChipId: RTL9302B_CHIP_ID,
+		Ports: []*Port{
+			{MacId: 0, PhyIdx: 0, Smi: 0, PhyAddr: 0, SdsIdx: 0, Attr: HWP_ETHER, Eth: HWP_2_5GE, Medi: HWP_COPPER, LedC: 0, LedF: HWP_NONE},
+			{MacId: 1, PhyIdx: 0, Smi: 0, PhyAddr: 1, SdsIdx: 0, Attr: HWP_ETHER, Eth: HWP_2_5GE, Medi: HWP_COPPER, LedC: 0, LedF: HWP_NONE},
+			{MacId: 24, PhyIdx: HWP_NONE, SdsIdx: 1, Attr: HWP_ETHER, Eth: HWP_XGE, Medi: HWP_FIBER, LedC: HWP_NONE, LedF: 1},
+			{MacId: 28, PhyIdx: HWP_NONE, SdsIdx: HWP_NONE, Attr: HWP_CPU, Eth: HWP_NONE, Medi: HWP_NONE},
+		},
+		Serdes: []*Serdes{
+			{Id: 2, Mode: RTK_MII_USXGMII_10GQXGMII},
+			{Id: 6, Mode: RTK_MII_10GR},
+		},
+		Phys: []*Phy{{Chip: RTK_PHYTYPE_RTL8224, MacId: 0, PhyMax: 2}},
+		Leds: &Leds{LedIfSel: LED_IF_SEL_SERIAL},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	if findings := validSwitch().Validate(); len(findings) != 0 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+func TestPortSerdes(t *testing.T) {
+	sw := validSwitch()
+	sw.Serdes = append(sw.Serdes, &Serdes{Id: 4, Mode: RTK_MII_10GR}, &Serdes{Id: 5, Mode: RTK_MII_10GR})
+	tests := []struct {
+		sdsIdx uint32
+		want   []int
+		err    bool
+	}{
+		{sdsIdx: HWP_NONE},
+		{sdsIdx: 1, want: []int{1}},
+		{sdsIdx: 3, want: []int{3}},
+		{sdsIdx: 1<<2 | 1<<3, want: []int{2, 3}},
+		{sdsIdx: 8, err: true},
+		{sdsIdx: 1<<1 | 1<<4, err: true},
+	}
+	for _, tt := range tests {
+		got, err := sw.PortSerdes(&Port{SdsIdx: tt.sdsIdx})
+		if (err != nil) != tt.err || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("sds_idx 0x%x: got %v, %v, want %v", tt.sdsIdx, got, err, tt.want)
+		}
+	}
+
+	// A fiber port fed by two lanes.
+	sw.Ports[2].SdsIdx = 1<<2 | 1<<3
+	if findings := sw.Validate(); len(findings) != 0 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+	b := sw.Budget()
+	if pb := b.Ports[2]; fmt.Sprint(pb.Serdes) != "[2 3]" || pb.Mbps != 10000 {
+		t.Errorf("port 24: serdes %v, %d Mbps", pb.Serdes, pb.Mbps)
+	}
+	for _, k := range []int{2, 3} {
+		if sb := b.Serdes[k]; len(sb.Ports) != 1 || sb.Ports[0] != 2 {
+			t.Errorf("serdes %d feeds ports %v, want [2]", k, sb.Ports)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(sw *Switch)
+		want   Finding
+	}{
+		{
+			name:   "PhyIdxOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[1].PhyIdx = 3 },
+			want:   Finding{ERROR, "ports[1].phy_idx", "PHY index 3 is out of range (1 PHYs)"},
+		},
+		{
+			name:   "SdsIdxOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[2].SdsIdx = 2 },
+			want:   Finding{ERROR, "ports[2].sds_idx", "serdes index 2 is out of range (2 serdes)"},
+		},
+		{
+			name:   "SdsBitmapOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[2].SdsIdx = 1<<1 | 1<<2 },
+			want:   Finding{ERROR, "ports[2].sds_idx", "serdes bitmap 0x6 is out of range (2 serdes)"},
+		},
+		{
+			name:   "DuplicateMdioAddress",
+			modify: func(sw *Switch) { sw.Ports[1].PhyAddr = 0 },
+			want:   Finding{ERROR, "ports[1].phy_addr", "SMI 0 address 0 already used by ports[0]"},
+		},
+		{
+			name:   "MacOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[2].MacId = 30 },
+			want:   Finding{ERROR, "ports[2].mac_id", "MAC 30 is out of range (28 ports on RTL930x)"},
+		},
+		{
+			name:   "CpuPortMac",
+			modify: func(sw *Switch) { sw.Ports[3].MacId = 27 },
+			want:   Finding{ERROR, "ports[3].mac_id", "the CPU port of RTL930x is MAC 28"},
+		},
+		{
+			name:   "SdsIdOutOfRange",
+			modify: func(sw *Switch) { sw.Serdes[1].Id = 12 },
+			want:   Finding{ERROR, "serdes[1].sds_id", "serdes 12 is out of range (12 serdes on RTL930x)"},
+		},
+		{
+			name:   "UnknownChipOfKnownFamily",
+			modify: func(sw *Switch) { sw.ChipId = 0x93027000 },
+			want:   Finding{WARNING, "chip_id", "unknown chip UNKNOWN (0x93027000), checking against the RTL930x family limits"},
+		},
+		{
+			name:   "DuplicateMac",
+			modify: func(sw *Switch) { sw.Ports[2].MacId = 1 },
+			want:   Finding{ERROR, "ports[2].mac_id", "MAC 1 already used by ports[1]"},
+		},
+		{
+			name:   "UnsupportedMode",
+			modify: func(sw *Switch) { sw.Serdes[1].Mode = RTK_MII_RXAUI },
+			want:   Finding{WARNING, "serdes[1].mode", "mode RTK_MII_RXAUI is not supported by RTL9302B (0x93021000)"},
+		},
+		{
+			name:   "PhyMaxTooSmall",
+			modify: func(sw *Switch) { sw.Phys[0].PhyMax = 1 },
+			want:   Finding{ERROR, "phys[0].phy_max", "2 ports use a PHY with 1 ports"},
+		},
+		{
+			name:   "PhyMaxAboveChip",
+			modify: func(sw *Switch) { sw.Phys[0].PhyMax = 8 },
+			want:   Finding{ERROR, "phys[0].phy_max", "RTK_PHYTYPE_RTL8224 has 4 ports, not 8"},
+		},
+		{
+			name:   "PhySmiMismatch",
+			modify: func(sw *Switch) { sw.Ports[1].Smi = 1 },
+			want:   Finding{ERROR, "ports[1].smi", "SMI 1 differs from the SMI 0 of the other ports of phys[0]"},
+		},
+		{
+			name:   "PhyAddrNotConsecutive",
+			modify: func(sw *Switch) { sw.Ports[1].PhyAddr = 3 },
+			want:   Finding{WARNING, "ports[1].phy_addr", "address 3 does not match the offset 1 of MAC 1 in phys[0], expected 1"},
+		},
+		{
+			name:   "PhyMacOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[1].MacId, sw.Ports[1].PhyAddr = 2, 2 },
+			want:   Finding{ERROR, "ports[1].mac_id", "MAC 2 is out of the 2 ports of phys[0] from MAC 0"},
+		},
+		{
+			name:   "PhySpeed",
+			modify: func(sw *Switch) { sw.Ports[0].Eth = HWP_XGE },
+			want:   Finding{ERROR, "ports[0].eth", "HWP_XGE is not supported by RTK_PHYTYPE_RTL8224"},
+		},
+		{
+			name:   "PhyHostMode",
+			modify: func(sw *Switch) { sw.Serdes[0].Mode = RTK_MII_QSGMII },
+			want:   Finding{WARNING, "ports[0].sds_idx", "serdes[0] in mode RTK_MII_QSGMII cannot feed RTK_PHYTYPE_RTL8224"},
+		},
+		{
+			name:   "ScIdxOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[2].Attr |= HWP_SC },
+			want:   Finding{ERROR, "ports[2].sc_idx", "serdes converter index 0 is out of range (0 converters)"},
+		},
+		{
+			name:   "ScIdxWithoutSc",
+			modify: func(sw *Switch) { sw.Ports[2].ScIdx = 1 },
+			want:   Finding{WARNING, "ports[2].sc_idx", "serdes converter index 1 is ignored without HWP_SC"},
+		},
+		{
+			name:   "InvalidEthType",
+			modify: func(sw *Switch) { sw.Ports[0].Eth = 9 },
+			want:   Finding{ERROR, "ports[0].eth", "invalid ethernet type HWP_ETH_UNKNOWN (9)"},
+		},
+		{
+			name:   "FiberWithoutLed",
+			modify: func(sw *Switch) { sw.Ports[2].LedF = HWP_NONE },
+			want:   Finding{WARNING, "ports[2].led_f", "fiber port without fiber LED set"},
+		},
+		{
+			name:   "LedSetOutOfRange",
+			modify: func(sw *Switch) { sw.Ports[2].LedF = 4 },
+			want:   Finding{ERROR, "ports[2].led_f", "LED set 4 is out of range"},
+		},
+		{
+			name: "ConverterUnknown",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8218, Smi: 1, PhyAddr: 0}}
+			},
+			want: Finding{WARNING, "converters[0].chip", "unknown converter chip 0x8218"},
+		},
+		{
+			name: "ConverterUnused",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8295, Smi: 1, PhyAddr: 0}}
+			},
+			want: Finding{WARNING, "converters[0]", "converter is not used by any port"},
+		},
+		{
+			name: "ConverterAddressRange",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8214, Smi: 0, PhyAddr: 30}}
+				sw.Ports[1].PhyAddr = 31
+			},
+			want: Finding{WARNING, "converters[0].phy_addr", "addresses 30 to 33 are out of the MDIO range"},
+		},
+		{
+			name: "ConverterAddressOverlap",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8295, Smi: 0, PhyAddr: 1}}
+			},
+			want: Finding{ERROR, "converters[0].phy_addr", "SMI 0 address 1 already used by ports[1]"},
+		},
+		{
+			name: "ConverterSpeed",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8214, Smi: 1, PhyAddr: 0}}
+				sw.Ports[2].Attr |= HWP_SC
+			},
+			want: Finding{WARNING, "ports[2].eth", "HWP_XGE is not supported by RTK_PHYTYPE_RTL8214QF"},
+		},
+		{
+			name: "ConverterHostMode",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8214, Smi: 1, PhyAddr: 0}}
+				sw.Ports[2].Attr |= HWP_SC
+			},
+			want: Finding{WARNING, "ports[2].sds_idx", "serdes[1] in mode RTK_MII_10GR cannot feed RTK_PHYTYPE_RTL8214QF"},
+		},
+		{
+			name: "ConverterTooManyPorts",
+			modify: func(sw *Switch) {
+				sw.Converters = []*SerdesConverter{{Chip: 0x8295, Smi: 1, PhyAddr: 0}}
+				sw.Ports[2].Attr |= HWP_SC
+				sw.Ports = append(sw.Ports, &Port{MacId: 25, PhyIdx: HWP_NONE, SdsIdx: 1, Attr: HWP_ETHER | HWP_SC, Eth: HWP_XGE, Medi: HWP_FIBER, LedC: HWP_NONE, LedF: 1})
+			},
+			want: Finding{WARNING, "converters[0]", "2 ports use RTK_PHYTYPE_RTL8295R, it has 1 ports"},
+		},
+		{
+			name:   "ConverterInPhyTable",
+			modify: func(sw *Switch) { sw.Phys[0].Chip = RTK_PHYTYPE_RTL8295R },
+			want:   Finding{WARNING, "phys[0].chip", "RTK_PHYTYPE_RTL8295R is a serdes converter, expected in the converter table"},
+		},
+		{
+			name: "PhySerdesModes",
+			modify: func(sw *Switch) {
+				sw.Serdes = append(sw.Serdes, &Serdes{Id: 3, Mode: RTK_MII_QSGMII})
+				sw.Ports[1].SdsIdx = 2
+			},
+			want: Finding{WARNING, "serdes[2].mode", "RTK_MII_QSGMII differs from the mode RTK_MII_USXGMII_10GQXGMII of serdes[0] feeding the same PHY"},
+		},
+		{
+			name: "PhyTooManySerdes",
+			modify: func(sw *Switch) {
+				sw.Phys[0].PhyMax = 4
+				for k := range 4 {
+					sw.Serdes = append(sw.Serdes, &Serdes{Id: uint8(3 + k), Mode: RTK_MII_USXGMII_10GQXGMII})
+					if k >= 2 {
+						sw.Ports = append(sw.Ports, &Port{MacId: uint8(k), PhyIdx: 0, PhyAddr: uint8(k), Attr: HWP_ETHER, Eth: HWP_2_5GE, Medi: HWP_COPPER, LedC: 0, LedF: HWP_NONE})
+					}
+					sw.Ports[[]int{0, 1, 4, 5}[k]].SdsIdx = uint32(2 + k)
+				}
+			},
+			want: Finding{ERROR, "phys[0]", "4 serdes feed the PHY, at most 3 are supported"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw := validSwitch()
+			tt.modify(sw)
+			findings := sw.Validate()
+			for _, f := range findings {
+				if f == tt.want {
+					return
+				}
+			}
+			t.Errorf("expected %v, got %v", tt.want, findings)
+		})
+	}
+}
+
+func TestValidateChipPorts(t *testing.T) {
+	sw := validSwitch()
+	sw.ChipId = RTL9303_CHIP_ID
+	for i := range 6 {
+		p := *sw.Ports[2]
+		p.MacId = uint8(16 + i)
+		sw.Ports = append(sw.Ports, &p)
+	}
+	want := Finding{ERROR, "ports", "9 ports defined, the RTL9303 has 8"}
+	found := false
+	for _, f := range sw.Validate() {
+		found = found || f == want
+	}
+	if !found {
+		t.Errorf("expected %v", want)
+	}
+	// The family limits apply to the chips of undocumented port count.
+	sw.ChipId = RTL9302B_CHIP_ID
+	for _, f := range sw.Validate() {
+		if f.Path == "ports" {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+}
+
+func TestValidateChipCounts(t *testing.T) {
+	has := func(sw *Switch, want Finding) bool {
+		for _, f := range sw.Validate() {
+			if f == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Nine 2.5G ports on a chip named after eight.
+	sw := validSwitch()
+	for i := range 7 {
+		p := *sw.Ports[1]
+		p.MacId = uint8(2 + i)
+		sw.Ports = append(sw.Ports, &p)
+	}
+	want := Finding{ERROR, "ports", "9 2.5G ports defined, the RTL9302B_8X2_5G has 8"}
+	sw.ChipId = RTL9302B_CHIP_ID_8X2_5G
+	if !has(sw, want) {
+		t.Errorf("expected %v", want)
+	}
+	sw.ChipId = RTL9302B_CHIP_ID
+	if has(sw, Finding{ERROR, "ports", "9 2.5G ports defined, the RTL9302B has 0"}) {
+		t.Errorf("unknown 2.5G port count is checked")
+	}
+
+	// Nine serdes enabled on a chip with eight, disabled ones not counting.
+	sw = validSwitch()
+	sw.ChipId = RTL9303_CHIP_ID
+	for i, id := range []uint8{0, 1, 3, 4, 5, 7, 8, 9} {
+		mode := RTK_MII_10GR
+		if i == 7 {
+			mode = RTK_MII_DISABLE
+		}
+		sw.Serdes = append(sw.Serdes, &Serdes{Id: id, Mode: mode})
+	}
+	want = Finding{ERROR, "serdes", "9 serdes enabled, the RTL9303 has 8"}
+	if !has(sw, want) {
+		t.Errorf("expected %v", want)
+	}
+	sw.Serdes = sw.Serdes[:len(sw.Serdes)-2]
+	for _, f := range sw.Validate() {
+		if f.Path == "serdes" {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+}
+
+func TestValidatePhyMacOffset(t *testing.T) {
+	// A 4 port PHY from MAC 0 at address 4, its ports 0 and 2 being used.
+	sw := validSwitch()
+	sw.Phys[0].PhyMax = 4
+	sw.Ports[0].PhyAddr = 4
+	sw.Ports[1].MacId, sw.Ports[1].PhyAddr = 2, 6
+	for _, f := range sw.Validate() {
+		if f.Severity != INFO {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+	sw.Ports[1].PhyAddr = 5
+	want := Finding{WARNING, "ports[1].phy_addr", "address 5 does not match the offset 2 of MAC 2 in phys[0], expected 6"}
+	if findings := sw.Validate(); len(findings) != 2 || findings[1] != want {
+		t.Errorf("expected %v, got %v", want, findings)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * GS1900-10HP like board: RTL8380M, an RTL8218B octal gigabit PHY on QSGMII
+ * links and two 1000BASE-X SFP cages, built with the SDK 2.x.
+ */
+#include <hwp/hw_profile.h>
+
+#define LED_GE_LINK_ACT     0xba0   // link/act at 10M, 100M and 1G
+#define LED_GE_LINK         0x220   // link at 1G
+
+static hwp_swDescp_t gs1900_10hp_swDescp = {
+
+    .chip_id                    = RTL8380M_CHIP_ID,
+    .swcore_supported           = TRUE,
+    .swcore_access_method       = HWP_SW_ACC_MEM,
+    .swcore_spi_chip_select     = HWP_NOT_USED,
+    .nic_supported              = TRUE,
+
+    .port.descp = {
+        [0] = { .mac_id = 8,  .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 8,  .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [1] = { .mac_id = 9,  .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 9,  .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [2] = { .mac_id = 10, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 10, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [3] = { .mac_id = 11, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 11, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [4] = { .mac_id = 12, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 12, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [5] = { .mac_id = 13, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 13, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [6] = { .mac_id = 14, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 14, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [7] = { .mac_id = 15, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 15, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [8] = { .mac_id = 24, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_FIBER, .sds_idx = 2, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 1, .led_layout = SINGLE_SET, },
+        [9] = { .mac_id = 26, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_FIBER, .sds_idx = 3, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 1, .led_layout = SINGLE_SET, },
+        [10] = { .mac_id = 28, .attr = HWP_CPU, .eth = HWP_NONE, .medi = HWP_NONE, .sds_idx = HWP_NONE, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = HWP_NONE, .led_layout = HWP_NONE, },
+        [11] = { .mac_id = HWP_END },
+    },  /* port.descp */
+
+    .led.descp = {
+        .led_if_sel = LED_IF_SEL_SERIAL,
+        .led_definition_set[0].led[0] = LED_GE_LINK_ACT,
+        .led_definition_set[1].led[0] = LED_GE_LINK_ACT,
+        .led_definition_set[1].led[1] = LED_GE_LINK,
+    },/* led.descp */
+
+    .serdes.descp = {
+        [0] = { .sds_id = 2, .mode = RTK_MII_QSGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [1] = { .sds_id = 3, .mode = RTK_MII_QSGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [2] = { .sds_id = 4, .mode = RTK_MII_1000BX_FIBER, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [3] = { .sds_id = 5, .mode = RTK_MII_1000BX_FIBER, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [4] = { .sds_id = HWP_END },
+    }, /* serdes.descp */
+
+    .phy.descp = {
+        [0] = { .chip = RTK_PHYTYPE_RTL8218B, .mac_id = 8, .phy_max = 8 },
+        [1] = { .chip = HWP_END },
+    }
+};
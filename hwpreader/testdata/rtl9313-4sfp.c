@@ -0,0 +1,50 @@
+/*
+ * RTL9313 reference like board driven by a little endian host: four SFP+
+ * cages and a combo port on an RTL8214FC with a LED set per medium.
+ */
+#include <hwp/hw_profile.h>
+
+#define LED_10G_LINK_ACT    0xa01   // link/act at 10G
+#define LED_GE_LINK_ACT     0xba0   // link/act at 10M, 100M and 1G
+#define LED_GE_LINK_FLASH   0x420   // link flash at 1G
+
+static hwp_swDescp_t rtl9313_4sfp_swDescp = {
+
+    .chip_id                    = RTL9313_CHIP_ID,
+    .swcore_supported           = TRUE,
+    .swcore_access_method       = HWP_SW_ACC_PCIe,
+    .swcore_spi_chip_select     = HWP_NOT_USED,
+    .nic_supported              = FALSE,
+
+    .port.descp = {
+        [0] = { .mac_id = 48, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 0, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 0, .led_layout = SINGLE_SET, },
+        [1] = { .mac_id = 49, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 1, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 0, .led_layout = SINGLE_SET, },
+        [2] = { .mac_id = 50, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 2, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 0, .led_layout = SINGLE_SET, },
+        [3] = { .mac_id = 51, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 3, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 0, .led_layout = SINGLE_SET, },
+        [4] = { .mac_id = 52, .attr = HWP_ETHER, .eth = HWP_GE, .medi = HWP_COMBO, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 1, .phy_addr = 0, .led_c = 1, .led_f = 2, .led_layout = DOUBLE_SET, },
+        [5] = { .mac_id = 56, .attr = HWP_CPU, .eth = HWP_NONE, .medi = HWP_NONE, .sds_idx = HWP_NONE, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = HWP_NONE, .led_layout = HWP_NONE, },
+        [6] = { .mac_id = HWP_END },
+    },  /* port.descp */
+
+    .led.descp = {
+        .led_if_sel = LED_IF_SEL_BI_COLOR_SCAN,
+        .led_definition_set[0].led[0] = LED_10G_LINK_ACT,
+        .led_definition_set[1].led[0] = LED_GE_LINK_ACT,
+        .led_definition_set[1].led[1] = LED_GE_LINK_FLASH,
+        .led_definition_set[2].led[0] = LED_GE_LINK_ACT,
+    },/* led.descp */
+
+    .serdes.descp = {
+        [0] = { .sds_id = 8,  .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [1] = { .sds_id = 9,  .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [2] = { .sds_id = 10, .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_CHANGE, .tx_polarity = SERDES_POLARITY_CHANGE },
+        [3] = { .sds_id = 11, .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_CHANGE, .tx_polarity = SERDES_POLARITY_CHANGE },
+        [4] = { .sds_id = 12, .mode = RTK_MII_QSGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [5] = { .sds_id = HWP_END },
+    }, /* serdes.descp */
+
+    .phy.descp = {
+        [0] = { .chip = RTK_PHYTYPE_RTL8214FC, .mac_id = 52, .phy_max = 1 },
+        [1] = { .chip = HWP_END },
+    }
+};
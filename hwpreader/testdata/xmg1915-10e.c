@@ -0,0 +1,54 @@
+/*
+ * XMG1915-10E like board: RTL9302B, two RTL8224 quad 2.5G PHYs on 10G-QXGMII
+ * links and two SFP+ cages.
+ */
+#include <hwp/hw_profile.h>
+
+#define LED_LINK_ACT        0xbeb   // link/act at any speed
+#define LED_2_5G_LINK       0x208   // link at 2.5G
+#define LED_10G_LINK_ACT    0xa01   // link/act at 10G
+
+static hwp_swDescp_t xmg1915_10e_swDescp = {
+
+    .chip_id                    = RTL9302B_CHIP_ID,
+    .swcore_supported           = TRUE,
+    .swcore_access_method       = HWP_SW_ACC_MEM,
+    .swcore_spi_chip_select     = HWP_NOT_USED,
+    .nic_supported              = TRUE,
+
+    .port.descp = {
+        [0] = { .mac_id = 0,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 0, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [1] = { .mac_id = 1,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 1, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [2] = { .mac_id = 2,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 2, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [3] = { .mac_id = 3,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 0, .smi = 0, .phy_addr = 3, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [4] = { .mac_id = 8,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 1, .smi = 0, .phy_addr = 4, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [5] = { .mac_id = 9,  .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 1, .smi = 0, .phy_addr = 5, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [6] = { .mac_id = 10, .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 1, .smi = 0, .phy_addr = 6, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [7] = { .mac_id = 11, .attr = HWP_ETHER, .eth = HWP_2_5GE, .medi = HWP_COPPER, .sds_idx = HWP_NONE, .phy_idx = 1, .smi = 0, .phy_addr = 7, .led_c = 0, .led_f = HWP_NONE, .led_layout = SINGLE_SET, },
+        [8] = { .mac_id = 24, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 2, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 1, .led_layout = SINGLE_SET, },
+        [9] = { .mac_id = 25, .attr = HWP_ETHER, .eth = HWP_XGE, .medi = HWP_FIBER, .sds_idx = 3, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = 1, .led_layout = SINGLE_SET, },
+        [10] = { .mac_id = 28, .attr = HWP_CPU, .eth = HWP_NONE, .medi = HWP_NONE, .sds_idx = HWP_NONE, .phy_idx = HWP_NONE, .smi = HWP_NONE, .phy_addr = HWP_NONE, .led_c = HWP_NONE, .led_f = HWP_NONE, .led_layout = HWP_NONE, },
+        [11] = { .mac_id = HWP_END },
+    },  /* port.descp */
+
+    .led.descp = {
+        .led_if_sel = LED_IF_SEL_SERIAL,
+        .led_definition_set[0].led[0] = LED_LINK_ACT,
+        .led_definition_set[0].led[1] = LED_2_5G_LINK,
+        .led_definition_set[1].led[0] = LED_10G_LINK_ACT,
+    },/* led.descp */
+
+    .serdes.descp = {
+        [0] = { .sds_id = 2, .mode = RTK_MII_USXGMII_10GQXGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [1] = { .sds_id = 3, .mode = RTK_MII_USXGMII_10GQXGMII, .rx_polarity = SERDES_POLARITY_NORMAL, .tx_polarity = SERDES_POLARITY_CHANGE },
+        [2] = { .sds_id = 6, .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_CHANGE, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [3] = { .sds_id = 7, .mode = RTK_MII_10GR, .rx_polarity = SERDES_POLARITY_CHANGE, .tx_polarity = SERDES_POLARITY_NORMAL },
+        [4] = { .sds_id = HWP_END },
+    }, /* serdes.descp */
+
+    .phy.descp = {
+        [0] = { .chip = RTK_PHYTYPE_RTL8224, .mac_id = 0, .phy_max = 4 },
+        [1] = { .chip = RTK_PHYTYPE_RTL8224, .mac_id = 8, .phy_max = 4 },
+        [2] = { .chip = HWP_END },
+    }
+};
@@ -0,0 +1,265 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package tui is an interactive terminal explorer of a switch descriptor.
+//
+// The entries of the descriptor are listed in tabs: ports, serdes, serdes
+// converters, PHYs and LED sets. The values of the selected entry are shown
+// below the list, or the raw bytes each of its fields was decoded from. The
+// references between entries are followed with a key: from a port to its
+// PHY, serdes, converter and LED sets, from the other entries to the ports
+// using them.
+//
+// The Explorer holds the state of the interface and renders it as lines of
+// text, Run drives it from a terminal.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+// Tabs of the explorer.
+const (
+	PORTS = iota
+	SERDES
+	CONVERTERS
+	PHYS
+	LEDS
+)
+
+var tabNames = []string{"Ports", "Serdes", "Converters", "PHYs", "LEDs"}
+
+// firstLink is the key following the first link of an entry.
+const firstLink = 0
+
+// place designates a row of a tab.
+type place struct {
+	tab, row int
+}
+
+// link is a reference from an entry to another one, followed with key.
+type link struct {
+	key   rune
+	label string
+	to    place
+}
+
+type row struct {
+	text string
+	// path designates the entry in the descriptor, e.g. ports[3].
+	path  string
+	links []link
+	// users are the ports referencing the entry.
+	users []place
+}
+
+type tab struct {
+	name     string
+	rows     []*row
+	sel, top int
+}
+
+// Explorer is the state of the terminal explorer.
+type Explorer struct {
+	sw *rtl.Switch
+	// data holds the raw bytes of the descriptor, starting at the absolute
+	// offset base. fields are the fields decoded from them, nil if the
+	// descriptor was not decoded from bytes.
+	data   []byte
+	base   int64
+	fields []rtl.Field
+
+	tabs []*tab
+	cur  int
+	hex  bool
+	// history holds the places left by following links.
+	history []place
+	// cycles counts the presses of the users key on each entry.
+	cycles map[*row]int
+
+	// searching is set while the search query is typed.
+	searching bool
+	query     string
+	status    string
+	// listLines is the height of the list when it was last rendered.
+	listLines int
+}
+
+// New returns an explorer of the switch descriptor sw. data holds its raw
+// bytes from the absolute offset base, and fields the fields decoded from
+// them by rtl.DecodeFields. data and fields are nil for descriptors parsed
+// from sources, which have no hex view.
+func New(sw *rtl.Switch, data []byte, base int64, fields []rtl.Field) *Explorer {
+	e := &Explorer{sw: sw, data: data, base: base, fields: fields, cycles: make(map[*row]int)}
+	for _, name := range tabNames {
+		e.tabs = append(e.tabs, &tab{name: name})
+	}
+	e.build()
+	return e
+}
+
+// build lists the entries of the descriptor and the links between them.
+func (e *Explorer) build() {
+	sw := e.sw
+	ports, serdes, converters, phys, leds := e.tabs[PORTS], e.tabs[SERDES], e.tabs[CONVERTERS], e.tabs[PHYS], e.tabs[LEDS]
+	for _, sd := range sw.Serdes {
+		serdes.rows = append(serdes.rows, &row{
+			text: fmt.Sprintf("sds %2d  %-26s rx %-6s tx %s", sd.Id, short(sd.Mode.String(), "RTK_MII_"), polarity(sd.RxPolarity), polarity(sd.TxPolarity)),
+			path: fmt.Sprintf("serdes[%d]", len(serdes.rows)),
+		})
+	}
+	for _, sc := range sw.Converters {
+		converters.rows = append(converters.rows, &row{
+			text: fmt.Sprintf("%-14s smi %d  addr %2d  rx %-6s tx %s", sc.ChipName(), sc.Smi, sc.PhyAddr, polarity(sc.RxPolarity), polarity(sc.TxPolarity)),
+			path: fmt.Sprintf("converters[%d]", len(converters.rows)),
+		})
+	}
+	for _, phy := range sw.Phys {
+		phys.rows = append(phys.rows, &row{
+			text: fmt.Sprintf("%-14s mac %2d  %d ports", short(phy.Chip.String(), "RTK_PHYTYPE_"), phy.MacId, phy.PhyMax),
+			path: fmt.Sprintf("phys[%d]", len(phys.rows)),
+		})
+	}
+	if sw.Leds != nil {
+		for i, set := range sw.Leds.LedSet {
+			var words []string
+			for _, w := range set.Led {
+				words = append(words, fmt.Sprintf("%04x", uint32(w)))
+			}
+			leds.rows = append(leds.rows, &row{
+				text: fmt.Sprintf("set %d  %s", i, strings.Join(words, " ")),
+				path: fmt.Sprintf("leds.led_definition_set[%d]", i),
+			})
+		}
+	}
+
+	for i, p := range sw.Ports {
+		r := &row{
+			text: fmt.Sprintf("mac %2d  %-6s %-7s phy %-3s sds %-3s sc %-3s led %s/%s  %s",
+				p.MacId, short(p.Eth.String(), "HWP_"), short(p.Medi.String(), "HWP_"),
+				index(uint32(p.PhyIdx)), index(p.SdsIdx), scIndex(p), index(uint32(p.LedC)), index(uint32(p.LedF)), p.Attr),
+			path: fmt.Sprintf("ports[%d]", i),
+		}
+		here := place{PORTS, i}
+		refs := []struct {
+			key   rune
+			label string
+			tab   int
+			idx   uint32
+			used  bool
+		}{
+			{'p', "PHY", PHYS, uint32(p.PhyIdx), true},
+			{'s', "serdes", SERDES, p.SdsIdx, true},
+			{'c', "converter", CONVERTERS, uint32(p.ScIdx), p.Attr&rtl.HWP_SC != 0},
+			{'l', "copper LEDs", LEDS, uint32(p.LedC), true},
+			{'f', "fiber LEDs", LEDS, uint32(p.LedF), true},
+		}
+		for _, ref := range refs {
+			if !ref.used || ref.idx == rtl.HWP_NONE || int(ref.idx) >= len(e.tabs[ref.tab].rows) {
+				continue
+			}
+			target := e.tabs[ref.tab].rows[ref.idx]
+			r.links = append(r.links, link{ref.key, fmt.Sprintf("%s %s", ref.label, target.path), place{ref.tab, int(ref.idx)}})
+			if n := len(target.users); n == 0 || target.users[n-1] != here {
+				target.users = append(target.users, here)
+			}
+		}
+		ports.rows = append(ports.rows, r)
+	}
+}
+
+func short(s, prefix string) string {
+	return strings.TrimPrefix(s, prefix)
+}
+
+func polarity(p rtl.SerdesPolarity) string {
+	if p == rtl.SERDES_POLARITY_CHANGE {
+		return "swap"
+	}
+	return "normal"
+}
+
+// index formats a table index, - for none.
+func index(i uint32) string {
+	if i == rtl.HWP_NONE {
+		return "-"
+	}
+	return fmt.Sprint(i)
+}
+
+func scIndex(p *rtl.Port) string {
+	if p.Attr&rtl.HWP_SC == 0 {
+		return "-"
+	}
+	return fmt.Sprint(p.ScIdx)
+}
+
+// selected returns the selected row of the current tab, nil if it is empty.
+func (e *Explorer) selected() *row {
+	t := e.tabs[e.cur]
+	if len(t.rows) == 0 {
+		return nil
+	}
+	return t.rows[t.sel]
+}
+
+// jump selects a row, remembering the current one to come back to it.
+func (e *Explorer) jump(to place) {
+	t := e.tabs[e.cur]
+	e.history = append(e.history, place{e.cur, t.sel})
+	e.goTo(to)
+}
+
+func (e *Explorer) goTo(to place) {
+	e.cur = to.tab
+	e.tabs[e.cur].sel = to.row
+}
+
+func (e *Explorer) move(delta int) {
+	t := e.tabs[e.cur]
+	if len(t.rows) == 0 {
+		return
+	}
+	t.sel = min(max(t.sel+delta, 0), len(t.rows)-1)
+}
+
+// follow follows the link of the selected entry bound to key, the first one
+// for firstLink. The users key jumps to the ports using the entry, the next
+// one at each press.
+func (e *Explorer) follow(key rune) {
+	r := e.selected()
+	if r == nil {
+		return
+	}
+	if key == 'u' || (key == firstLink && len(r.links) == 0) {
+		if len(r.users) == 0 {
+			e.status = r.path + " is not used by any port"
+			return
+		}
+		k := e.cycles[r] % len(r.users)
+		e.cycles[r]++
+		e.jump(r.users[k])
+		e.status = fmt.Sprintf("user %d/%d of %s", k+1, len(r.users), r.path)
+		return
+	}
+	for _, l := range r.links {
+		if l.key == key || key == firstLink {
+			e.jump(l.to)
+			e.status = l.label
+			return
+		}
+	}
+	e.status = fmt.Sprintf("no %q link from %s", key, r.path)
+}
+
+// back returns to the place left by the last link followed.
+func (e *Explorer) back() {
+	if len(e.history) == 0 {
+		e.status = "no previous entry"
+		return
+	}
+	e.goTo(e.history[len(e.history)-1])
+	e.history = e.history[:len(e.history)-1]
+}
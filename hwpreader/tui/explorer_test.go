@@ -0,0 +1,144 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/corpus"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Key
+	}{
+		{"q", []Key{{Rune, 'q'}}},
+		{"\x1b[A\x1bOB\x1b[5~", []Key{{Code: Up}, {Code: Down}, {Code: PageUp}}},
+		{"/é\r", []Key{{Rune, '/'}, {Rune, 'é'}, {Code: Enter}}},
+		{"\x1b", []Key{{Code: Escape}}},
+		{"\x1b[1;5Ax", []Key{{Rune, 'x'}}},
+		{"\t\x1b[Z\x7f\x03", []Key{{Code: Tab}, {Code: BackTab}, {Code: Backspace}, {Code: CtrlC}}},
+	}
+	for _, tt := range tests {
+		if got := ParseKeys([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseKeys(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// explorer returns an explorer of the XMG1915-10E board of the corpus.
+func explorer(t *testing.T) *Explorer {
+	for _, b := range corpus.Boards(t) {
+		if b.Name == "xmg1915-10e" {
+			return New(b.Switch, b.Data, 0, b.Fields)
+		}
+	}
+	t.Fatal("board xmg1915-10e not found")
+	return nil
+}
+
+func press(e *Explorer, keys string) {
+	for _, k := range ParseKeys([]byte(keys)) {
+		e.Handle(k)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{"Down", "jj\x1b[B", "ports[3]"},
+		{"End", "G", "ports[10]"},
+		{"Tab", "\t", "serdes[0]"},
+		{"Number", "4", "phys[0]"},
+		{"Phy", "jjjjp", "phys[1]"},
+		{"Serdes", "Gks", "serdes[3]"},
+		{"FiberLeds", "Gkf", "leds.led_definition_set[1]"},
+		{"Enter", "j\r", "phys[0]"},
+		{"Back", "jjjjpb", "ports[4]"},
+		{"Users", "4jububu", "ports[6]"},
+		{"UsersBack", "4jubu", "ports[5]"},
+		{"Search", "/rtl8224\r", "phys[0]"},
+		{"SearchNext", "/rtl8224\rn", "phys[1]"},
+		{"SearchWraps", "/mac_id 24\rnn", "ports[8]"},
+		{"SearchCancel", "/xyz\x1b", "ports[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := explorer(t)
+			press(e, tt.keys)
+			if r := e.selected(); r == nil || r.path != tt.want {
+				t.Errorf("selected %v, want %s (status %q)", r, tt.want, e.status)
+			}
+		})
+	}
+}
+
+func TestQuit(t *testing.T) {
+	e := explorer(t)
+	if e.Handle(Key{Code: Rune, Rune: 'j'}) {
+		t.Error("j quits")
+	}
+	if e.Handle(Key{Code: Rune, Rune: '/'}); e.Handle(Key{Code: Rune, Rune: 'q'}) {
+		t.Error("q quits while searching")
+	}
+	press(e, "\x1b")
+	if !e.Handle(Key{Code: Rune, Rune: 'q'}) || !e.Handle(Key{Code: CtrlC}) {
+		t.Error("q and ctrl-c do not quit")
+	}
+}
+
+func TestRender(t *testing.T) {
+	e := explorer(t)
+	press(e, "G")
+	lines := e.Render(60, 20)
+	if len(lines) != 20 {
+		t.Fatalf("%d lines rendered, want 20", len(lines))
+	}
+	var text []string
+	highlighted := ""
+	for _, l := range lines {
+		if len([]rune(l.Text)) > 60 {
+			t.Errorf("line %q is wider than 60 columns", l.Text)
+		}
+		if l.Highlight {
+			highlighted = l.Text
+		}
+		text = append(text, l.Text)
+	}
+	out := strings.Join(text, "\n")
+	for _, want := range []string{"RTL9302B", "[1 Ports (11)]", "ports[10]", "eth                  HWP_NONE"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render lacks %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(highlighted, " 10  mac 28") {
+		t.Errorf("highlighted %q, want the last port", highlighted)
+	}
+}
+
+func TestHexView(t *testing.T) {
+	e := explorer(t)
+	press(e, "4x")
+	detail := strings.Join(e.detail(), "\n")
+	for _, want := range []string{
+		"0x000490  00 00 00 20  chip                 RTK_PHYTYPE_RTL8224",
+		"0x000494  04           phy_max              4",
+		"0x000496  00           pad                  (padding)",
+	} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("hex view lacks %q:\n%s", want, detail)
+		}
+	}
+
+	e = New(e.sw, nil, 0, nil)
+	press(e, "x")
+	if detail := strings.Join(e.detail(), "\n"); !strings.Contains(detail, "no raw bytes") {
+		t.Errorf("hex view without bytes:\n%s", detail)
+	}
+}
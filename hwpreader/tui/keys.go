@@ -0,0 +1,104 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+// Code identifies the special keys, Rune the printable ones.
+type Code int
+
+const (
+	Rune Code = iota
+	Up
+	Down
+	Left
+	Right
+	PageUp
+	PageDown
+	Home
+	End
+	Enter
+	Backspace
+	Escape
+	Tab
+	BackTab
+	CtrlC
+)
+
+// Key is a key press.
+type Key struct {
+	Code Code
+	// Rune is the character of the Rune keys.
+	Rune rune
+}
+
+// escapes are the sequences sent by the terminals for the special keys.
+var escapes = []struct {
+	seq  string
+	code Code
+}{
+	{"\x1b[A", Up}, {"\x1bOA", Up},
+	{"\x1b[B", Down}, {"\x1bOB", Down},
+	{"\x1b[C", Right}, {"\x1bOC", Right},
+	{"\x1b[D", Left}, {"\x1bOD", Left},
+	{"\x1b[5~", PageUp},
+	{"\x1b[6~", PageDown},
+	{"\x1b[H", Home}, {"\x1bOH", Home}, {"\x1b[1~", Home},
+	{"\x1b[F", End}, {"\x1bOF", End}, {"\x1b[4~", End},
+	{"\x1b[Z", BackTab},
+}
+
+// ParseKeys decodes the key presses read from a terminal in raw mode. Unknown
+// escape sequences are dropped.
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			n, code := escape(b)
+			if code >= 0 {
+				keys = append(keys, Key{Code: code})
+			}
+			b = b[n:]
+			continue
+		}
+		r, n := utf8.DecodeRune(b)
+		b = b[n:]
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, Key{Code: Enter})
+		case 0x7f, 0x08:
+			keys = append(keys, Key{Code: Backspace})
+		case '\t':
+			keys = append(keys, Key{Code: Tab})
+		case 0x03:
+			keys = append(keys, Key{Code: CtrlC})
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, Key{Code: Rune, Rune: r})
+			}
+		}
+	}
+	return keys
+}
+
+// escape decodes the escape sequence at the start of b and returns its
+// length, and its key or -1 for unknown sequences. A lone escape character is
+// the escape key.
+func escape(b []byte) (int, Code) {
+	for _, e := range escapes {
+		if len(b) >= len(e.seq) && string(b[:len(e.seq)]) == e.seq {
+			return len(e.seq), e.code
+		}
+	}
+	if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+		return 1, Escape
+	}
+	// Skip the parameters and the final byte of an unknown sequence.
+	n := 2
+	for n < len(b) && (b[n] < 0x40 || b[n] > 0x7e) {
+		n++
+	}
+	return min(n+1, len(b)), -1
+}
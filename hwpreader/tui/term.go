@@ -0,0 +1,83 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Run drives the explorer from the terminal of the standard input and
+// output, until it quits. The terminal is switched to raw mode and to its
+// alternate screen, both restored on return.
+func Run(e *Explorer) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	saved, err := unix.IoctlGetTermios(in, ioctlGetTermios)
+	if err != nil {
+		return fmt.Errorf("standard input is not a terminal: %v", err)
+	}
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN], raw.Cc[unix.VTIME] = 1, 0
+	if err := unix.IoctlSetTermios(in, ioctlSetTermios, &raw); err != nil {
+		return err
+	}
+	defer unix.IoctlSetTermios(in, ioctlSetTermios, saved)
+
+	w := bufio.NewWriter(os.Stdout)
+	w.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		w.WriteString("\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		// The size is read at each redraw to follow the resizes.
+		width, height := 80, 24
+		if ws, err := unix.IoctlGetWinsize(out, unix.TIOCGWINSZ); err == nil && ws.Col > 0 && ws.Row > 0 {
+			width, height = int(ws.Col), int(ws.Row)
+		}
+		draw(w, e.Render(width, height))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range ParseKeys(buf[:n]) {
+			if e.Handle(k) {
+				return nil
+			}
+		}
+	}
+}
+
+// draw writes the lines from the top of the screen, the highlighted ones in
+// reverse video.
+func draw(w *bufio.Writer, lines []Line) {
+	w.WriteString("\x1b[H")
+	for i, l := range lines {
+		if i > 0 {
+			w.WriteString("\r\n")
+		}
+		if l.Highlight {
+			w.WriteString("\x1b[7m" + l.Text + "\x1b[0m")
+		} else {
+			w.WriteString(l.Text)
+		}
+		w.WriteString("\x1b[K")
+	}
+	w.WriteString("\x1b[J")
+}
@@ -0,0 +1,12 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+//go:build darwin || freebsd || netbsd || openbsd
+
+package tui
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
@@ -0,0 +1,10 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package tui
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
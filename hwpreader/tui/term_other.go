@@ -0,0 +1,13 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package tui
+
+import "fmt"
+
+// Run drives the explorer from the terminal, which is not supported on this
+// system.
+func Run(e *Explorer) error {
+	return fmt.Errorf("the terminal explorer is not supported on this system")
+}
@@ -0,0 +1,290 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"xioxoz.fr/hwpreader/rtl"
+)
+
+const help = "q quit  tab/1-5 tabs  enter/p/s/c/l/f follow  u users  b back  x hex  / search  n next"
+
+// Handle updates the explorer with a key press and returns true when the
+// explorer is to quit.
+func (e *Explorer) Handle(k Key) bool {
+	if e.searching {
+		e.edit(k)
+		return false
+	}
+	e.status = ""
+	switch k.Code {
+	case CtrlC:
+		return true
+	case Up:
+		e.move(-1)
+	case Down:
+		e.move(1)
+	case PageUp:
+		e.move(-e.page())
+	case PageDown:
+		e.move(e.page())
+	case Home:
+		e.move(-len(e.tabs[e.cur].rows))
+	case End:
+		e.move(len(e.tabs[e.cur].rows))
+	case Tab, Right:
+		e.cur = (e.cur + 1) % len(e.tabs)
+	case BackTab, Left:
+		e.cur = (e.cur + len(e.tabs) - 1) % len(e.tabs)
+	case Enter:
+		e.follow(firstLink)
+	case Backspace:
+		e.back()
+	case Rune:
+		switch r := k.Rune; r {
+		case 'q':
+			return true
+		case 'k':
+			e.move(-1)
+		case 'j':
+			e.move(1)
+		case 'g':
+			e.move(-len(e.tabs[e.cur].rows))
+		case 'G':
+			e.move(len(e.tabs[e.cur].rows))
+		case '1', '2', '3', '4', '5':
+			e.cur = int(r - '1')
+		case 'x':
+			e.hex = !e.hex
+		case '/':
+			e.searching, e.query = true, ""
+		case 'n':
+			e.find()
+		case 'b':
+			e.back()
+		case 'p', 's', 'c', 'l', 'f', 'u':
+			e.follow(r)
+		case '?':
+			e.status = help
+		}
+	}
+	return false
+}
+
+// edit updates the search query being typed.
+func (e *Explorer) edit(k Key) {
+	switch k.Code {
+	case Rune:
+		e.query += string(k.Rune)
+	case Backspace:
+		if e.query == "" {
+			e.searching = false
+			return
+		}
+		q := []rune(e.query)
+		e.query = string(q[:len(q)-1])
+	case Enter:
+		e.searching = false
+		e.find()
+	case Escape, CtrlC:
+		e.searching, e.query = false, ""
+	}
+}
+
+// find selects the next entry matching the search query, in the current tab
+// then in the following ones. Entries match on their summary and values,
+// regardless of the case.
+func (e *Explorer) find() {
+	if e.query == "" {
+		return
+	}
+	query := strings.ToLower(e.query)
+	var places []place
+	for i := range e.tabs {
+		t := (e.cur + i) % len(e.tabs)
+		for r := range e.tabs[t].rows {
+			places = append(places, place{t, r})
+		}
+	}
+	// Start after the selected row, wrapping around to it.
+	start := min(e.tabs[e.cur].sel+1, len(places))
+	for i := range places {
+		p := places[(start+i)%len(places)]
+		if strings.Contains(strings.ToLower(e.text(e.tabs[p.tab].rows[p.row])), query) {
+			if p != (place{e.cur, e.tabs[e.cur].sel}) {
+				e.jump(p)
+			}
+			e.status = fmt.Sprintf("%q found in %s", e.query, e.tabs[p.tab].rows[p.row].path)
+			return
+		}
+	}
+	e.status = fmt.Sprintf("%q not found", e.query)
+}
+
+// text returns the searchable text of an entry.
+func (e *Explorer) text(r *row) string {
+	var b strings.Builder
+	b.WriteString(r.path + " " + r.text)
+	for _, v := range e.sw.Values(r.path) {
+		b.WriteString(" " + v.Name + " " + v.Value)
+	}
+	return b.String()
+}
+
+// Line is a line of the rendered explorer, Highlight being set for the
+// selected entry.
+type Line struct {
+	Text      string
+	Highlight bool
+}
+
+// Render returns the lines of the explorer on a terminal of width columns
+// and height lines: the tabs, the entries of the current tab, the details of
+// the selected entry and a status line.
+func (e *Explorer) Render(width, height int) []Line {
+	var lines []Line
+	add := func(highlight bool, format string, args ...any) {
+		lines = append(lines, Line{Text: truncate(fmt.Sprintf(format, args...), width), Highlight: highlight})
+	}
+
+	add(false, "%s  %d ports, %d serdes, %d converters, %d PHYs",
+		e.sw.ChipId, len(e.sw.Ports), len(e.sw.Serdes), len(e.sw.Converters), len(e.sw.Phys))
+	var tabs []string
+	for i, t := range e.tabs {
+		label := fmt.Sprintf("%d %s (%d)", i+1, t.name, len(t.rows))
+		if i == e.cur {
+			label = "[" + label + "]"
+		} else {
+			label = " " + label + " "
+		}
+		tabs = append(tabs, label)
+	}
+	add(false, "%s", strings.Join(tabs, " "))
+	add(false, "%s", strings.Repeat("-", width))
+
+	// The details of the entry take the room they need, leaving at least
+	// 3 lines to the list.
+	avail := max(height-5, 4)
+	detail := e.detail()
+	detailLines := min(len(detail), avail-3)
+	listLines := avail - detailLines
+	e.listLines = listLines
+
+	t := e.tabs[e.cur]
+	t.top = min(t.top, t.sel)
+	if t.sel >= t.top+listLines {
+		t.top = t.sel - listLines + 1
+	}
+	for i := range listLines {
+		if r := t.top + i; r < len(t.rows) {
+			add(r == t.sel, "%3d  %s", r, t.rows[r].text)
+		} else if r == 0 {
+			add(false, "     (empty)")
+		} else {
+			add(false, "")
+		}
+	}
+	add(false, "%s", strings.Repeat("-", width))
+	for i := range detailLines {
+		if i == detailLines-1 && len(detail) > detailLines {
+			add(false, "  ...")
+			break
+		}
+		add(false, "%s", detail[i])
+	}
+
+	switch {
+	case e.searching:
+		add(false, "/%s_", e.query)
+	case e.status != "":
+		add(false, "%s", e.status)
+	default:
+		add(false, "%s", help)
+	}
+	return lines
+}
+
+// page returns the number of rows a page key moves by: the height of the
+// list when it was last rendered.
+func (e *Explorer) page() int {
+	return max(e.listLines, 1)
+}
+
+// detail returns the lines describing the selected entry: its values or the
+// bytes of its fields, then its links.
+func (e *Explorer) detail() []string {
+	r := e.selected()
+	if r == nil {
+		return nil
+	}
+	lines := []string{r.path}
+	if e.hex {
+		lines = append(lines, e.hexLines(r)...)
+	} else {
+		for _, v := range e.sw.Values(r.path) {
+			lines = append(lines, fmt.Sprintf("  %-20s %s", v.Name, v.Value))
+		}
+	}
+	for _, l := range r.links {
+		lines = append(lines, fmt.Sprintf("  [%c] %s", l.key, l.label))
+	}
+	if len(r.users) > 0 {
+		var users []string
+		for _, u := range r.users {
+			users = append(users, fmt.Sprintf("%s (mac %d)", e.tabs[u.tab].rows[u.row].path, e.sw.Ports[u.row].MacId))
+		}
+		lines = append(lines, fmt.Sprintf("  [u] used by %s", strings.Join(users, ", ")))
+	}
+	return lines
+}
+
+// hexLines returns the offset, raw bytes and value of the fields of an
+// entry.
+func (e *Explorer) hexLines(r *row) []string {
+	if e.fields == nil {
+		return []string{"  no raw bytes: the descriptor was not decoded from a binary"}
+	}
+	values := make(map[string]string)
+	for _, v := range e.sw.Values(r.path) {
+		values[v.Name] = v.Value
+	}
+	var lines []string
+	for _, f := range e.fields {
+		name, ok := strings.CutPrefix(f.Path, r.path+".")
+		if !ok {
+			continue
+		}
+		value := values[name]
+		switch {
+		case f.Pad:
+			value = "(padding)"
+		case f.Unused:
+			value = "(unused)"
+		}
+		lines = append(lines, fmt.Sprintf("  0x%06x  %-11s  %-20s %s", f.Offset, e.hexBytes(f), name, value))
+	}
+	return lines
+}
+
+func (e *Explorer) hexBytes(f rtl.Field) string {
+	start := f.Offset - e.base
+	if start < 0 || start+int64(f.Size) > int64(len(e.data)) {
+		return "?"
+	}
+	var b []string
+	for _, c := range e.data[start : start+int64(f.Size)] {
+		b = append(b, fmt.Sprintf("%02x", c))
+	}
+	return strings.Join(b, " ")
+}
+
+// truncate cuts s to width characters.
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:max(width, 0)])
+}
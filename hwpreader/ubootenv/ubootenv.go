@@ -0,0 +1,272 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package ubootenv reads and writes U-Boot environment blocks, as found in the
+// flash dumps of the switches.
+//
+// A block starts with the CRC32 of its data area, stored with the endianness
+// of the target. Redundant environments (CONFIG_SYS_REDUNDAND_ENVIRONMENT)
+// follow it with a flags byte, incremented on each save to elect the most
+// recent copy. The data area holds NUL terminated "name=value" strings ended
+// by an empty string; the remaining space is padding.
+package ubootenv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// Common sizes of environment blocks (CONFIG_ENV_SIZE).
+var Sizes = []int{0x1000, 0x2000, 0x4000, 0x8000, 0x10000, 0x20000, 0x40000}
+
+// Alignment of the environment blocks in flash: the smallest erase block.
+const Alignment = 0x1000
+
+// Var is an environment variable.
+type Var struct {
+	Name  string
+	Value string
+}
+
+func (v Var) String() string {
+	return v.Name + "=" + v.Value
+}
+
+// Env is an environment block.
+type Env struct {
+	// Size of the block, header included.
+	Size      int
+	Redundant bool
+	Flags     uint8
+	Order     binary.ByteOrder
+	vars      []Var
+}
+
+// New returns an empty environment block.
+func New(size int, redundant bool, order binary.ByteOrder) *Env {
+	return &Env{Size: size, Redundant: redundant, Order: order}
+}
+
+func (e *Env) headerSize() int {
+	if e.Redundant {
+		return 5
+	}
+	return 4
+}
+
+// Parse decodes an environment block: its size is the size of the block. The
+// format, plain or redundant, and the byte order are detected from the CRC.
+func Parse(block []byte) (*Env, error) {
+	for _, redundant := range []bool{false, true} {
+		for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+			e := New(len(block), redundant, order)
+			if len(block) <= e.headerSize() {
+				return nil, fmt.Errorf("block too short (%d bytes)", len(block))
+			}
+			data := block[e.headerSize():]
+			if order.Uint32(block) != crc32.ChecksumIEEE(data) {
+				continue
+			}
+			if redundant {
+				e.Flags = block[4]
+			}
+			if err := e.parseVars(data); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("CRC mismatch")
+}
+
+func (e *Env) parseVars(data []byte) error {
+	for off := 0; ; {
+		end := bytes.IndexByte(data[off:], 0)
+		if end < 0 {
+			return fmt.Errorf("unterminated variable at 0x%x", off)
+		}
+		if end == 0 {
+			return nil
+		}
+		name, value, ok := strings.Cut(string(data[off:off+end]), "=")
+		if !ok {
+			return fmt.Errorf("invalid variable %q at 0x%x", data[off:off+end], off)
+		}
+		e.vars = append(e.vars, Var{Name: name, Value: value})
+		off += end + 1
+	}
+}
+
+// Vars returns the variables in storage order.
+func (e *Env) Vars() []Var {
+	return e.vars
+}
+
+// Get returns the value of the variable called name.
+func (e *Env) Get(name string) (string, bool) {
+	for _, v := range e.vars {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of a variable. New variables are appended.
+func (e *Env) Set(name, value string) error {
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	if strings.ContainsRune(value, 0) {
+		return fmt.Errorf("%s: invalid NUL in value", name)
+	}
+	for i := range e.vars {
+		if e.vars[i].Name == name {
+			e.vars[i].Value = value
+			return nil
+		}
+	}
+	e.vars = append(e.vars, Var{Name: name, Value: value})
+	return nil
+}
+
+// Unset removes a variable and returns true if it was defined.
+func (e *Env) Unset(name string) bool {
+	for i, v := range e.vars {
+		if v.Name == name {
+			e.vars = append(e.vars[:i], e.vars[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalBinary encodes the block with its CRC. The data area is padded with
+// NUL bytes up to the size of the block.
+func (e *Env) MarshalBinary() ([]byte, error) {
+	block := make([]byte, e.Size)
+	hdr := e.headerSize()
+	if e.Size <= hdr {
+		return nil, fmt.Errorf("block too short (%d bytes)", e.Size)
+	}
+	off := hdr
+	for _, v := range e.vars {
+		s := v.String()
+		// Keep room for the terminating empty string.
+		if off+len(s)+2 > e.Size {
+			return nil, fmt.Errorf("environment does not fit in %d bytes", e.Size)
+		}
+		off += copy(block[off:], s) + 1
+	}
+	if e.Redundant {
+		block[4] = e.Flags
+	}
+	e.Order.PutUint32(block, crc32.ChecksumIEEE(block[hdr:]))
+	return block, nil
+}
+
+// Block is an environment block found in a flash dump.
+type Block struct {
+	Offset int64
+	Env    *Env
+}
+
+// Locate returns the environment blocks of a flash dump. Blocks are looked for
+// at every Alignment boundary, with every size of Sizes.
+func Locate(dump []byte) []Block {
+	var blocks []Block
+	for off := 0; off < len(dump); off += Alignment {
+		if !looksLikeEnv(dump[off:]) {
+			continue
+		}
+		for _, size := range Sizes {
+			if off+size > len(dump) {
+				break
+			}
+			if e, err := Parse(dump[off : off+size]); err == nil {
+				blocks = append(blocks, Block{Offset: int64(off), Env: e})
+				off += size - Alignment
+				break
+			}
+		}
+	}
+	return blocks
+}
+
+// looksLikeEnv returns true if a variable name followed by '=' starts the data
+// area of a plain or redundant block, to avoid computing CRCs everywhere.
+func looksLikeEnv(b []byte) bool {
+	for _, hdr := range []int{4, 5} {
+		for i := hdr; i < len(b) && i < hdr+64; i++ {
+			c := b[i]
+			if c == '=' && i > hdr {
+				return true
+			}
+			if c < 0x21 || c > 0x7e {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// Newer returns true if the redundant environment a was saved after b, as
+// U-Boot elects the valid copy from their flags: the flags wrap around from
+// 255 to 0.
+func Newer(a, b *Env) bool {
+	switch {
+	case a.Flags == 255 && b.Flags == 0:
+		return false
+	case a.Flags == 0 && b.Flags == 255:
+		return true
+	}
+	return a.Flags >= b.Flags
+}
+
+// Pair returns the index of the other copy of the redundant environment
+// blocks[i]: the other redundant block of the same size and byte order. It
+// returns -1 if there is none.
+func Pair(blocks []Block, i int) int {
+	e := blocks[i].Env
+	if !e.Redundant {
+		return -1
+	}
+	for j, b := range blocks {
+		if j != i && b.Env.Redundant && b.Env.Size == e.Size && b.Env.Order == e.Order {
+			return j
+		}
+	}
+	return -1
+}
+
+// Save writes the environment of blocks[i] to the dump and returns the offset
+// it was written at. Plain environments are written in place. Redundant
+// environments are saved as U-Boot does: the flags are incremented past the
+// ones of the other copy and the environment is written over the other copy,
+// the block it was read from being left untouched as the fallback. Without
+// other copy in the dump, it is written in place with incremented flags.
+func Save(dump []byte, blocks []Block, i int) (int64, error) {
+	b := blocks[i]
+	off := b.Offset
+	if b.Env.Redundant {
+		flags := b.Env.Flags
+		if j := Pair(blocks, i); j >= 0 {
+			if Newer(blocks[j].Env, b.Env) {
+				flags = blocks[j].Env.Flags
+			}
+			off = blocks[j].Offset
+		}
+		b.Env.Flags = flags + 1
+	}
+	data, err := b.Env.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	if off+int64(len(data)) > int64(len(dump)) {
+		return 0, fmt.Errorf("block at 0x%x overflows the dump", off)
+	}
+	copy(dump[off:], data)
+	return off, nil
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package ubootenv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func testEnv(t *testing.T, size int, redundant bool, order binary.ByteOrder) *Env {
+	e := New(size, redundant, order)
+	for _, v := range []Var{{"bootdelay", "3"}, {"bootcmd", "bootm 0xb4100000"}, {"ethaddr", "bc:cf:4f:00:11:22"}} {
+		if err := e.Set(v.Name, v.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Flags = 7
+	return e
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, redundant := range []bool{false, true} {
+		for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+			e := testEnv(t, 0x1000, redundant, order)
+			block, err := e.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Parse(block)
+			if err != nil {
+				t.Fatalf("redundant %v, %v: %v", redundant, order, err)
+			}
+			if got.Redundant != redundant || got.Order != order || !reflect.DeepEqual(got.Vars(), e.Vars()) {
+				t.Errorf("redundant %v, %v: got %+v", redundant, order, got)
+			}
+			if redundant && got.Flags != 7 {
+				t.Errorf("unexpected flags %d", got.Flags)
+			}
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	e := testEnv(t, 0x1000, false, binary.BigEndian)
+	e.Set("bootdelay", "0")
+	e.Set("ipaddr", "192.168.1.1")
+	if !e.Unset("ethaddr") || e.Unset("serverip") {
+		t.Errorf("unexpected Unset results")
+	}
+	want := []Var{{"bootdelay", "0"}, {"bootcmd", "bootm 0xb4100000"}, {"ipaddr", "192.168.1.1"}}
+	if !reflect.DeepEqual(e.Vars(), want) {
+		t.Errorf("got %v, want %v", e.Vars(), want)
+	}
+	if v, ok := e.Get("ipaddr"); !ok || v != "192.168.1.1" {
+		t.Errorf("unexpected ipaddr %q", v)
+	}
+	for _, name := range []string{"", "a=b"} {
+		if err := e.Set(name, "x"); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+
+	small := testEnv(t, 32, false, binary.BigEndian)
+	if _, err := small.MarshalBinary(); err == nil {
+		t.Errorf("expected an error on an environment too large for its block")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	block, err := testEnv(t, 0x1000, false, binary.BigEndian).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block[10] ^= 1
+	if _, err := Parse(block); err == nil {
+		t.Errorf("expected a CRC error")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	dump := bytes.Repeat([]byte{0xff}, 0x20000)
+	// Text looking like a variable, but without a valid CRC.
+	copy(dump[0x1000:], "\x00\x00\x00\x00ab=cd\x00\x00")
+	plain, _ := testEnv(t, 0x2000, false, binary.BigEndian).MarshalBinary()
+	copy(dump[0x4000:], plain)
+	redundant, _ := testEnv(t, 0x10000, true, binary.LittleEndian).MarshalBinary()
+	copy(dump[0x10000:], redundant)
+
+	blocks := Locate(dump)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	if b := blocks[0]; b.Offset != 0x4000 || b.Env.Size != 0x2000 || b.Env.Redundant {
+		t.Errorf("unexpected first block at 0x%x: %+v", b.Offset, b.Env)
+	}
+	if b := blocks[1]; b.Offset != 0x10000 || b.Env.Size != 0x10000 || !b.Env.Redundant {
+		t.Errorf("unexpected second block at 0x%x: %+v", b.Offset, b.Env)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dump := bytes.Repeat([]byte{0xff}, 0x30000)
+	plain, _ := testEnv(t, 0x1000, false, binary.BigEndian).MarshalBinary()
+	copy(dump[0x1000:], plain)
+	for i, flags := range []uint8{7, 6} {
+		e := testEnv(t, 0x10000, true, binary.BigEndian)
+		e.Flags = flags
+		b, _ := e.MarshalBinary()
+		copy(dump[0x10000*(i+1):], b)
+	}
+	blocks := Locate(dump)
+	if len(blocks) != 3 || Pair(blocks, 0) != -1 || Pair(blocks, 1) != 2 || Pair(blocks, 2) != 1 {
+		t.Fatalf("unexpected blocks %+v", blocks)
+	}
+	if !Newer(blocks[1].Env, blocks[2].Env) {
+		t.Errorf("flags 7 should be newer than 6")
+	}
+
+	// The plain environment is written in place.
+	blocks[0].Env.Set("bootdelay", "0")
+	if off, err := Save(dump, blocks, 0); err != nil || off != 0x1000 {
+		t.Errorf("plain environment saved at 0x%x: %v", off, err)
+	}
+
+	// The newest copy is saved over the other one, with the next flags.
+	blocks[1].Env.Set("bootdelay", "1")
+	if off, err := Save(dump, blocks, 1); err != nil || off != 0x20000 {
+		t.Errorf("redundant environment saved at 0x%x: %v", off, err)
+	}
+	saved := Locate(dump)
+	if len(saved) != 3 {
+		t.Fatalf("got %d blocks after save, want 3", len(saved))
+	}
+	want := []struct {
+		flags     uint8
+		bootdelay string
+	}{{0, "0"}, {7, "3"}, {8, "1"}}
+	for i, b := range saved {
+		if v, _ := b.Env.Get("bootdelay"); v != want[i].bootdelay || b.Env.Redundant && b.Env.Flags != want[i].flags {
+			t.Errorf("block %d: flags %d, bootdelay %s, want %d, %s", i, b.Env.Flags, v, want[i].flags, want[i].bootdelay)
+		}
+	}
+	if Newer(saved[1].Env, saved[2].Env) {
+		t.Errorf("the saved copy should be elected")
+	}
+
+	// Flags wrap around.
+	a, b := New(0x1000, true, binary.BigEndian), New(0x1000, true, binary.BigEndian)
+	a.Flags, b.Flags = 0, 255
+	if !Newer(a, b) || Newer(b, a) {
+		t.Errorf("flags 0 should be newer than 255")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package uimage decodes legacy U-Boot images (uImage), as used to ship the
+// kernel of Realtek based switches.
+package uimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// IH_MAGIC is the magic number of uImage headers (include/image.h).
+const IH_MAGIC = 0x27051956
+
+// HeaderSize is the size of the uImage header.
+const HeaderSize = 64
+
+// Operating system, architecture and type of the images (include/image.h).
+const (
+	IH_OS_LINUX    = 5
+	IH_ARCH_ARM    = 2
+	IH_ARCH_MIPS   = 5
+	IH_TYPE_KERNEL = 2
+)
+
+// Compression of the payload (include/image.h).
+type Compression uint8
+
+const (
+	IH_COMP_NONE Compression = iota
+	IH_COMP_GZIP
+	IH_COMP_BZIP2
+	IH_COMP_LZMA
+	IH_COMP_LZO
+	IH_COMP_LZ4
+	IH_COMP_ZSTD
+)
+
+func (c Compression) String() string {
+	switch c {
+	case IH_COMP_NONE:
+		return "none"
+	case IH_COMP_GZIP:
+		return "gzip"
+	case IH_COMP_BZIP2:
+		return "bzip2"
+	case IH_COMP_LZMA:
+		return "lzma"
+	case IH_COMP_LZO:
+		return "lzo"
+	case IH_COMP_LZ4:
+		return "lz4"
+	case IH_COMP_ZSTD:
+		return "zstd"
+	default:
+		return fmt.Sprintf("UNKNOWN (%d)", uint8(c))
+	}
+}
+
+// Header is the legacy image header (struct legacy_img_hdr), stored big
+// endian.
+type Header struct {
+	Magic       uint32
+	HeaderCRC   uint32
+	Time        uint32
+	Size        uint32
+	Load        uint32
+	Entry       uint32
+	DataCRC     uint32
+	OS          uint8
+	Arch        uint8
+	Type        uint8
+	Compression Compression
+	Name        [32]byte
+}
+
+// Image is a decoded uImage.
+type Image struct {
+	Header
+	// Data is the payload, still compressed.
+	Data []byte
+}
+
+// ImageName returns the name of the image.
+func (h *Header) ImageName() string {
+	name, _, _ := bytes.Cut(h.Name[:], []byte{0})
+	return string(name)
+}
+
+// Parse decodes the uImage at the start of data and verifies its checksums.
+// Trailing bytes are ignored.
+func Parse(data []byte) (*Image, error) {
+	return ParseMagic(data, IH_MAGIC)
+}
+
+// ParseMagic decodes a uImage whose header starts with magic instead of
+// IH_MAGIC, as the bootloaders of some vendors expect.
+func ParseMagic(data []byte, magic uint32) (*Image, error) {
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("image too short (%d bytes)", len(data))
+	}
+	im := &Image{}
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &im.Header); err != nil {
+		return nil, err
+	}
+	if im.Magic != magic {
+		return nil, fmt.Errorf("bad magic 0x%08x", im.Magic)
+	}
+	hdr := make([]byte, HeaderSize)
+	copy(hdr, data)
+	binary.BigEndian.PutUint32(hdr[4:], 0)
+	if crc := crc32.ChecksumIEEE(hdr); crc != im.HeaderCRC {
+		return nil, fmt.Errorf("header checksum 0x%08x, expected 0x%08x", crc, im.HeaderCRC)
+	}
+	if uint64(im.Size) > uint64(len(data)-HeaderSize) {
+		return nil, fmt.Errorf("truncated image: %d bytes of data, header says %d", len(data)-HeaderSize, im.Size)
+	}
+	im.Data = data[HeaderSize : HeaderSize+int(im.Size)]
+	if crc := crc32.ChecksumIEEE(im.Data); crc != im.DataCRC {
+		return nil, fmt.Errorf("data checksum 0x%08x, expected 0x%08x", crc, im.DataCRC)
+	}
+	return im, nil
+}
+
+// MarshalBinary encodes the image, computing the size and the checksums of
+// the header from the payload. A zero magic is encoded as IH_MAGIC.
+func (im *Image) MarshalBinary() ([]byte, error) {
+	h := im.Header
+	if h.Magic == 0 {
+		h.Magic = IH_MAGIC
+	}
+	h.Size = uint32(len(im.Data))
+	h.DataCRC = crc32.ChecksumIEEE(im.Data)
+	h.HeaderCRC = 0
+	var b bytes.Buffer
+	if err := binary.Write(&b, binary.BigEndian, &h); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(b.Bytes()[4:], crc32.ChecksumIEEE(b.Bytes()))
+	b.Write(im.Data)
+	return b.Bytes(), nil
+}
+
+// SetName sets the name of the image, truncated to the size of the header
+// field.
+func (h *Header) SetName(name string) {
+	h.Name = [32]byte{}
+	copy(h.Name[:len(h.Name)-1], name)
+}
+
+// Compress returns data compressed with comp, for the payload of an image.
+func Compress(comp Compression, data []byte) ([]byte, error) {
+	switch comp {
+	case IH_COMP_NONE:
+		return data, nil
+	case IH_COMP_LZMA:
+		var b bytes.Buffer
+		w, err := lzma.NewWriter(&b)
+		if err != nil {
+			return nil, fmt.Errorf("lzma: %v", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("lzma: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("lzma: %v", err)
+		}
+		return b.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", comp)
+	}
+}
+
+// Decompress returns the uncompressed payload.
+func (im *Image) Decompress() ([]byte, error) {
+	switch im.Compression {
+	case IH_COMP_NONE:
+		return im.Data, nil
+	case IH_COMP_LZMA:
+		r, err := lzma.NewReader(bytes.NewReader(im.Data))
+		if err != nil {
+			return nil, fmt.Errorf("lzma: %v", err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("lzma: %v", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", im.Compression)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package uimage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// build returns a uImage holding data, compressed with comp.
+func build(t *testing.T, data []byte, comp Compression) []byte {
+	payload, err := Compress(comp, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := &Image{
+		Header: Header{Load: 0x80000000, Entry: 0x80000400, OS: 5, Arch: 5, Type: 2, Compression: comp},
+		Data:   payload,
+	}
+	im.SetName("Linux Kernel Image")
+	b, err := im.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	kernel := bytes.Repeat([]byte("vmlinux "), 1024)
+	for _, comp := range []Compression{IH_COMP_NONE, IH_COMP_LZMA} {
+		t.Run(comp.String(), func(t *testing.T) {
+			// Trailing bytes are ignored.
+			im, err := Parse(append(build(t, kernel, comp), 0xff, 0xff))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if im.ImageName() != "Linux Kernel Image" || im.Load != 0x80000000 || im.Compression != comp {
+				t.Errorf("unexpected header: %+v", im.Header)
+			}
+			data, err := im.Decompress()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, kernel) {
+				t.Errorf("unexpected payload of %d bytes", len(data))
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	img := build(t, []byte("kernel"), IH_COMP_NONE)
+	tests := []struct {
+		name   string
+		mangle func([]byte) []byte
+		want   string
+	}{
+		{"Short", func(b []byte) []byte { return b[:HeaderSize-1] }, "too short"},
+		{"Magic", func(b []byte) []byte { b[0] = 0; return b }, "bad magic"},
+		{"HeaderCRC", func(b []byte) []byte { b[8] ^= 1; return b }, "header checksum"},
+		{"Truncated", func(b []byte) []byte { return b[:len(b)-1] }, "truncated"},
+		{"DataCRC", func(b []byte) []byte { b[HeaderSize] ^= 1; return b }, "data checksum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.mangle(bytes.Clone(img)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	img := build(t, []byte("kernel"), IH_COMP_LZMA)
+	im, err := Parse(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := im.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, img) {
+		t.Errorf("re-encoded image differs")
+	}
+
+	// Vendor magics are kept.
+	im.Magic = 0x83800000
+	if b, err = im.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(b); err == nil || !strings.Contains(err.Error(), "bad magic") {
+		t.Errorf("expected a bad magic error, got %v", err)
+	}
+	if vendor, err := ParseMagic(b, 0x83800000); err != nil || !bytes.Equal(vendor.Data, im.Data) {
+		t.Errorf("unexpected vendor image: %v", err)
+	}
+}
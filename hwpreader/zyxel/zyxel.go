@@ -0,0 +1,304 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+// Package zyxel splits the firmware images of the Zyxel switches built on
+// Realtek SoCs into their components: the kernel uImage and the squashfs root
+// filesystem.
+//
+// The layout is the one of the images the OpenWrt realtek target builds for
+// these switches to be accepted by the vendor firmware upgrade
+// (target/linux/realtek/image: Build/zyxel-vers and the UIMAGE_MAGIC of the
+// zyxel_gs1900 devices). The image starts with the kernel uImage, whose magic
+// is either IH_MAGIC or the one the vendor U-Boot checks, 0x83800000 on the
+// GS1900 series. The payload of the uImage is the compressed kernel followed
+// by the version trailer checked by the vendor upgrade, a firmware version
+// per supported hardware, the hardware code in parentheses, then a date:
+//
+//	VERS\n
+//	V9.99(AAZI.0) | V9.99(AAHH.0) | 12/12/2012\n
+//
+// The squashfs root filesystem, if any, starts at the first 64 KiB boundary
+// after the uImage, the gap being zero filled.
+//
+// Images of the whole flash start with the bootloader and its environment
+// instead, the firmware following them at a 64 KiB boundary. No such image
+// was available to check where the vendors put it: the firmware is the first
+// valid uImage found at these boundaries.
+package zyxel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"xioxoz.fr/hwpreader/uimage"
+)
+
+const (
+	// GS1900_MAGIC is the uImage magic of the GS1900 series.
+	GS1900_MAGIC = 0x83800000
+	// RootfsAlign is the alignment of the root filesystem in the image.
+	RootfsAlign = 0x10000
+	// Start of the version trailer.
+	trailerMagic = "VERS\n"
+)
+
+// Magics are the uImage magics of the firmware images.
+var Magics = []uint32{uimage.IH_MAGIC, GS1900_MAGIC}
+
+// Squashfs magic numbers, little and big endian.
+var squashfsMagics = []string{"hsqs", "sqsh"}
+
+// Firmware is a decoded firmware image.
+type Firmware struct {
+	// Versions are the firmware versions of the supported hardware, e.g.
+	// V9.99(AAZI.0).
+	Versions []string
+	// Date is the date of the version trailer.
+	Date string
+	// Kernel is the kernel uImage, its payload without the version trailer.
+	Kernel *uimage.Image
+	// Rootfs is the root filesystem and its padding, nil if the image has
+	// none.
+	Rootfs []byte
+	// Bootloader is what precedes the kernel in an image of the whole flash,
+	// nil if the image starts with the kernel.
+	Bootloader []byte
+}
+
+// IsFirmware returns true if data starts with the magic of firmware images.
+func IsFirmware(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	magic := binary.BigEndian.Uint32(data)
+	for _, m := range Magics {
+		if magic == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the offset of the kernel in data: 0 if data starts with the
+// magic of firmware images, else the first 64 KiB boundary holding a valid
+// uImage of these magics, or -1 if there is none.
+func Find(data []byte) int {
+	if IsFirmware(data) {
+		return 0
+	}
+	for off := RootfsAlign; off+uimage.HeaderSize <= len(data); off += RootfsAlign {
+		if !IsFirmware(data[off:]) {
+			continue
+		}
+		if _, err := uimage.ParseMagic(data[off:], binary.BigEndian.Uint32(data[off:])); err == nil {
+			return off
+		}
+	}
+	return -1
+}
+
+// Parse decodes a firmware image and verifies its checksums.
+func Parse(data []byte) (*Firmware, error) {
+	off := Find(data)
+	if off < 0 {
+		return nil, fmt.Errorf("not a Zyxel firmware image")
+	}
+	fw := &Firmware{}
+	if off > 0 {
+		fw.Bootloader = data[:off]
+		data = data[off:]
+	}
+	k, err := uimage.ParseMagic(data, binary.BigEndian.Uint32(data))
+	if err != nil {
+		return nil, fmt.Errorf("kernel: %v", err)
+	}
+	fw.Kernel = k
+	i := bytes.LastIndex(k.Data, []byte(trailerMagic))
+	if i < 0 {
+		return nil, fmt.Errorf("kernel: no version trailer")
+	}
+	if err := fw.parseTrailer(k.Data[i+len(trailerMagic):]); err != nil {
+		return nil, fmt.Errorf("version trailer: %v", err)
+	}
+	k.Data = k.Data[:i]
+
+	end := uimage.HeaderSize + int(k.Size)
+	if end == len(data) {
+		return fw, nil
+	}
+	start := align(end)
+	if start >= len(data) {
+		return nil, fmt.Errorf("%d bytes after the kernel, expected a root filesystem at 0x%x", len(data)-end, off+start)
+	}
+	for _, b := range data[end:start] {
+		if b != 0 {
+			return nil, fmt.Errorf("padding after the kernel is not zero filled")
+		}
+	}
+	fw.Rootfs = data[start:]
+	if !isSquashfs(fw.Rootfs) {
+		return nil, fmt.Errorf("rootfs at 0x%x: not a squashfs image", off+start)
+	}
+	return fw, nil
+}
+
+// parseTrailer decodes the lines of the version trailer following its magic.
+func (fw *Firmware) parseTrailer(b []byte) error {
+	line, ok := strings.CutSuffix(string(b), "\n")
+	if !ok || strings.Contains(line, "\n") {
+		return fmt.Errorf("expected a single line, got %q", b)
+	}
+	parts := strings.Split(line, " | ")
+	fw.Date = parts[len(parts)-1]
+	fw.Versions = parts[:len(parts)-1]
+	if len(fw.Versions) == 0 {
+		return fmt.Errorf("no version in %q", line)
+	}
+	for _, v := range fw.Versions {
+		if Hardware(v) == "" {
+			return fmt.Errorf("no hardware code in version %q", v)
+		}
+	}
+	return nil
+}
+
+// Hardware returns the hardware code of a firmware version, e.g. AAZI for
+// V9.99(AAZI.0), or "" if it has none.
+func Hardware(version string) string {
+	_, code, ok := strings.Cut(version, "(")
+	if !ok || !strings.HasSuffix(code, ")") {
+		return ""
+	}
+	code, _, _ = strings.Cut(strings.TrimSuffix(code, ")"), ".")
+	return code
+}
+
+func isSquashfs(data []byte) bool {
+	for _, m := range squashfsMagics {
+		if bytes.HasPrefix(data, []byte(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+func align(n int) int {
+	return (n + RootfsAlign - 1) &^ (RootfsAlign - 1)
+}
+
+// trailer returns the version trailer of the firmware.
+func (fw *Firmware) trailer() []byte {
+	var b strings.Builder
+	b.WriteString(trailerMagic)
+	for _, v := range fw.Versions {
+		b.WriteString(v + " | ")
+	}
+	b.WriteString(fw.Date + "\n")
+	return []byte(b.String())
+}
+
+// MarshalBinary encodes the firmware image: the bootloader if any, the kernel
+// uImage with the version trailer appended to its payload, then the root
+// filesystem at the next aligned offset. All the checksums are computed.
+func (fw *Firmware) MarshalBinary() ([]byte, error) {
+	if fw.Kernel == nil {
+		return nil, fmt.Errorf("no kernel")
+	}
+	if len(fw.Versions) == 0 {
+		return nil, fmt.Errorf("no firmware version")
+	}
+	for _, v := range fw.Versions {
+		if Hardware(v) == "" || strings.ContainsAny(v, "|\n") {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+	}
+	if strings.ContainsAny(fw.Date, "|\n") {
+		return nil, fmt.Errorf("invalid date %q", fw.Date)
+	}
+	if fw.Rootfs != nil && !isSquashfs(fw.Rootfs) {
+		return nil, fmt.Errorf("rootfs: not a squashfs image")
+	}
+	if len(fw.Bootloader)%RootfsAlign != 0 {
+		return nil, fmt.Errorf("bootloader of %d bytes, not a multiple of 0x%x", len(fw.Bootloader), RootfsAlign)
+	}
+	k := *fw.Kernel
+	k.Data = append(bytes.Clone(k.Data), fw.trailer()...)
+	img, err := k.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if fw.Rootfs != nil {
+		img = append(img, make([]byte, align(len(img))-len(img))...)
+		img = append(img, fw.Rootfs...)
+	}
+	return append(bytes.Clone(fw.Bootloader), img...), nil
+}
+
+// DefaultLoad is the default load address and entry point of raw kernels.
+const DefaultLoad = 0x80000000
+
+// Settings are the settings of a packed image. The zero fields are taken
+// from the reference image when there is one, else from the kernel uImage or
+// the defaults.
+type Settings struct {
+	Versions []string
+	Date     string
+	// Magic, load address and entry point of the kernel uImage.
+	Magic, Load, Entry uint32
+}
+
+// Pack builds the firmware image of a kernel, raw or uImage, and of a root
+// filesystem, or nil. ref is the image to take the settings from, or nil. A
+// raw kernel is LZMA compressed in a uImage whose header is the one of the
+// kernel of ref, the non zero settings overriding it. A uImage kernel keeps
+// its payload and takes the magic, load address and entry point of ref and
+// of the settings, its checksums being computed again when the image is
+// encoded.
+func Pack(kernel, rootfs []byte, ref *Firmware, s Settings) (*Firmware, error) {
+	fw := &Firmware{Versions: s.Versions, Date: s.Date, Rootfs: rootfs}
+	hdr := uimage.Header{
+		Magic: uimage.IH_MAGIC, Load: DefaultLoad, Entry: DefaultLoad,
+		OS: uimage.IH_OS_LINUX, Arch: uimage.IH_ARCH_MIPS, Type: uimage.IH_TYPE_KERNEL,
+	}
+	hdr.SetName("Linux Kernel Image")
+	var k *uimage.Image
+	if IsFirmware(kernel) {
+		var err error
+		if k, err = uimage.ParseMagic(kernel, binary.BigEndian.Uint32(kernel)); err != nil {
+			return nil, fmt.Errorf("kernel: %v", err)
+		}
+		hdr = k.Header
+	}
+	if ref != nil {
+		if fw.Versions == nil {
+			fw.Versions = ref.Versions
+		}
+		if fw.Date == "" {
+			fw.Date = ref.Date
+		}
+		hdr = ref.Kernel.Header
+	}
+	if s.Magic != 0 {
+		hdr.Magic = s.Magic
+	}
+	if s.Load != 0 {
+		hdr.Load = s.Load
+	}
+	if s.Entry != 0 {
+		hdr.Entry = s.Entry
+	}
+
+	if k != nil {
+		k.Magic, k.Load, k.Entry = hdr.Magic, hdr.Load, hdr.Entry
+		fw.Kernel = k
+		return fw, nil
+	}
+	hdr.Compression = uimage.IH_COMP_LZMA
+	data, err := uimage.Compress(hdr.Compression, kernel)
+	if err != nil {
+		return nil, fmt.Errorf("kernel: %v", err)
+	}
+	fw.Kernel = &uimage.Image{Header: hdr, Data: data}
+	return fw, nil
+}
@@ -0,0 +1,274 @@
+// Copyright (C) 2025 - Damien Dejean <dam.dejean@gmail.com>
+
+package zyxel
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"xioxoz.fr/hwpreader/uimage"
+)
+
+// sample is the head of an initramfs image for the GS1900-10HP and GS1900-8,
+// laid out as the OpenWrt recipe kernel-bin | append-dtb | lzma | zyxel-vers |
+// uImage lzma with the GS1900 uImage magic builds it. The LZMA stream is cut
+// after its properties.
+const sample = "" +
+	"\x83\x80\x00\x00\x35\x12\x15\xa8\x67\xf0\x00\x00\x00\x00\x00\x35" +
+	"\x80\x10\x00\x00\x80\x10\x00\x00\xb7\xde\xcf\x48\x05\x05\x02\x03" +
+	"\x4d\x49\x50\x53\x20\x4f\x70\x65\x6e\x57\x72\x74\x20\x4c\x69\x6e" +
+	"\x75\x78\x2d\x36\x2e\x36\x2e\x38\x36\x00\x00\x00\x00\x00\x00\x00" +
+	"\x5d\x00\x00\x80\x00\x56\x45\x52\x53\x0a\x56\x39\x2e\x39\x39\x28" +
+	"\x41\x41\x5a\x49\x2e\x30\x29\x20\x7c\x20\x56\x39\x2e\x39\x39\x28" +
+	"\x41\x41\x48\x48\x2e\x30\x29\x20\x7c\x20\x31\x32\x2f\x31\x32\x2f" +
+	"\x32\x30\x31\x32\x0a"
+
+// build returns a firmware image of a LZMA compressed kernel and a root
+// filesystem, independently of MarshalBinary.
+func build(t *testing.T, kernel, rootfs []byte) []byte {
+	payload, err := uimage.Compress(uimage.IH_COMP_LZMA, kernel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &uimage.Image{
+		Header: uimage.Header{Magic: GS1900_MAGIC, Load: 0x80000000, Entry: 0x80000400, OS: uimage.IH_OS_LINUX, Arch: uimage.IH_ARCH_MIPS, Type: uimage.IH_TYPE_KERNEL, Compression: uimage.IH_COMP_LZMA},
+		Data:   append(payload, "VERS\nV9.99(AAZI.0) | 12/12/2012\n"...),
+	}
+	img, err := k.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rootfs != nil {
+		img = append(img, make([]byte, RootfsAlign-len(img)%RootfsAlign)...)
+		img = append(img, rootfs...)
+	}
+	return img
+}
+
+func TestParseSample(t *testing.T) {
+	fw, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"V9.99(AAZI.0)", "V9.99(AAHH.0)"}; strings.Join(fw.Versions, ",") != strings.Join(want, ",") || fw.Date != "12/12/2012" {
+		t.Errorf("unexpected versions %q of %q", fw.Versions, fw.Date)
+	}
+	k := fw.Kernel
+	if k.Magic != GS1900_MAGIC || k.Load != 0x80100000 || k.Compression != uimage.IH_COMP_LZMA || k.ImageName() != "MIPS OpenWrt Linux-6.6.86" {
+		t.Errorf("unexpected kernel header %+v", k.Header)
+	}
+	if !bytes.Equal(k.Data, []byte("\x5d\x00\x00\x80\x00")) || fw.Rootfs != nil {
+		t.Errorf("unexpected components: kernel %x, rootfs %x", k.Data, fw.Rootfs)
+	}
+	b, err := fw.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != sample {
+		t.Errorf("re-encoded image differs from the sample")
+	}
+}
+
+func TestParse(t *testing.T) {
+	kernel := bytes.Repeat([]byte("vmlinux "), 1024)
+	rootfs := []byte("hsqs rootfs")
+	img := build(t, kernel, rootfs)
+	fw, err := Parse(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fw.Versions) != 1 || Hardware(fw.Versions[0]) != "AAZI" {
+		t.Errorf("unexpected versions %q", fw.Versions)
+	}
+	data, err := fw.Kernel.Decompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, kernel) || !bytes.Equal(fw.Rootfs, rootfs) {
+		t.Errorf("unexpected components")
+	}
+	b, err := fw.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, img) {
+		t.Errorf("re-encoded image differs from the reference")
+	}
+
+	fw.Versions = append(fw.Versions, "V9.99(AAHH.0)")
+	if b, err = fw.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if fw, err = Parse(b); err != nil || len(fw.Versions) != 2 || !bytes.Equal(fw.Rootfs, rootfs) {
+		t.Errorf("unexpected image with a new version: %v", err)
+	}
+	fw.Versions = []string{"V9.99"}
+	if _, err := fw.MarshalBinary(); err == nil {
+		t.Errorf("expected an error on a version without hardware code")
+	}
+}
+
+func TestParseBootloader(t *testing.T) {
+	// An image of the whole flash: the bootloader, a stray magic in its
+	// environment not starting a valid uImage, then the sample firmware.
+	boot := bytes.Repeat([]byte{0xff}, 4*RootfsAlign)
+	copy(boot, "U-Boot")
+	copy(boot[2*RootfsAlign:], sample[:4])
+	flash := append(bytes.Clone(boot), sample...)
+	if off := Find(flash); off != len(boot) {
+		t.Fatalf("kernel found at 0x%x, want 0x%x", off, len(boot))
+	}
+	fw, err := Parse(flash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fw.Bootloader, boot) || fw.Kernel.ImageName() != "MIPS OpenWrt Linux-6.6.86" || fw.Date != "12/12/2012" {
+		t.Errorf("unexpected components of the flash image")
+	}
+	b, err := fw.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, flash) {
+		t.Errorf("re-encoded flash image differs")
+	}
+
+	// An upgrade image has no bootloader.
+	if fw, err = Parse([]byte(sample)); err != nil || fw.Bootloader != nil {
+		t.Errorf("unexpected bootloader %x: %v", fw.Bootloader, err)
+	}
+	if Find(boot) != -1 {
+		t.Errorf("firmware found in the bootloader alone")
+	}
+	fw.Bootloader = []byte("U-Boot")
+	if _, err := fw.MarshalBinary(); err == nil || !strings.Contains(err.Error(), "bootloader") {
+		t.Errorf("expected an error on an unaligned bootloader, got %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	img := build(t, []byte("vmlinux"), []byte("hsqs rootfs"))
+	kernelEnd := bytes.Index(img, []byte("2012\n")) + 5
+	tests := []struct {
+		name   string
+		mangle func([]byte) []byte
+		want   string
+	}{
+		{"Magic", func(b []byte) []byte { b[0] = 'X'; return b }, "not a Zyxel firmware"},
+		{"HeaderCRC", func(b []byte) []byte { b[0x10] ^= 1; return b }, "header checksum"},
+		{"DataCRC", func(b []byte) []byte { b[uimage.HeaderSize] ^= 1; return b }, "data checksum"},
+		{"Padding", func(b []byte) []byte { b[kernelEnd] = 0xff; return b }, "not zero filled"},
+		{"Rootfs", func(b []byte) []byte { b[len(b)-len("hsqs rootfs")] = 'X'; return b }, "not a squashfs"},
+		{"Truncated", func(b []byte) []byte { return b[:kernelEnd+1] }, "expected a root filesystem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.mangle(bytes.Clone(img)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// A uImage without version trailer.
+	k := &uimage.Image{Data: []byte("vmlinux")}
+	b, _ := k.MarshalBinary()
+	if _, err := Parse(b); err == nil || !strings.Contains(err.Error(), "no version trailer") {
+		t.Errorf("expected a trailer error, got %v", err)
+	}
+}
+
+func TestPack(t *testing.T) {
+	kernel := bytes.Repeat([]byte("vmlinux "), 1024)
+	rootfs := []byte("hsqs rootfs")
+	img := build(t, kernel, rootfs)
+	ref, err := Parse(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Repacking the content of an image with its settings gives it back.
+	fw, err := Pack(kernel, rootfs, ref, Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := fw.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, img) {
+		t.Errorf("repacked image differs from the reference")
+	}
+
+	// Explicit settings override the reference.
+	fw, err = Pack(kernel, nil, ref, Settings{Versions: []string{"V1.00(AAHH.0)"}, Load: 0x80100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := fw.Kernel.Header; h.Magic != GS1900_MAGIC || h.Load != 0x80100000 || h.Entry != 0x80000400 || fw.Versions[0] != "V1.00(AAHH.0)" || fw.Date != "12/12/2012" {
+		t.Errorf("unexpected settings %+v of %q %q", h, fw.Versions, fw.Date)
+	}
+
+	// Without reference, the defaults.
+	fw, err = Pack(kernel, nil, nil, Settings{Versions: []string{"V1.00(AAZI.0)"}, Entry: 0x80000400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := fw.Kernel.Header; h.Magic != uimage.IH_MAGIC || h.Load != DefaultLoad || h.Entry != 0x80000400 || h.Compression != uimage.IH_COMP_LZMA {
+		t.Errorf("unexpected default header %+v", h)
+	}
+
+	// A uImage keeps its payload and takes the magic, load address and entry
+	// point of the reference and of the settings.
+	u := &uimage.Image{Header: uimage.Header{Magic: uimage.IH_MAGIC, Load: 0x80000000, Entry: 0x80000000, Compression: uimage.IH_COMP_LZMA}, Data: ref.Kernel.Data}
+	u.SetName("uImage")
+	k, err := u.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw, err = Pack(k, rootfs, ref, Settings{Load: 0x80100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := fw.Kernel.Header; h.Magic != GS1900_MAGIC || h.Load != 0x80100000 || h.Entry != 0x80000400 || h.ImageName() != "uImage" || !bytes.Equal(fw.Kernel.Data, ref.Kernel.Data) {
+		t.Errorf("unexpected header %+v of a uImage", h)
+	}
+	if b, err = fw.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(b); err != nil {
+		t.Errorf("image of a uImage: %v", err)
+	}
+
+	// Without reference nor settings, a uImage is kept as is.
+	if fw, err = Pack(k, nil, nil, Settings{Versions: []string{"V1.00(AAZI.0)"}}); err != nil || fw.Kernel.Magic != u.Magic || fw.Kernel.Load != u.Load || fw.Kernel.Entry != u.Entry {
+		t.Errorf("unexpected kernel of a uImage: %v", err)
+	}
+}
+
+func TestPackSample(t *testing.T) {
+	ref, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The kernel uImage of the sample with the magic of a generic uImage is
+	// packed back to the sample: GS1900 magic, version trailer and checksums.
+	k := *ref.Kernel
+	k.Magic = uimage.IH_MAGIC
+	b, err := k.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw, err := Pack(b, nil, nil, Settings{Versions: ref.Versions, Date: ref.Date, Magic: GS1900_MAGIC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err = fw.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != sample {
+		t.Errorf("packed image differs from the sample")
+	}
+}